@@ -2,51 +2,637 @@
 package badger
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
 	"time"
 
-	"github.com/dgraph-io/badger"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/internal/loop"
+	"github.com/gookit/goutil/mathutil"
+	"github.com/gookit/gsr"
 )
 
 // Name driver name
 const Name = "badger"
 
+// Memory use as the dir for open an in-memory db, data will not persist to disk.
+const Memory = ":memory:"
+
+// default settings for the value log GC
+const (
+	DefaultGCInterval     = 5 * time.Minute
+	DefaultGCDiscardRatio = 0.5
+)
+
+// max retry times on transaction conflict
+const maxRetries = 100
+
 // BadgerDB definition
 type BadgerDB struct {
+	cache.BaseDriver
 	db *badger.DB
+	// value log GC runner
+	gc *loop.Runner
+}
+
+// NewMemory new an in-memory badger db
+func NewMemory(optFns ...func(option *cache.Option)) (*BadgerDB, error) {
+	return New(Memory, optFns...)
+}
+
+// New a BadgerDB instance. if dir is empty or Memory, will open an in-memory db.
+//
+// Usage:
+//
+//	c, err := badger.New("path/to/dir", cache.WithPrefix("app:"))
+func New(dir string, optFns ...func(option *cache.Option)) (*BadgerDB, error) {
+	var opts badger.Options
+	if dir == "" || dir == Memory {
+		opts = badger.DefaultOptions("").WithInMemory(true)
+	} else {
+		opts = badger.DefaultOptions(dir)
+	}
+
+	return NewWithOptions(opts, optFns...)
+}
+
+// NewWithOptions new a BadgerDB instance by custom badger.Options.
+//
+// NOTICE: the badger logger will be disabled if the debug option is false.
+// the value log GC goroutine will be started by DefaultGCInterval, if the db is not in-memory.
+func NewWithOptions(opts badger.Options, optFns ...func(option *cache.Option)) (*BadgerDB, error) {
+	c := &BadgerDB{gc: new(loop.Runner)}
+	c.SetName(Name)
+	c.WithOptions(optFns...)
+
+	if c.HasLogger() {
+		opts = opts.WithLogger(badgerLogger{c: c})
+	} else if !c.IsDebug() {
+		opts = opts.WithLogger(nil)
+	}
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.db = db
+	if !opts.InMemory && !opts.ReadOnly {
+		c.StartGC(DefaultGCInterval, DefaultGCDiscardRatio)
+	}
+	return c, nil
+}
+
+// Db get the badger db
+func (c *BadgerDB) Db() *badger.DB {
+	return c.db
+}
+
+// StartGC start a goroutine for run the value log GC periodically, it will be stopped by Close().
+// if the GC goroutine is running, it will be restarted with new settings.
+func (c *BadgerDB) StartGC(interval time.Duration, discardRatio float64) {
+	c.gc.Start(interval, func() {
+		// one call only rewrite one log file at most, so repeat it until nothing to rewrite.
+		for {
+			if err := c.db.RunValueLogGC(discardRatio); err != nil {
+				if err != badger.ErrNoRewrite {
+					c.LogErr("value log GC failed", err)
+				}
+				return
+			}
+		}
+	})
+}
+
+// StopGC stop the value log GC goroutine, and wait it exited.
+func (c *BadgerDB) StopGC() {
+	c.gc.Stop()
+}
+
+/*************************************************************
+ * methods implements of the gsr.SimpleCacher
+ *************************************************************/
+
+// WithContext returns a copy of the driver for operate with ctx.
+// the ctx is checked before each transaction, and between the items of multi operations.
+func (c *BadgerDB) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *c
+	cp.SetContext(ctx)
+	return &cp
 }
 
+// Has cache key
 func (c *BadgerDB) Has(key string) bool {
-	panic("implement me")
+	err := c.view(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(c.Key(key)))
+		return err
+	})
+	return err == nil
 }
 
+// Get value by key
 func (c *BadgerDB) Get(key string) any {
-	panic("implement me")
+	var val any
+	err := c.view(func(txn *badger.Txn) (err error) {
+		val, err = c.get(txn, c.Key(key))
+		return err
+	})
+
+	if err != nil {
+		if err != badger.ErrKeyNotFound {
+			c.SetOpErr("Get", key, err)
+		}
+		return nil
+	}
+	return val
 }
 
+// Set value by key
 func (c *BadgerDB) Set(key string, val any, ttl time.Duration) (err error) {
-	panic("implement me")
+	bts, err := c.MustMarshal(val)
+	if err != nil {
+		return err
+	}
+
+	return c.update(func(txn *badger.Txn) error {
+		return txn.SetEntry(newEntry(c.Key(key), bts, ttl))
+	})
 }
 
+// Del value by key
 func (c *BadgerDB) Del(key string) error {
-	panic("implement me")
+	return c.update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(c.Key(key)))
+	})
 }
 
+// GetMulti values by multi key. the not exists keys will be ignored.
 func (c *BadgerDB) GetMulti(keys []string) map[string]any {
-	panic("implement me")
+	results := make(map[string]any, len(keys))
+	err := c.view(func(txn *badger.Txn) error {
+		for _, key := range keys {
+			if err := c.ContextErr(); err != nil {
+				return err
+			}
+
+			val, err := c.get(txn, c.Key(key))
+			if err != nil {
+				if err == badger.ErrKeyNotFound {
+					continue
+				}
+				return err
+			}
+			results[key] = val
+		}
+		return nil
+	})
+
+	if err != nil {
+		c.SetOpErr("GetMulti", "", err)
+		return nil
+	}
+	return results
 }
 
+// SetMulti values by multi key. will write by the badger.WriteBatch
 func (c *BadgerDB) SetMulti(values map[string]any, ttl time.Duration) (err error) {
-	panic("implement me")
+	if err = c.ContextErr(); err != nil {
+		return err
+	}
+
+	wb := c.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for key, val := range values {
+		if err = c.ContextErr(); err != nil {
+			return err
+		}
+
+		bts, err := c.MustMarshal(val)
+		if err != nil {
+			return err
+		}
+
+		if err = wb.SetEntry(newEntry(c.Key(key), bts, ttl)); err != nil {
+			return err
+		}
+	}
+
+	return wb.Flush()
 }
 
+// DelMulti values by multi key. will delete by the badger.WriteBatch
 func (c *BadgerDB) DelMulti(keys []string) error {
-	panic("implement me")
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+
+	wb := c.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, key := range keys {
+		if err := c.ContextErr(); err != nil {
+			return err
+		}
+		if err := wb.Delete([]byte(c.Key(key))); err != nil {
+			return err
+		}
+	}
+
+	return wb.Flush()
 }
 
+// Clear all cache data. if the prefix option is set, only delete the keys with prefix.
 func (c *BadgerDB) Clear() error {
-	panic("implement me")
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+
+	prefix := c.Key("")
+	if prefix == "" {
+		return c.db.DropAll()
+	}
+	return c.clear(prefix)
+}
+
+// ClearPrefix delete the caches which key has the prefix, the driver prefix will be added.
+func (c *BadgerDB) ClearPrefix(prefix string) error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+	return c.clear(c.Key(prefix))
 }
 
+// delete the keys by the real key prefix
+func (c *BadgerDB) clear(prefix string) error {
+	// collect the keys with prefix, then delete them by batch
+	var keys [][]byte
+	err := c.view(func(txn *badger.Txn) error {
+		opt := badger.DefaultIteratorOptions
+		opt.PrefetchValues = false
+		opt.Prefix = []byte(prefix)
+
+		it := txn.NewIterator(opt)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	wb := c.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, key := range keys {
+		if err := c.ContextErr(); err != nil {
+			return err
+		}
+		if err := wb.Delete(key); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// Close the GC goroutine and the db
 func (c *BadgerDB) Close() error {
+	c.StopGC()
 	return c.db.Close()
 }
+
+/*************************************************************
+ * methods implements of the cache.Counter
+ *************************************************************/
+
+// Incr increment the key value by 1
+func (c *BadgerDB) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1
+func (c *BadgerDB) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta.
+// the ttl is only used when the key is created.
+func (c *BadgerDB) IncrBy(key string, delta int64, ttl ...time.Duration) (num int64, err error) {
+	err = c.update(func(txn *badger.Txn) error {
+		val, exp, err := c.loadForUpdate(txn, c.Key(key))
+		if err != nil {
+			return err
+		}
+
+		num = 0
+		if val != nil {
+			if num, err = mathutil.ToInt64(val); err != nil {
+				return cache.ErrNotNumber
+			}
+		} else {
+			exp = expiresAt(cache.CreateTTL(ttl))
+		}
+
+		num += delta
+		return c.save(txn, c.Key(key), num, exp)
+	})
+	return
+}
+
+// IncrByFloat increment the key value by float delta.
+// the ttl is only used when the key is created.
+func (c *BadgerDB) IncrByFloat(key string, delta float64, ttl ...time.Duration) (num float64, err error) {
+	err = c.update(func(txn *badger.Txn) error {
+		val, exp, err := c.loadForUpdate(txn, c.Key(key))
+		if err != nil {
+			return err
+		}
+
+		num = 0
+		if val != nil {
+			if num, err = mathutil.ToFloat(val); err != nil {
+				return cache.ErrNotNumber
+			}
+		} else {
+			exp = expiresAt(cache.CreateTTL(ttl))
+		}
+
+		num += delta
+		return c.save(txn, c.Key(key), num, exp)
+	})
+	return
+}
+
+/*************************************************************
+ * methods implements of the cache.ConditionalSetter
+ *************************************************************/
+
+// Add set the key value only if the key does not exist
+func (c *BadgerDB) Add(key string, val any, ttl time.Duration) (ok bool, err error) {
+	err = c.update(func(txn *badger.Txn) error {
+		ok = false
+		_, err := txn.Get([]byte(c.Key(key)))
+		if err != badger.ErrKeyNotFound {
+			return err
+		}
+
+		ok = true
+		return c.save(txn, c.Key(key), val, expiresAt(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// Replace set the key value only if the key already exists
+func (c *BadgerDB) Replace(key string, val any, ttl time.Duration) (ok bool, err error) {
+	err = c.update(func(txn *badger.Txn) error {
+		ok = false
+		if _, err := txn.Get([]byte(c.Key(key))); err != nil {
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			return err
+		}
+
+		ok = true
+		return c.save(txn, c.Key(key), val, expiresAt(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (c *BadgerDB) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (ok bool, err error) {
+	err = c.update(func(txn *badger.Txn) error {
+		ok = false
+		val, err := c.get(txn, c.Key(key))
+		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			return err
+		}
+
+		if !c.EqualValue(val, oldVal) {
+			return nil
+		}
+
+		ok = true
+		return c.save(txn, c.Key(key), newVal, expiresAt(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal
+func (c *BadgerDB) CompareAndDelete(key string, oldVal any) (ok bool, err error) {
+	err = c.update(func(txn *badger.Txn) error {
+		ok = false
+		val, err := c.get(txn, c.Key(key))
+		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			return err
+		}
+
+		if !c.EqualValue(val, oldVal) {
+			return nil
+		}
+
+		ok = true
+		return txn.Delete([]byte(c.Key(key)))
+	})
+	return ok && err == nil, err
+}
+
+/*************************************************************
+ * methods implements of the cache.TTLer
+ *************************************************************/
+
+// TTL get the remaining time to live of the key
+func (c *BadgerDB) TTL(key string) (ttl time.Duration, err error) {
+	err = c.view(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(c.Key(key)))
+		if err != nil {
+			return err
+		}
+
+		ttl = cache.Forever
+		if exp := item.ExpiresAt(); exp > 0 {
+			// at least 1 second, the key is still exists
+			if ttl = time.Until(time.Unix(int64(exp), 0)); ttl < time.Second {
+				ttl = time.Second
+			}
+		}
+		return nil
+	})
+
+	if err == badger.ErrKeyNotFound {
+		return 0, cache.ErrNotFound
+	}
+	return
+}
+
+// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration.
+func (c *BadgerDB) Expire(key string, ttl time.Duration) error {
+	err := c.update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(c.Key(key)))
+		if err != nil {
+			return err
+		}
+
+		bts, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		return txn.SetEntry(newEntry(c.Key(key), bts, ttl))
+	})
+
+	if err == badger.ErrKeyNotFound {
+		return cache.ErrNotFound
+	}
+	return err
+}
+
+// Persist remove the key expiration
+func (c *BadgerDB) Persist(key string) error {
+	return c.Expire(key, cache.Forever)
+}
+
+// Touch refresh the key expiration to ttl from now
+func (c *BadgerDB) Touch(key string, ttl time.Duration) error {
+	if err := c.Expire(key, ttl); err != cache.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+/*************************************************************
+ * helper methods
+ *************************************************************/
+
+// get and unmarshal the value of the real key
+func (c *BadgerDB) get(txn *badger.Txn, key string) (val any, err error) {
+	item, err := txn.Get([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+
+	err = item.Value(func(bts []byte) error {
+		return c.UnmarshalTo(bts, &val)
+	})
+	return
+}
+
+// load the key value for update, the returned expire time is 0 if the key not exists.
+func (c *BadgerDB) loadForUpdate(txn *badger.Txn, key string) (any, uint64, error) {
+	item, err := txn.Get([]byte(key))
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+
+	var val any
+	err = item.Value(func(bts []byte) error {
+		return c.UnmarshalTo(bts, &val)
+	})
+	return val, item.ExpiresAt(), err
+}
+
+// save the value to key with the unix expire time, 0 is never expired
+func (c *BadgerDB) save(txn *badger.Txn, key string, val any, exp uint64) error {
+	bts, err := c.MustMarshal(val)
+	if err != nil {
+		return err
+	}
+
+	e := badger.NewEntry([]byte(key), bts)
+	e.ExpiresAt = exp
+	return txn.SetEntry(e)
+}
+
+// run a read-only transaction, will check the context before run.
+func (c *BadgerDB) view(fn func(txn *badger.Txn) error) error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+	return c.db.View(fn)
+}
+
+// run a read-write transaction, will check the context before run,
+// and retry it on transaction conflict.
+func (c *BadgerDB) update(fn func(txn *badger.Txn) error) (err error) {
+	for i := 0; i < maxRetries; i++ {
+		if err = c.ContextErr(); err != nil {
+			return err
+		}
+
+		if err = c.db.Update(fn); !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+func newEntry(key string, bts []byte, ttl time.Duration) *badger.Entry {
+	e := badger.NewEntry([]byte(key), bts)
+	if ttl > 0 {
+		e = e.WithTTL(ttl)
+	}
+	return e
+}
+
+func expiresAt(ttl time.Duration) uint64 {
+	if ttl > 0 {
+		return uint64(time.Now().Add(ttl).Unix())
+	}
+	return 0
+}
+
+// badgerLogger forward the badger logs to the driver logger. the info and debug logs require the debug enabled.
+type badgerLogger struct {
+	c *BadgerDB
+}
+
+func (l badgerLogger) Errorf(format string, v ...any) {
+	l.c.Log(slog.LevelError, strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l badgerLogger) Warningf(format string, v ...any) {
+	l.c.Log(slog.LevelWarn, strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l badgerLogger) Infof(format string, v ...any) {
+	if l.c.DebugEnabled() {
+		l.c.Log(slog.LevelInfo, strings.TrimSpace(fmt.Sprintf(format, v...)))
+	}
+}
+
+func (l badgerLogger) Debugf(format string, v ...any) {
+	l.c.Debugf("%s", strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+/*************************************************************
+ * open by DSN url
+ *************************************************************/
+
+func init() {
+	cache.RegisterFactory(Name, openURL)
+}
+
+// open by the DSN url. eg: "badger:///path/to/dir?prefix=app:", "badger://:memory:"
+func openURL(u *url.URL) (cache.Cache, error) {
+	optFns, err := cache.URLOptions(u)
+	if err != nil {
+		return nil, err
+	}
+	return New(cache.URLPath(u), optFns...)
+}
@@ -1,16 +1,39 @@
-// Package bolt use the go.etcd.io/bbolt(github.com/etcd-io/bbolt) as cache driver
+// Package boltdb use the go.etcd.io/bbolt(github.com/etcd-io/bbolt) as cache driver
+//
+// BoltDB has no native TTL, so each value is stored with an expire time header.
+// the expired keys are deleted lazily on read, and by a background sweep goroutine.
 package boltdb
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
 	"time"
 
 	"github.com/gookit/cache"
+	"github.com/gookit/cache/internal/expiry"
+	"github.com/gookit/cache/internal/loop"
+	"github.com/gookit/goutil/mathutil"
+	"github.com/gookit/gsr"
 	"go.etcd.io/bbolt"
 )
 
 // Name driver name
 const Name = "boltDB"
 
+// DefaultBucket the default bucket name for store the cache data
+const DefaultBucket = "myBucket"
+
+// DefaultSweepInterval the default interval for delete the expired keys
+const DefaultSweepInterval = time.Minute
+
+// errors for load the key
+var (
+	errNotFound = errors.New("boltDB: key not found")
+	errExpired  = errors.New("boltDB: key expired")
+)
+
 // BoltDB definition
 type BoltDB struct {
 	cache.BaseDriver
@@ -18,91 +41,579 @@ type BoltDB struct {
 	file string
 	// db instance
 	db *bbolt.DB
-	// Bucket name
+	// expired keys sweep runner
+	sweeper *loop.Runner
+	// Bucket name, it will be created on write if not exists. default is DefaultBucket
 	Bucket string
 }
 
-// New a BuntDB instance
-func New(file string) *BoltDB {
-	db, err := bbolt.Open(file, 0666, nil)
+// New open a BoltDB instance by the db file path.
+//
+// Usage:
+//
+//	c, err := boltdb.New("path/to/my.db", cache.WithPrefix("app:"))
+func New(file string, optFns ...func(option *cache.Option)) (*BoltDB, error) {
+	return NewWithOptions(file, nil, optFns...)
+}
+
+// NewWithOptions open a BoltDB instance by the db file path and custom bbolt.Options.
+// the sweep goroutine will be started by DefaultSweepInterval, if the db is not read-only.
+func NewWithOptions(file string, opts *bbolt.Options, optFns ...func(option *cache.Option)) (*BoltDB, error) {
+	db, err := bbolt.Open(file, 0666, opts)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	c := &BoltDB{
+		db:      db,
+		file:    file,
+		sweeper: new(loop.Runner),
+		Bucket:  DefaultBucket,
 	}
+	c.SetName(Name)
+	c.WithOptions(optFns...)
+
+	if !db.IsReadOnly() {
+		err = db.Update(func(tx *bbolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte(c.Bucket))
+			return err
+		})
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+
+		c.StartSweep(DefaultSweepInterval)
+	}
+	return c, nil
+}
+
+// Db get the bbolt db
+func (c *BoltDB) Db() *bbolt.DB {
+	return c.db
+}
+
+// StartSweep start a goroutine for delete the expired keys periodically, it will be stopped by Close().
+// if the sweep goroutine is running, it will be restarted with new interval.
+func (c *BoltDB) StartSweep(interval time.Duration) {
+	c.sweeper.Start(interval, func() {
+		if err := c.Sweep(); err != nil {
+			c.LogErr("sweep expired keys failed", err)
+		}
+	})
+}
+
+// StopSweep stop the sweep goroutine, and wait it exited.
+func (c *BoltDB) StopSweep() {
+	c.sweeper.Stop()
+}
+
+// Sweep delete all expired keys with the prefix
+func (c *BoltDB) Sweep() error {
+	return c.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(c.Bucket))
+		if b == nil {
+			return nil
+		}
+
+		return c.deleteKeys(b, []byte(c.Key("")), func(v []byte) bool {
+			_, exp, err := expiry.Decode(v)
+			return err == nil && expiry.Expired(exp)
+		})
+	})
+}
+
+/*************************************************************
+ * methods implements of the gsr.SimpleCacher
+ *************************************************************/
 
-	return &BoltDB{db: db, Bucket: "myBucket"}
+// WithContext returns a copy of the driver for operate with ctx.
+// the ctx is checked before each transaction, and between the items of multi operations.
+func (c *BoltDB) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *c
+	cp.SetContext(ctx)
+	return &cp
 }
 
 // Has value check by key
 func (c *BoltDB) Has(key string) bool {
-	return c.Get(key) != nil
+	var expired bool
+	err := c.view(func(tx *bbolt.Tx) error {
+		_, _, err := c.load(tx, c.Key(key))
+		expired = err == errExpired
+		return err
+	})
+
+	if expired {
+		c.delExpired(c.Key(key))
+	}
+	return err == nil
 }
 
 // Get value by key
 func (c *BoltDB) Get(key string) any {
 	var val any
-	err := c.db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(c.Bucket))
-		bs := b.Get([]byte(key))
-
-		if err := c.UnmarshalTo(bs, &val); err != nil {
-			return err
-		}
-
-		return nil
+	var expired bool
+	err := c.view(func(tx *bbolt.Tx) (err error) {
+		val, err = c.get(tx, c.Key(key))
+		expired = err == errExpired
+		return err
 	})
 
 	if err != nil {
+		if expired {
+			c.delExpired(c.Key(key))
+		} else if err != errNotFound {
+			c.SetOpErr("Get", key, err)
+		}
 		return nil
 	}
 	return val
 }
 
 // Set value by key
-func (c *BoltDB) Set(key string, val any, _ time.Duration) (err error) {
-	bts, err := c.MustMarshal(val)
-	if err != nil {
-		return
-	}
-
-	return c.db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(c.Bucket))
-		err := b.Put([]byte(key), bts)
-		return err
+func (c *BoltDB) Set(key string, val any, ttl time.Duration) (err error) {
+	return c.update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(c.Bucket))
+		if err != nil {
+			return err
+		}
+		return c.save(b, c.Key(key), val, expiry.At(ttl))
 	})
 }
 
 // Del value by key
 func (c *BoltDB) Del(key string) error {
-	panic("implement me")
+	return c.update(func(tx *bbolt.Tx) error {
+		if b := tx.Bucket([]byte(c.Bucket)); b != nil {
+			return b.Delete([]byte(c.Key(key)))
+		}
+		return nil
+	})
 }
 
-// GetMulti values by multi key
+// GetMulti values by multi key, in one transaction. the not exists keys will be ignored.
 func (c *BoltDB) GetMulti(keys []string) map[string]any {
-	panic("implement me")
+	results := make(map[string]any, len(keys))
+	err := c.view(func(tx *bbolt.Tx) error {
+		for _, key := range keys {
+			if err := c.ContextErr(); err != nil {
+				return err
+			}
+
+			val, err := c.get(tx, c.Key(key))
+			if err != nil {
+				if isNotFound(err) {
+					continue
+				}
+				return err
+			}
+			results[key] = val
+		}
+		return nil
+	})
+
+	if err != nil {
+		c.SetOpErr("GetMulti", "", err)
+		return nil
+	}
+	return results
 }
 
-// SetMulti values by multi key
+// SetMulti values by multi key, in one transaction.
 func (c *BoltDB) SetMulti(values map[string]any, ttl time.Duration) (err error) {
-	panic("implement me")
+	return c.update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(c.Bucket))
+		if err != nil {
+			return err
+		}
+
+		exp := expiry.At(ttl)
+		for key, val := range values {
+			if err := c.ContextErr(); err != nil {
+				return err
+			}
+			if err := c.save(b, c.Key(key), val, exp); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// DelMulti values by multi key
+// DelMulti values by multi key, in one transaction.
 func (c *BoltDB) DelMulti(keys []string) error {
-	panic("implement me")
+	return c.update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(c.Bucket))
+		if b == nil {
+			return nil
+		}
+
+		for _, key := range keys {
+			if err := c.ContextErr(); err != nil {
+				return err
+			}
+			if err := b.Delete([]byte(c.Key(key))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// Clear all data
+// Clear all data in the bucket. if the prefix option is set, only delete the keys with prefix,
+// otherwise will drop and recreate the bucket.
 func (c *BoltDB) Clear() error {
-	panic("implement me")
+	return c.update(func(tx *bbolt.Tx) error {
+		name := []byte(c.Bucket)
+		b := tx.Bucket(name)
+		if b == nil {
+			return nil
+		}
+
+		if prefix := c.Key(""); prefix != "" {
+			return c.deleteKeys(b, []byte(prefix), nil)
+		}
+
+		if err := tx.DeleteBucket(name); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(name)
+		return err
+	})
 }
 
-// Close db
+// ClearPrefix delete the caches which key has the prefix, the driver prefix will be added.
+func (c *BoltDB) ClearPrefix(prefix string) error {
+	return c.update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(c.Bucket))
+		if b == nil {
+			return nil
+		}
+		return c.deleteKeys(b, []byte(c.Key(prefix)), nil)
+	})
+}
+
+// Close the sweep goroutine and the db
 func (c *BoltDB) Close() error {
-	err := c.db.Sync()
-	if err != nil {
-		return err
+	c.StopSweep()
+
+	if !c.db.IsReadOnly() {
+		if err := c.db.Sync(); err != nil {
+			return err
+		}
 	}
 
 	// do close
 	return c.db.Close()
 }
+
+/*************************************************************
+ * methods implements of the cache.Counter
+ *************************************************************/
+
+// Incr increment the key value by 1
+func (c *BoltDB) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1
+func (c *BoltDB) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta.
+// the ttl is only used when the key is created.
+func (c *BoltDB) IncrBy(key string, delta int64, ttl ...time.Duration) (num int64, err error) {
+	err = c.updateKey(key, func(b *bbolt.Bucket, val any, exp int64, found bool) error {
+		if found {
+			if num, err = mathutil.ToInt64(val); err != nil {
+				return cache.ErrNotNumber
+			}
+		} else {
+			exp = expiry.At(cache.CreateTTL(ttl))
+		}
+
+		num += delta
+		return c.save(b, c.Key(key), num, exp)
+	})
+	return
+}
+
+// IncrByFloat increment the key value by float delta.
+// the ttl is only used when the key is created.
+func (c *BoltDB) IncrByFloat(key string, delta float64, ttl ...time.Duration) (num float64, err error) {
+	err = c.updateKey(key, func(b *bbolt.Bucket, val any, exp int64, found bool) error {
+		if found {
+			if num, err = mathutil.ToFloat(val); err != nil {
+				return cache.ErrNotNumber
+			}
+		} else {
+			exp = expiry.At(cache.CreateTTL(ttl))
+		}
+
+		num += delta
+		return c.save(b, c.Key(key), num, exp)
+	})
+	return
+}
+
+/*************************************************************
+ * methods implements of the cache.ConditionalSetter
+ *************************************************************/
+
+// Add set the key value only if the key does not exist
+func (c *BoltDB) Add(key string, val any, ttl time.Duration) (ok bool, err error) {
+	err = c.updateKey(key, func(b *bbolt.Bucket, _ any, _ int64, found bool) error {
+		if found {
+			return nil
+		}
+
+		ok = true
+		return c.save(b, c.Key(key), val, expiry.At(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// Replace set the key value only if the key already exists
+func (c *BoltDB) Replace(key string, val any, ttl time.Duration) (ok bool, err error) {
+	err = c.updateKey(key, func(b *bbolt.Bucket, _ any, _ int64, found bool) error {
+		if !found {
+			return nil
+		}
+
+		ok = true
+		return c.save(b, c.Key(key), val, expiry.At(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (c *BoltDB) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (ok bool, err error) {
+	err = c.updateKey(key, func(b *bbolt.Bucket, val any, _ int64, found bool) error {
+		if !found || !c.EqualValue(val, oldVal) {
+			return nil
+		}
+
+		ok = true
+		return c.save(b, c.Key(key), newVal, expiry.At(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal
+func (c *BoltDB) CompareAndDelete(key string, oldVal any) (ok bool, err error) {
+	err = c.updateKey(key, func(b *bbolt.Bucket, val any, _ int64, found bool) error {
+		if !found || !c.EqualValue(val, oldVal) {
+			return nil
+		}
+
+		ok = true
+		return b.Delete([]byte(c.Key(key)))
+	})
+	return ok && err == nil, err
+}
+
+/*************************************************************
+ * methods implements of the cache.TTLer
+ *************************************************************/
+
+// TTL get the remaining time to live of the key
+func (c *BoltDB) TTL(key string) (ttl time.Duration, err error) {
+	err = c.view(func(tx *bbolt.Tx) error {
+		_, exp, err := c.load(tx, c.Key(key))
+		ttl = expiry.Remaining(exp)
+		return err
+	})
+
+	if isNotFound(err) {
+		return 0, cache.ErrNotFound
+	}
+	return
+}
+
+// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration.
+func (c *BoltDB) Expire(key string, ttl time.Duration) error {
+	err := c.update(func(tx *bbolt.Tx) error {
+		bts, _, err := c.load(tx, c.Key(key))
+		if err != nil {
+			return err
+		}
+
+		// tx.Bucket() is not nil after load success
+		return tx.Bucket([]byte(c.Bucket)).Put([]byte(c.Key(key)), expiry.Encode(bts, expiry.At(ttl)))
+	})
+
+	if isNotFound(err) {
+		return cache.ErrNotFound
+	}
+	return err
+}
+
+// Persist remove the key expiration
+func (c *BoltDB) Persist(key string) error {
+	return c.Expire(key, cache.Forever)
+}
+
+// Touch refresh the key expiration to ttl from now
+func (c *BoltDB) Touch(key string, ttl time.Duration) error {
+	if err := c.Expire(key, ttl); err != cache.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+/*************************************************************
+ * helper methods
+ *************************************************************/
+
+// load the raw value and expire time of the real key.
+// returns errNotFound if the key not exists, errExpired if the key is expired.
+func (c *BoltDB) load(tx *bbolt.Tx, key string) ([]byte, int64, error) {
+	b := tx.Bucket([]byte(c.Bucket))
+	if b == nil {
+		return nil, 0, errNotFound
+	}
+
+	bs := b.Get([]byte(key))
+	if bs == nil {
+		return nil, 0, errNotFound
+	}
+
+	bts, exp, err := expiry.Decode(bs)
+	if err != nil {
+		return nil, 0, err
+	}
+	if expiry.Expired(exp) {
+		return nil, 0, errExpired
+	}
+	return bts, exp, nil
+}
+
+// get and unmarshal the value of the real key
+func (c *BoltDB) get(tx *bbolt.Tx, key string) (val any, err error) {
+	bts, _, err := c.load(tx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.UnmarshalTo(bts, &val)
+	return
+}
+
+// delete the expired key on read. re-check it in the write transaction, the key maybe updated.
+func (c *BoltDB) delExpired(key string) {
+	if c.db.IsReadOnly() {
+		return
+	}
+
+	c.SetOpErr("DelExpired", key, c.db.Update(func(tx *bbolt.Tx) error {
+		if _, _, err := c.load(tx, key); err != errExpired {
+			return nil
+		}
+		return tx.Bucket([]byte(c.Bucket)).Delete([]byte(key))
+	}))
+}
+
+// delete the keys with prefix in the bucket, if match is not nil, only delete the matched values.
+func (c *BoltDB) deleteKeys(b *bbolt.Bucket, prefix []byte, match func(v []byte) bool) error {
+	cur := b.Cursor()
+
+	for k, v := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); {
+		if err := c.ContextErr(); err != nil {
+			return err
+		}
+
+		if match != nil && !match(v) {
+			k, v = cur.Next()
+			continue
+		}
+
+		// the cursor position is undefined after delete, so seek from the deleted key
+		k = append([]byte(nil), k...)
+		if err := cur.Delete(); err != nil {
+			return err
+		}
+		k, v = cur.Seek(k)
+	}
+	return nil
+}
+
+// run the read-modify-write fn for the key in a transaction.
+func (c *BoltDB) updateKey(key string, fn func(b *bbolt.Bucket, val any, exp int64, found bool) error) error {
+	return c.update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(c.Bucket))
+		if err != nil {
+			return err
+		}
+
+		bts, exp, err := c.load(tx, c.Key(key))
+		if err != nil {
+			if isNotFound(err) {
+				return fn(b, nil, 0, false)
+			}
+			return err
+		}
+
+		var val any
+		if err = c.UnmarshalTo(bts, &val); err != nil {
+			return err
+		}
+		return fn(b, val, exp, true)
+	})
+}
+
+func isNotFound(err error) bool {
+	return err == errNotFound || err == errExpired
+}
+
+// save the value to the real key with the expire unix nano, 0 is never expired
+func (c *BoltDB) save(b *bbolt.Bucket, key string, val any, exp int64) error {
+	bts, err := c.MustMarshal(val)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), expiry.Encode(bts, exp))
+}
+
+// run a read-only transaction, will check the context before run.
+func (c *BoltDB) view(fn func(tx *bbolt.Tx) error) error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+	return c.db.View(fn)
+}
+
+// run a read-write transaction, will check the context before run.
+func (c *BoltDB) update(fn func(tx *bbolt.Tx) error) error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+	return c.db.Update(fn)
+}
+
+/*************************************************************
+ * open by DSN url
+ *************************************************************/
+
+func init() {
+	cache.RegisterFactory(Name, openURL)
+}
+
+// open by the DSN url. eg: "boltdb:///path/to/my.db?bucket=cache&prefix=app:"
+func openURL(u *url.URL) (cache.Cache, error) {
+	optFns, err := cache.URLOptions(u)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := New(cache.URLPath(u), optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	if bucket := u.Query().Get("bucket"); bucket != "" {
+		c.Bucket = bucket
+	}
+	return c, nil
+}
@@ -0,0 +1,172 @@
+package boltdb_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/boltdb"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/goutil/testutil/assert"
+	"go.etcd.io/bbolt"
+)
+
+func Example() {
+	dir, _ := os.MkdirTemp("", "boltdb")
+	defer os.RemoveAll(dir)
+
+	c, err := boltdb.New(filepath.Join(dir, "my.db"))
+	if err != nil {
+		panic(err)
+	}
+	defer c.Close()
+
+	key := "name"
+
+	// set
+	c.Set(key, "cache value", cache.Seconds2)
+	fmt.Println(c.Has(key))
+
+	// get
+	val := c.Get(key)
+	fmt.Println(val)
+
+	time.Sleep(2 * time.Second)
+
+	// get expired
+	val2 := c.Get(key)
+	fmt.Println(val2)
+
+	// Output:
+	// true
+	// cache value
+	// <nil>
+}
+
+func newTestDB(t *testing.T, optFns ...func(option *cache.Option)) *boltdb.BoltDB {
+	c, err := boltdb.New(filepath.Join(t.TempDir(), "test.db"), optFns...)
+	assert.NoErr(t, err)
+	t.Cleanup(func() {
+		assert.NoErr(t, c.Close())
+	})
+	return c
+}
+
+func TestNew_error(t *testing.T) {
+	_, err := boltdb.New(filepath.Join(t.TempDir(), "not-exist", "test.db"))
+	assert.Err(t, err)
+}
+
+func TestBoltDB_bucket(t *testing.T) {
+	is := assert.New(t)
+	c := newTestDB(t)
+
+	// auto create on write
+	c.Bucket = "other"
+	is.Nil(c.Get("key"))
+	is.NoErr(c.Del("key"))
+	is.NoErr(c.Set("key", "value", 0))
+	is.Eq("value", c.Get("key"))
+
+	c.Bucket = boltdb.DefaultBucket
+	is.False(c.Has("key"))
+}
+
+func TestBoltDB_multi(t *testing.T) {
+	is := assert.New(t)
+	c := newTestDB(t, cache.WithPrefix("app:"))
+
+	is.NoErr(c.Db().Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(c.Bucket)).Put([]byte("other"), []byte("value"))
+	}))
+
+	is.NoErr(c.SetMulti(map[string]any{"k1": "v1", "k2": "v2", "k3": "v3"}, cache.Seconds3))
+	is.Eq(map[string]any{"k1": "v1", "k2": "v2", "k3": "v3"}, c.GetMulti([]string{"k1", "k2", "k3", "k4"}))
+
+	is.NoErr(c.DelMulti([]string{"k1"}))
+	is.False(c.Has("k1"))
+	is.True(c.Has("k2"))
+
+	// only clear the keys with prefix
+	is.NoErr(c.Clear())
+	is.False(c.Has("k2"))
+	is.False(c.Has("k3"))
+	is.NoErr(c.Db().View(func(tx *bbolt.Tx) error {
+		is.Eq([]byte("value"), tx.Bucket([]byte(c.Bucket)).Get([]byte("other")))
+		return nil
+	}))
+}
+
+func TestBoltDB_Clear(t *testing.T) {
+	is := assert.New(t)
+	c := newTestDB(t)
+
+	is.NoErr(c.SetMulti(map[string]any{"k1": "v1", "k2": "v2"}, 0))
+	is.NoErr(c.Clear())
+	is.False(c.Has("k1"))
+	is.False(c.Has("k2"))
+
+	// bucket is recreated
+	is.NoErr(c.Set("k1", "v1", 0))
+	is.Eq("v1", c.Get("k1"))
+}
+
+func TestBoltDB_Sweep(t *testing.T) {
+	is := assert.New(t)
+	c := newTestDB(t)
+
+	is.NoErr(c.SetMulti(map[string]any{"k1": "v1", "k2": "v2"}, 10*time.Millisecond))
+	is.NoErr(c.Set("k3", "v3", 0))
+	c.StartSweep(20 * time.Millisecond)
+	time.Sleep(60 * time.Millisecond)
+
+	is.NoErr(c.Db().View(func(tx *bbolt.Tx) error {
+		is.Eq(1, tx.Bucket([]byte(c.Bucket)).Stats().KeyN)
+		return nil
+	}))
+	is.Eq("v3", c.Get("k3"))
+}
+
+func TestBoltDB_counter_ttl(t *testing.T) {
+	is := assert.New(t)
+	c := newTestDB(t)
+
+	num, err := c.Incr("num", cache.Seconds3)
+	is.NoErr(err)
+	is.Eq(int64(1), num)
+	num, err = c.IncrBy("num", 5)
+	is.NoErr(err)
+	is.Eq(int64(6), num)
+
+	ttl, err := c.TTL("num")
+	is.NoErr(err)
+	is.True(ttl > 0 && ttl <= cache.Seconds3)
+
+	is.NoErr(c.Persist("num"))
+	ttl, err = c.TTL("num")
+	is.NoErr(err)
+	is.Eq(time.Duration(cache.Forever), ttl)
+
+	_, err = c.TTL("not-exist")
+	is.ErrIs(err, cache.ErrNotFound)
+
+	ok, err := c.Add("num", 1, 0)
+	is.NoErr(err)
+	is.False(ok)
+	ok, err = c.CompareAndSwap("num", 6, 7, 0)
+	is.NoErr(err)
+	is.True(ok)
+	ok, err = c.CompareAndDelete("num", 7)
+	is.NoErr(err)
+	is.True(ok)
+	is.False(c.Has("num"))
+}
+
+func TestBoltDB_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		return newTestDB(t)
+	})
+}
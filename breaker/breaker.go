@@ -0,0 +1,479 @@
+// Package breaker provide a circuit breaker driver for the flaky cache backends.
+//
+// The Breaker tracks the error rate and latency of the calls to the backend, and opens the circuit
+// after the failure rate reached the threshold. While open, the calls fail fast with ErrOpen,
+// or be routed to the fallback cache. After the open timeout, some probe calls are allowed
+// in half-open state, the circuit will be closed if they are all succeeded.
+//
+// NOTICE: the reads(Get, Has, GetMulti) have no error, the failure of them is detected by the
+// ErrCount() and LastErr() of the backend, eg: the drivers based on cache.BaseDriver.
+// Otherwise they are only counted as failure by the latency.
+//
+// Usage:
+//
+//	rds := goredis.Connect("127.0.0.1:6379", "", 0)
+//	c := breaker.New(rds,
+//		breaker.WithThreshold(0.5, 20),
+//		breaker.WithSlowCall(100*time.Millisecond),
+//		breaker.WithFallback(cache.NewMemoryCache()),
+//	)
+//
+//	cache.Register(breaker.Name, c)
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/gsr"
+)
+
+// Name driver name
+const Name = "breaker"
+
+// default options
+const (
+	// DefaultWindow default time window for count the calls
+	DefaultWindow = 10 * time.Second
+	// DefaultMinCalls default min calls number in the window before trip
+	DefaultMinCalls = 20
+	// DefaultFailureRate default failure rate threshold to open the circuit
+	DefaultFailureRate = 0.5
+	// DefaultOpenTimeout default wait time before probe the backend
+	DefaultOpenTimeout = 5 * time.Second
+)
+
+// ErrOpen the circuit is open, the call is rejected
+var ErrOpen = errors.New("breaker: the circuit is open")
+
+// ErrRead the read is failed, but the backend has no last error
+var ErrRead = errors.New("breaker: the read is failed")
+
+// the backend can report the errors of the reads. eg: the drivers based on cache.BaseDriver
+type errCounter interface {
+	ErrCount() uint64
+	LastErr(key string) error
+}
+
+// State of the circuit
+type State int
+
+// states of the circuit
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+// String get state name
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Options for the Breaker
+type Options struct {
+	// Window the time window for count the calls. default is DefaultWindow
+	Window time.Duration
+	// MinCalls the min calls number in the window before trip. default is DefaultMinCalls
+	MinCalls int
+	// FailureRate the failure rate(0-1] threshold to open the circuit. default is DefaultFailureRate
+	FailureRate float64
+	// SlowCall the call slower than it will be counted as failure. default is 0, disabled.
+	SlowCall time.Duration
+	// OpenTimeout the wait time in open state before probe the backend. default is DefaultOpenTimeout
+	OpenTimeout time.Duration
+	// HalfOpenCalls the probe calls number in half-open state, all succeeded will close the circuit. default is 1
+	HalfOpenCalls int
+	// Fallback the cache for handle the calls on the circuit is not closed. default is nil, fail fast with ErrOpen.
+	Fallback cache.Cache
+	// IsFailure check the error of a call is failure or not.
+	// default the cache.ErrNotFound, cache.ErrNotNumber and context.Canceled are not failures.
+	IsFailure func(err error) bool
+	// OnStateChange called on the circuit state changed
+	OnStateChange func(from, to State)
+	// OnCall called after each call to the backend
+	OnCall func(op string, latency time.Duration, err error)
+	// OnReject called on a call is rejected, or routed to the fallback
+	OnReject func(op string)
+}
+
+// WithThreshold set the failure rate threshold and the min calls number in the window
+func WithThreshold(rate float64, minCalls int) func(opt *Options) {
+	return func(opt *Options) {
+		opt.FailureRate = rate
+		opt.MinCalls = minCalls
+	}
+}
+
+// WithWindow set the time window for count the calls
+func WithWindow(window time.Duration) func(opt *Options) {
+	return func(opt *Options) {
+		opt.Window = window
+	}
+}
+
+// WithSlowCall set the latency threshold, the slower calls will be counted as failure
+func WithSlowCall(latency time.Duration) func(opt *Options) {
+	return func(opt *Options) {
+		opt.SlowCall = latency
+	}
+}
+
+// WithOpenTimeout set the wait time before probe, and the probe calls number in half-open state
+func WithOpenTimeout(timeout time.Duration, probes int) func(opt *Options) {
+	return func(opt *Options) {
+		opt.OpenTimeout = timeout
+		opt.HalfOpenCalls = probes
+	}
+}
+
+// WithFallback set the fallback cache
+func WithFallback(c cache.Cache) func(opt *Options) {
+	return func(opt *Options) {
+		opt.Fallback = c
+	}
+}
+
+// WithStateChange set the callback on the circuit state changed
+func WithStateChange(fn func(from, to State)) func(opt *Options) {
+	return func(opt *Options) {
+		opt.OnStateChange = fn
+	}
+}
+
+// WithCallHook set the callback after each call to the backend
+func WithCallHook(fn func(op string, latency time.Duration, err error)) func(opt *Options) {
+	return func(opt *Options) {
+		opt.OnCall = fn
+	}
+}
+
+// WithRejectHook set the callback on a call is rejected
+func WithRejectHook(fn func(op string)) func(opt *Options) {
+	return func(opt *Options) {
+		opt.OnReject = fn
+	}
+}
+
+// the circuit state shared by the driver copies
+type circuit struct {
+	mu    sync.Mutex
+	state State
+	// generation of the state, for ignore the results of calls started in previous state
+	gen uint64
+	// counters in current window
+	winStart time.Time
+	calls    int
+	failures int
+	// open time
+	openedAt time.Time
+	// probe calls in half-open state
+	probes    int
+	successes int
+}
+
+// Breaker the circuit breaker driver
+type Breaker struct {
+	*circuit
+	backend cache.Cache
+	opt     Options
+	// context for operate
+	ctx context.Context
+}
+
+// New create a Breaker for the backend
+func New(backend cache.Cache, optFns ...func(opt *Options)) *Breaker {
+	opt := Options{
+		Window:        DefaultWindow,
+		MinCalls:      DefaultMinCalls,
+		FailureRate:   DefaultFailureRate,
+		OpenTimeout:   DefaultOpenTimeout,
+		HalfOpenCalls: 1,
+		IsFailure:     isFailure,
+	}
+	for _, fn := range optFns {
+		fn(&opt)
+	}
+
+	if opt.Window <= 0 {
+		opt.Window = DefaultWindow
+	}
+	if opt.MinCalls <= 0 {
+		opt.MinCalls = 1
+	}
+	if opt.FailureRate <= 0 || opt.FailureRate > 1 {
+		opt.FailureRate = DefaultFailureRate
+	}
+	if opt.OpenTimeout <= 0 {
+		opt.OpenTimeout = DefaultOpenTimeout
+	}
+	if opt.HalfOpenCalls <= 0 {
+		opt.HalfOpenCalls = 1
+	}
+	if opt.IsFailure == nil {
+		opt.IsFailure = isFailure
+	}
+
+	return &Breaker{
+		circuit: &circuit{winStart: time.Now()},
+		backend: backend,
+		opt:     opt,
+	}
+}
+
+// Backend get the backend cache
+func (b *Breaker) Backend() cache.Cache {
+	return b.backend
+}
+
+// State get the current circuit state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.opt.OpenTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// WithContext returns a copy of the driver for operate with ctx.
+// the copy shares the circuit with the origin.
+func (b *Breaker) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *b
+	cp.ctx = ctx
+	return &cp
+}
+
+/*************************************************************
+ * methods implements of the gsr.SimpleCacher
+ *************************************************************/
+
+// Has cache key
+func (b *Breaker) Has(key string) (ok bool) {
+	b.read("Has", key, func(c cache.Cache) {
+		ok = c.Has(key)
+	})
+	return
+}
+
+// Get value by key
+func (b *Breaker) Get(key string) (val any) {
+	b.read("Get", key, func(c cache.Cache) {
+		val = c.Get(key)
+	})
+	return
+}
+
+// Set value by key
+func (b *Breaker) Set(key string, val any, ttl time.Duration) error {
+	return b.run("Set", func(c cache.Cache) error {
+		return c.Set(key, val, ttl)
+	})
+}
+
+// Del value by key
+func (b *Breaker) Del(key string) error {
+	return b.run("Del", func(c cache.Cache) error {
+		return c.Del(key)
+	})
+}
+
+// GetMulti values by keys
+func (b *Breaker) GetMulti(keys []string) (values map[string]any) {
+	b.read("GetMulti", "", func(c cache.Cache) {
+		values = c.GetMulti(keys)
+	})
+	return
+}
+
+// SetMulti values
+func (b *Breaker) SetMulti(values map[string]any, ttl time.Duration) error {
+	return b.run("SetMulti", func(c cache.Cache) error {
+		return c.SetMulti(values, ttl)
+	})
+}
+
+// DelMulti values by keys
+func (b *Breaker) DelMulti(keys []string) error {
+	return b.run("DelMulti", func(c cache.Cache) error {
+		return c.DelMulti(keys)
+	})
+}
+
+// Clear all caches
+func (b *Breaker) Clear() error {
+	return b.run("Clear", func(c cache.Cache) error {
+		return c.Clear()
+	})
+}
+
+// Close the backend. the fallback will not be closed.
+func (b *Breaker) Close() error {
+	return b.backend.Close()
+}
+
+/*************************************************************
+ * helper methods
+ *************************************************************/
+
+// run the operation on the backend if the call is allowed, otherwise on the fallback.
+// returns ErrOpen if the call is rejected and no fallback.
+func (b *Breaker) run(op string, fn func(c cache.Cache) error) error {
+	if b.ctx != nil {
+		if err := b.ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	gen, ok := b.allow()
+	if !ok {
+		if b.opt.OnReject != nil {
+			b.opt.OnReject(op)
+		}
+
+		if b.opt.Fallback != nil {
+			return fn(b.withCtx(b.opt.Fallback))
+		}
+		return ErrOpen
+	}
+
+	start := time.Now()
+	err := fn(b.withCtx(b.backend))
+	latency := time.Since(start)
+
+	if b.opt.OnCall != nil {
+		b.opt.OnCall(op, latency, err)
+	}
+
+	failed := (err != nil && b.opt.IsFailure(err)) || (b.opt.SlowCall > 0 && latency >= b.opt.SlowCall)
+	b.record(gen, failed)
+	return err
+}
+
+// read run the read operation by run(), the error of it is got from the backend if it is an errCounter.
+func (b *Breaker) read(op, key string, fn func(c cache.Cache)) {
+	_ = b.run(op, func(c cache.Cache) error {
+		ec, ok := cache.Unwrap(c).(errCounter)
+		if !ok {
+			fn(c)
+			return nil
+		}
+
+		n := ec.ErrCount()
+		fn(c)
+		if ec.ErrCount() == n {
+			return nil
+		}
+		if err := ec.LastErr(key); err != nil {
+			return err
+		}
+		return ErrRead
+	})
+}
+
+func (b *Breaker) withCtx(c cache.Cache) cache.Cache {
+	if b.ctx != nil {
+		return cache.WithContext(c, b.ctx)
+	}
+	return c
+}
+
+// check the call is allowed, returns the state generation of the call.
+func (b *Breaker) allow() (uint64, bool) {
+	b.mu.Lock()
+	from := b.state
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.opt.OpenTimeout {
+		b.setState(StateHalfOpen)
+	}
+
+	ok := true
+	switch b.state {
+	case StateOpen:
+		ok = false
+	case StateHalfOpen:
+		if ok = b.probes < b.opt.HalfOpenCalls; ok {
+			b.probes++
+		}
+	}
+
+	gen, to := b.gen, b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return gen, ok
+}
+
+// record the result of a call
+func (b *Breaker) record(gen uint64, failed bool) {
+	b.mu.Lock()
+	from := b.state
+	// the state is changed after the call started
+	if gen != b.gen {
+		b.mu.Unlock()
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		if now := time.Now(); now.Sub(b.winStart) >= b.opt.Window {
+			b.winStart, b.calls, b.failures = now, 0, 0
+		}
+
+		b.calls++
+		if failed {
+			b.failures++
+		}
+		if b.calls >= b.opt.MinCalls && float64(b.failures) >= b.opt.FailureRate*float64(b.calls) {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		if failed {
+			b.setState(StateOpen)
+		} else if b.successes++; b.successes >= b.opt.HalfOpenCalls {
+			b.setState(StateClosed)
+		}
+	}
+
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// set the state and reset the counters, must be called with lock.
+func (b *Breaker) setState(state State) {
+	now := time.Now()
+	b.state = state
+	b.gen++
+	b.winStart, b.calls, b.failures = now, 0, 0
+	b.probes, b.successes = 0, 0
+
+	if state == StateOpen {
+		b.openedAt = now
+	}
+}
+
+func (b *Breaker) notify(from, to State) {
+	if from != to && b.opt.OnStateChange != nil {
+		b.opt.OnStateChange(from, to)
+	}
+}
+
+func isFailure(err error) bool {
+	return !errors.Is(err, cache.ErrNotFound) &&
+		!errors.Is(err, cache.ErrNotNumber) &&
+		!errors.Is(err, context.Canceled)
+}
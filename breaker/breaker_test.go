@@ -0,0 +1,197 @@
+package breaker_test
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/breaker"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/cache/gocache"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func Example() {
+	backend := gocache.NewSimple() // eg: redis, memcached
+	c := breaker.New(backend,
+		breaker.WithThreshold(0.5, 20),
+		breaker.WithSlowCall(100*time.Millisecond),
+		breaker.WithFallback(cache.NewMemoryCache()),
+		breaker.WithStateChange(func(from, to breaker.State) {
+			fmt.Println("circuit:", from, "->", to)
+		}),
+	)
+
+	_ = c.Set("name", "cache value", cache.TwoMinutes)
+	fmt.Println(c.Get("name"), c.State())
+
+	// Output:
+	// cache value closed
+}
+
+func TestBreaker_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		return breaker.New(gocache.NewSimple())
+	})
+}
+
+var errDown = errors.New("backend is down")
+
+// a backend can be toggled to failed or slow. the read errors are saved by the BaseDriver.
+type flaky struct {
+	cache.Cache
+	cache.BaseDriver
+	down  atomic.Bool
+	delay atomic.Int64
+}
+
+func (f *flaky) Get(key string) any {
+	time.Sleep(time.Duration(f.delay.Load()))
+	if f.down.Load() {
+		f.SetOpErr("Get", key, errDown)
+		return nil
+	}
+	return f.Cache.Get(key)
+}
+
+func (f *flaky) GetMulti(keys []string) map[string]any {
+	if f.down.Load() {
+		f.SetOpErr("GetMulti", "", errDown)
+		return map[string]any{}
+	}
+	return f.Cache.GetMulti(keys)
+}
+
+func (f *flaky) Set(key string, val any, ttl time.Duration) error {
+	if f.down.Load() {
+		return errDown
+	}
+	return f.Cache.Set(key, val, ttl)
+}
+
+func TestBreaker_states(t *testing.T) {
+	is := assert.New(t)
+	backend := &flaky{Cache: gocache.NewSimple()}
+
+	var changes []string
+	var calls, rejects atomic.Int32
+	c := breaker.New(backend,
+		breaker.WithThreshold(0.5, 4),
+		breaker.WithOpenTimeout(50*time.Millisecond, 2),
+		breaker.WithStateChange(func(from, to breaker.State) {
+			changes = append(changes, from.String()+"->"+to.String())
+		}),
+		breaker.WithCallHook(func(op string, latency time.Duration, err error) {
+			calls.Add(1)
+		}),
+		breaker.WithRejectHook(func(op string) {
+			rejects.Add(1)
+		}),
+	)
+
+	// not found is not failure
+	is.NoErr(c.Set("key", "value", 0))
+	is.Eq("value", c.Get("key"))
+	is.Eq(breaker.StateClosed, c.State())
+
+	// open after the failure rate reached
+	backend.down.Store(true)
+	is.ErrIs(c.Set("key", "value", 0), errDown)
+	is.ErrIs(c.Set("key", "value", 0), errDown)
+	is.Eq(breaker.StateOpen, c.State())
+	is.Eq(int32(4), calls.Load())
+
+	// fail fast on open
+	is.ErrIs(c.Set("key", "value", 0), breaker.ErrOpen)
+	is.Nil(c.Get("key"))
+	is.Eq(int32(4), calls.Load())
+	is.Eq(int32(2), rejects.Load())
+
+	// probe failed, open again
+	time.Sleep(60 * time.Millisecond)
+	is.Eq(breaker.StateHalfOpen, c.State())
+	is.ErrIs(c.Set("key", "value", 0), errDown)
+	is.Eq(breaker.StateOpen, c.State())
+
+	// probes succeeded, closed
+	backend.down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	is.NoErr(c.Set("key", "value2", 0))
+	is.Eq(breaker.StateHalfOpen, c.State())
+	is.Eq("value2", c.Get("key"))
+	is.Eq(breaker.StateClosed, c.State())
+
+	is.Eq([]string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+	}, changes)
+}
+
+func TestBreaker_readFailure(t *testing.T) {
+	is := assert.New(t)
+	backend := &flaky{Cache: gocache.NewSimple()}
+	var errs []error
+	c := breaker.New(backend,
+		breaker.WithThreshold(0.5, 4),
+		breaker.WithOpenTimeout(50*time.Millisecond, 1),
+		breaker.WithCallHook(func(op string, latency time.Duration, err error) {
+			errs = append(errs, err)
+		}),
+	)
+
+	is.NoErr(c.Set("key", "value", 0))
+	is.Eq("value", c.Get("key"))
+	is.Nil(c.Get("not-exist"))
+
+	// the failed reads open the circuit
+	backend.down.Store(true)
+	is.Nil(c.Get("key"))
+	is.Nil(c.Get("key"))
+	is.Nil(c.GetMulti([]string{"key"})["key"])
+	is.Eq(breaker.StateOpen, c.State())
+	is.Eq([]error{nil, nil, nil, errDown, errDown, errDown}, errs)
+
+	// the failed probe read opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	is.Eq(breaker.StateHalfOpen, c.State())
+	is.Nil(c.Get("key"))
+	is.Eq(breaker.StateOpen, c.State())
+
+	// the succeeded probe read closes the circuit
+	backend.down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	is.Eq("value", c.Get("key"))
+	is.Eq(breaker.StateClosed, c.State())
+}
+
+func TestBreaker_slowAndFallback(t *testing.T) {
+	is := assert.New(t)
+	backend := &flaky{Cache: gocache.NewSimple()}
+	fallback := cache.NewMemoryCache()
+	c := breaker.New(backend,
+		breaker.WithThreshold(0.5, 3),
+		breaker.WithSlowCall(10*time.Millisecond),
+		breaker.WithFallback(fallback),
+	)
+
+	is.NoErr(c.Set("key", "value", 0))
+	backend.delay.Store(int64(15 * time.Millisecond))
+	is.Eq("value", c.Get("key"))
+	is.Eq(breaker.StateClosed, c.State())
+	is.Eq("value", c.Get("key"))
+	is.Eq(breaker.StateOpen, c.State())
+
+	// the calls are routed to the fallback
+	is.Nil(c.Get("key"))
+	is.NoErr(c.Set("key", "fallback value", 0))
+	is.Eq("fallback value", c.Get("key"))
+	is.Eq("fallback value", fallback.Get("key"))
+	is.Eq("value", backend.Cache.Get("key"))
+	is.NoErr(c.Close())
+}
@@ -2,9 +2,15 @@
 package buntdb
 
 import (
+	"cmp"
+	"context"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gookit/cache"
+	"github.com/gookit/goutil/mathutil"
+	"github.com/gookit/gsr"
 	"github.com/tidwall/buntdb"
 )
 
@@ -39,9 +45,12 @@ func New(file string) *BuntDB {
 		panic(err)
 	}
 
-	return &BuntDB{
+	c := &BuntDB{
 		db: db,
 	}
+
+	c.SetName(Name)
+	return c
 }
 
 // Db get
@@ -49,10 +58,19 @@ func (c *BuntDB) Db() *buntdb.DB {
 	return c.db
 }
 
+// WithContext returns a copy of the driver for operate with ctx.
+// the ctx is checked before each transaction, and between the items of multi operations.
+func (c *BuntDB) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *c
+	cp.SetContext(ctx)
+	return &cp
+}
+
 // Has key
 func (c *BuntDB) Has(key string) bool {
+	key = c.Key(key)
 	has := false
-	err := c.db.View(func(tx *buntdb.Tx) error {
+	err := c.view(func(tx *buntdb.Tx) error {
 		val, err := tx.Get(key, false)
 		has = val != ""
 		return err
@@ -67,8 +85,9 @@ func (c *BuntDB) Has(key string) bool {
 
 // Get value by key
 func (c *BuntDB) Get(key string) any {
+	key = c.Key(key)
 	var val any
-	err := c.db.View(func(tx *buntdb.Tx) error {
+	err := c.view(func(tx *buntdb.Tx) error {
 		str, err := tx.Get(key, false)
 		if err != nil {
 			return err
@@ -78,6 +97,10 @@ func (c *BuntDB) Get(key string) any {
 	})
 
 	if err != nil {
+		// not exists is not an error
+		if err != buntdb.ErrNotFound {
+			c.SetOpErr("Get", key, err)
+		}
 		return nil
 	}
 	return val
@@ -90,33 +113,37 @@ func (c *BuntDB) Set(key string, val any, ttl time.Duration) (err error) {
 		return err
 	}
 
-	return c.db.Update(func(tx *buntdb.Tx) (err error) {
-		opt := &buntdb.SetOptions{}
-		if ttl > 0 {
-			opt.TTL = ttl
-			opt.Expires = true
-		}
-
-		_, _, err = tx.Set(key, string(bts), opt)
+	return c.update(func(tx *buntdb.Tx) (err error) {
+		_, _, err = tx.Set(c.Key(key), string(bts), newSetOptions(ttl))
 		return err
 	})
 }
 
 // Del value by key
 func (c *BuntDB) Del(key string) error {
-	return c.db.Update(func(tx *buntdb.Tx) error {
-		_, err := tx.Delete(key)
-		return err
+	key = c.Key(key)
+	return c.update(func(tx *buntdb.Tx) error {
+		if _, err := tx.Delete(key); err != buntdb.ErrNotFound {
+			return err
+		}
+		return nil
 	})
 }
 
 // GetMulti values by multi key
 func (c *BuntDB) GetMulti(keys []string) map[string]any {
 	results := make(map[string]any, len(keys))
-	err := c.db.View(func(tx *buntdb.Tx) error {
+	err := c.view(func(tx *buntdb.Tx) error {
 		for _, key := range keys {
-			str, err := tx.Get(key, false)
+			if err := c.ContextErr(); err != nil {
+				return err
+			}
+
+			str, err := tx.Get(c.Key(key), false)
 			if err != nil {
+				if err == buntdb.ErrNotFound {
+					continue
+				}
 				return err
 			}
 
@@ -140,20 +167,19 @@ func (c *BuntDB) GetMulti(keys []string) map[string]any {
 
 // SetMulti values by multi key
 func (c *BuntDB) SetMulti(values map[string]any, ttl time.Duration) (err error) {
-	return c.db.Update(func(tx *buntdb.Tx) (err error) {
-		opt := &buntdb.SetOptions{}
-		if ttl > 0 {
-			opt.TTL = ttl
-			opt.Expires = true
-		}
-
+	return c.update(func(tx *buntdb.Tx) (err error) {
+		opt := newSetOptions(ttl)
 		for key, val := range values {
+			if err := c.ContextErr(); err != nil {
+				return err
+			}
+
 			bts, err := c.MustMarshal(val)
 			if err != nil {
 				return err
 			}
 
-			_, _, err = tx.Set(key, string(bts), opt)
+			_, _, err = tx.Set(c.Key(key), string(bts), opt)
 			if err != nil {
 				return err
 			}
@@ -165,9 +191,12 @@ func (c *BuntDB) SetMulti(values map[string]any, ttl time.Duration) (err error)
 
 // DelMulti values by multi key
 func (c *BuntDB) DelMulti(keys []string) error {
-	return c.db.Update(func(tx *buntdb.Tx) (err error) {
+	return c.update(func(tx *buntdb.Tx) (err error) {
 		for _, k := range keys {
-			if _, err = tx.Delete(k); err != nil {
+			if err = c.ContextErr(); err != nil {
+				return err
+			}
+			if _, err = tx.Delete(c.Key(k)); err != nil && err != buntdb.ErrNotFound {
 				return err
 			}
 		}
@@ -176,10 +205,231 @@ func (c *BuntDB) DelMulti(keys []string) error {
 	})
 }
 
-// Clear all cache data
+// Incr increment the key value by 1
+func (c *BuntDB) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1
+func (c *BuntDB) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta.
+// the ttl is only used when the key is created.
+func (c *BuntDB) IncrBy(key string, delta int64, ttl ...time.Duration) (num int64, err error) {
+	key = c.Key(key)
+	err = c.update(func(tx *buntdb.Tx) error {
+		val, opt, err := c.loadForUpdate(tx, key, ttl)
+		if err != nil {
+			return err
+		}
+
+		if val != nil {
+			if num, err = mathutil.ToInt64(val); err != nil {
+				return cache.ErrNotNumber
+			}
+		}
+
+		num += delta
+		return c.save(tx, key, num, opt)
+	})
+	return
+}
+
+// IncrByFloat increment the key value by float delta.
+// the ttl is only used when the key is created.
+func (c *BuntDB) IncrByFloat(key string, delta float64, ttl ...time.Duration) (num float64, err error) {
+	key = c.Key(key)
+	err = c.update(func(tx *buntdb.Tx) error {
+		val, opt, err := c.loadForUpdate(tx, key, ttl)
+		if err != nil {
+			return err
+		}
+
+		if val != nil {
+			if num, err = mathutil.ToFloat(val); err != nil {
+				return cache.ErrNotNumber
+			}
+		}
+
+		num += delta
+		return c.save(tx, key, num, opt)
+	})
+	return
+}
+
+// Add set the key value only if the key does not exist
+func (c *BuntDB) Add(key string, val any, ttl time.Duration) (ok bool, err error) {
+	key = c.Key(key)
+	err = c.update(func(tx *buntdb.Tx) error {
+		_, err := tx.Get(key)
+		if err != buntdb.ErrNotFound {
+			return err
+		}
+
+		ok = true
+		return c.save(tx, key, val, newSetOptions(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// Replace set the key value only if the key already exists
+func (c *BuntDB) Replace(key string, val any, ttl time.Duration) (ok bool, err error) {
+	key = c.Key(key)
+	err = c.update(func(tx *buntdb.Tx) error {
+		if _, err := tx.Get(key); err != nil {
+			if err == buntdb.ErrNotFound {
+				return nil
+			}
+			return err
+		}
+
+		ok = true
+		return c.save(tx, key, val, newSetOptions(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (c *BuntDB) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (ok bool, err error) {
+	key = c.Key(key)
+	oldBts, err := c.MustMarshal(oldVal)
+	if err != nil {
+		return false, err
+	}
+
+	err = c.update(func(tx *buntdb.Tx) error {
+		str, err := tx.Get(key)
+		if err != nil {
+			if err == buntdb.ErrNotFound {
+				return nil
+			}
+			return err
+		}
+
+		if str != string(oldBts) {
+			return nil
+		}
+
+		ok = true
+		return c.save(tx, key, newVal, newSetOptions(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal
+func (c *BuntDB) CompareAndDelete(key string, oldVal any) (ok bool, err error) {
+	key = c.Key(key)
+	oldBts, err := c.MustMarshal(oldVal)
+	if err != nil {
+		return false, err
+	}
+
+	err = c.update(func(tx *buntdb.Tx) error {
+		str, err := tx.Get(key)
+		if err != nil {
+			if err == buntdb.ErrNotFound {
+				return nil
+			}
+			return err
+		}
+
+		if str != string(oldBts) {
+			return nil
+		}
+
+		ok = true
+		_, err = tx.Delete(key)
+		return err
+	})
+	return ok && err == nil, err
+}
+
+// TTL get the remaining time to live of the key
+func (c *BuntDB) TTL(key string) (ttl time.Duration, err error) {
+	key = c.Key(key)
+	err = c.view(func(tx *buntdb.Tx) error {
+		ttl, err = tx.TTL(key)
+		return err
+	})
+
+	if err == buntdb.ErrNotFound {
+		return 0, cache.ErrNotFound
+	}
+	if ttl < 0 { // no expiration
+		ttl = cache.Forever
+	}
+	return
+}
+
+// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration.
+func (c *BuntDB) Expire(key string, ttl time.Duration) error {
+	key = c.Key(key)
+	err := c.update(func(tx *buntdb.Tx) error {
+		str, err := tx.Get(key)
+		if err != nil {
+			return err
+		}
+
+		_, _, err = tx.Set(key, str, newSetOptions(ttl))
+		return err
+	})
+
+	if err == buntdb.ErrNotFound {
+		return cache.ErrNotFound
+	}
+	return err
+}
+
+// Persist remove the key expiration
+func (c *BuntDB) Persist(key string) error {
+	return c.Expire(key, cache.Forever)
+}
+
+// Touch refresh the key expiration to ttl from now
+func (c *BuntDB) Touch(key string, ttl time.Duration) error {
+	if err := c.Expire(key, ttl); err != cache.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// Clear all cache data. if the prefix is set, will only delete the keys with the prefix.
 func (c *BuntDB) Clear() error {
-	return c.db.Update(func(tx *buntdb.Tx) error {
-		return tx.DeleteAll()
+	return c.clear(c.Key(""))
+}
+
+// ClearPrefix delete the caches which key has the prefix, the driver prefix will be added.
+func (c *BuntDB) ClearPrefix(prefix string) error {
+	return c.clear(c.Key(prefix))
+}
+
+// delete the keys by the real key prefix
+func (c *BuntDB) clear(prefix string) error {
+	return c.update(func(tx *buntdb.Tx) error {
+		if prefix == "" {
+			return tx.DeleteAll()
+		}
+
+		var keys []string
+		err := tx.AscendGreaterOrEqual("", prefix, func(key, _ string) bool {
+			if !strings.HasPrefix(key, prefix) {
+				return false
+			}
+			keys = append(keys, key)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if _, err = tx.Delete(key); err != nil && err != buntdb.ErrNotFound {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
@@ -187,3 +437,88 @@ func (c *BuntDB) Clear() error {
 func (c *BuntDB) Close() error {
 	return c.db.Close()
 }
+
+// load the key value for update in a transaction. if the key exists, the returned
+// options will keep the key expire time, otherwise will use the optional create ttl.
+func (c *BuntDB) loadForUpdate(tx *buntdb.Tx, key string, ttl []time.Duration) (any, *buntdb.SetOptions, error) {
+	str, err := tx.Get(key)
+	if err == buntdb.ErrNotFound {
+		return nil, newSetOptions(cache.CreateTTL(ttl)), nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var val any
+	if err = c.UnmarshalTo([]byte(str), &val); err != nil {
+		return nil, nil, err
+	}
+
+	left, err := tx.TTL(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return val, newSetOptions(left), nil
+}
+
+// save the value to key in a transaction
+func (c *BuntDB) save(tx *buntdb.Tx, key string, val any, opt *buntdb.SetOptions) error {
+	bts, err := c.MustMarshal(val)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = tx.Set(key, string(bts), opt)
+	return err
+}
+
+func newSetOptions(ttl time.Duration) *buntdb.SetOptions {
+	opt := &buntdb.SetOptions{}
+	if ttl > 0 {
+		opt.TTL = ttl
+		opt.Expires = true
+	}
+	return opt
+}
+
+// run a read-only transaction, will check the context before run.
+func (c *BuntDB) view(fn func(tx *buntdb.Tx) error) error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+	return c.db.View(fn)
+}
+
+// run a read-write transaction, will check the context before run.
+func (c *BuntDB) update(fn func(tx *buntdb.Tx) error) error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+	return c.db.Update(fn)
+}
+
+/*************************************************************
+ * open by DSN url
+ *************************************************************/
+
+func init() {
+	cache.RegisterFactory(Name, openURL)
+}
+
+// open by the DSN url. eg: "buntdb:///path/to/my.db?prefix=app:", "buntdb://:memory:"
+func openURL(u *url.URL) (cache.Cache, error) {
+	optFns, err := cache.URLOptions(u)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := buntdb.Open(cmp.Or(cache.URLPath(u), Memory))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &BuntDB{db: db}
+	c.SetName(Name)
+	c.WithOptions(optFns...)
+	return c, nil
+}
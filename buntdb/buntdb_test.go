@@ -0,0 +1,45 @@
+package buntdb_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/buntdb"
+	"github.com/gookit/cache/cachetest"
+)
+
+func Example() {
+	c := buntdb.NewMemory()
+	defer c.Close()
+
+	key := "name"
+
+	// set
+	c.Set(key, "cache value", cache.Seconds2)
+	fmt.Println(c.Has(key))
+
+	// get
+	val := c.Get(key)
+	fmt.Println(val)
+
+	time.Sleep(2 * time.Second)
+
+	// get expired
+	val2 := c.Get(key)
+	fmt.Println(val2)
+
+	// Output:
+	// true
+	// cache value
+	// <nil>
+}
+
+func TestBuntDB_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		c := buntdb.NewMemory()
+		t.Cleanup(func() { c.Close() })
+		return c
+	})
+}
@@ -4,6 +4,8 @@
 package cache
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"github.com/gookit/gsr"
@@ -12,6 +14,82 @@ import (
 // Cache interface definition
 type Cache = gsr.SimpleCacher
 
+// ContextCacher interface definition. for drivers support operate with context.
+type ContextCacher = gsr.ContextCacher
+
+// WithContext returns the driver copy with ctx if it implements ContextCacher, otherwise returns itself.
+func WithContext(c Cache, ctx context.Context) Cache {
+	if cc, ok := c.(ContextCacher); ok {
+		return cc.WithContext(ctx)
+	}
+	return c
+}
+
+// Counter interface definition. for drivers support atomic counter.
+//
+// The optional ttl is only used when the key is created by the operation,
+// an exists counter will keep its expire time.
+type Counter interface {
+	// Incr increment the key value by 1
+	Incr(key string, ttl ...time.Duration) (int64, error)
+	// Decr decrement the key value by 1
+	Decr(key string, ttl ...time.Duration) (int64, error)
+	// IncrBy increment the key value by delta
+	IncrBy(key string, delta int64, ttl ...time.Duration) (int64, error)
+	// IncrByFloat increment the key value by float delta
+	IncrByFloat(key string, delta float64, ttl ...time.Duration) (float64, error)
+}
+
+// ConditionalSetter interface definition. for drivers support conditional write.
+//
+// The returned bool reports whether the value has been written. the values of CompareAndSwap are equal
+// if they are deep equal or their marshaled data are equal, eg: int(1) and float64(1) by JSON.
+type ConditionalSetter interface {
+	// Add set the key value only if the key does not exist
+	Add(key string, val any, ttl time.Duration) (bool, error)
+	// Replace set the key value only if the key already exists
+	Replace(key string, val any, ttl time.Duration) (bool, error)
+	// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+	CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (bool, error)
+}
+
+// CompareDeleter interface definition. for drivers support conditional delete.
+type CompareDeleter interface {
+	// CompareAndDelete delete the key only if the current value is equals to oldVal.
+	// The returned bool reports whether the key has been deleted.
+	CompareAndDelete(key string, oldVal any) (bool, error)
+}
+
+// TTLer interface definition. for drivers support inspect and update the key expiration.
+type TTLer interface {
+	// TTL get the remaining time to live of the key.
+	// returns Forever if the key has no expiration, returns ErrNotFound if the key does not exist.
+	TTL(key string) (time.Duration, error)
+	// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration, same as Persist.
+	// returns ErrNotFound if the key does not exist.
+	Expire(key string, ttl time.Duration) error
+	// Persist remove the key expiration. returns ErrNotFound if the key does not exist.
+	Persist(key string) error
+	// Touch refresh the key expiration to ttl from now. unlike Expire, a missing key is not an error.
+	Touch(key string, ttl time.Duration) error
+}
+
+// PrefixClearer interface definition. for drivers can enumerate the keys.
+type PrefixClearer interface {
+	// ClearPrefix delete the caches which key has the prefix. the prefix option of driver will be added.
+	ClearPrefix(prefix string) error
+}
+
+// some generic errors
+var (
+	// ErrNotNumber the cache value is not a number
+	ErrNotNumber = errors.New("cache value is not a number")
+	// ErrNotFound the cache key does not exist
+	ErrNotFound = errors.New("cache key not found")
+	// ErrNotSupported the operation is not supported by the driver
+	ErrNotSupported = errors.New("operation is not supported by the driver")
+)
+
 // some generic expire time define.
 const (
 	// Forever Always exist
@@ -125,6 +203,11 @@ func Driver(driverName string) Cache {
 	return std.Driver(driverName)
 }
 
+// UseHook add hooks for observe the operations of all drivers in the default manager. eg: tracing
+func UseHook(hooks ...Hook) *Manager {
+	return std.UseHook(hooks...)
+}
+
 // Std get default cache manager instance
 func Std() *Manager {
 	return std
@@ -145,6 +228,11 @@ func Close() error {
 	return std.Close()
 }
 
+// Shutdown close all drivers in parallel, and wait them done or the ctx is done.
+func Shutdown(ctx context.Context) error {
+	return std.Shutdown(ctx)
+}
+
 // ClearAll all drivers caches
 func ClearAll() error {
 	return std.ClearAll()
@@ -0,0 +1,625 @@
+// Package cachetest provide a conformance test suite for the cache drivers.
+//
+// Usage:
+//
+//	func TestSuite(t *testing.T) {
+//		cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+//			c := mydriver.New()
+//			t.Cleanup(func() { c.Close() })
+//			return c
+//		})
+//	}
+package cachetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/gsr"
+)
+
+// Factory create a new and empty cache driver for test.
+// it will be called for each sub test, please close the driver by t.Cleanup() if needed.
+type Factory func(t *testing.T) cache.Cache
+
+// Options for run the suite
+type Options struct {
+	// TTLPrecision the expire time precision of the driver. default is 10ms
+	//
+	// eg: the memcached ttl precision is 1 second
+	TTLPrecision time.Duration
+	// ClearAll mark the Clear() of the driver will delete all data, not only the keys with prefix.
+	ClearAll bool
+	// Concurrency the goroutine number for the concurrency tests. default is 8
+	Concurrency int
+}
+
+// WithTTLPrecision set the expire time precision of the driver
+func WithTTLPrecision(precision time.Duration) func(opt *Options) {
+	return func(opt *Options) {
+		opt.TTLPrecision = precision
+	}
+}
+
+// WithClearAll mark the Clear() of the driver will delete all data, not only the keys with prefix.
+func WithClearAll() func(opt *Options) {
+	return func(opt *Options) {
+		opt.ClearAll = true
+	}
+}
+
+// optioner the driver that can set the cache.Option. eg: embed the cache.BaseDriver
+type optioner interface {
+	WithOptions(optFns ...func(option *cache.Option))
+}
+
+type suite struct {
+	Options
+	factory Factory
+}
+
+// RunSuite run the conformance test suite on the driver created by the factory.
+//
+// The optional interfaces: cache.Counter, cache.ConditionalSetter, cache.CompareDeleter,
+// cache.TTLer and gsr.ContextCacher will be tested if the driver implements them.
+// the key prefix tests only run if the driver embeds the cache.BaseDriver.
+func RunSuite(t *testing.T, factory Factory, optFns ...func(opt *Options)) {
+	s := &suite{
+		factory: factory,
+		Options: Options{
+			TTLPrecision: 10 * time.Millisecond,
+			Concurrency:  8,
+		},
+	}
+	for _, fn := range optFns {
+		fn(&s.Options)
+	}
+
+	t.Run("Miss", s.testMiss)
+	t.Run("SetGet", s.testSetGet)
+	t.Run("Overwrite", s.testOverwrite)
+	t.Run("Expire", s.testExpire)
+	t.Run("Multi", s.testMulti)
+	t.Run("Clear", s.testClear)
+	t.Run("Prefix", s.testPrefix)
+	t.Run("Concurrency", s.testConcurrency)
+	t.Run("Counter", s.testCounter)
+	t.Run("ConditionalSetter", s.testConditionalSetter)
+	t.Run("CompareDeleter", s.testCompareDeleter)
+	t.Run("CompareCodec", s.testCompareCodec)
+	t.Run("TTLer", s.testTTLer)
+	t.Run("PrefixClearer", s.testPrefixClearer)
+	t.Run("ContextCacher", s.testContextCacher)
+}
+
+// ttl for the expire tests, and the wait time for it expired.
+func (s *suite) ttl() (ttl, wait time.Duration) {
+	ttl = max(s.TTLPrecision, 50*time.Millisecond)
+	return ttl, ttl + s.TTLPrecision + 50*time.Millisecond
+}
+
+// EqualValue assert the cache value is equals to want.
+// the value maybe (un)marshaled by the driver, eg: int 1 will be float64 1 after json decode.
+func EqualValue(t testing.TB, want, got any, fmtAndArgs ...any) {
+	t.Helper()
+	if !cache.EqualValue(want, got) {
+		assert.Fail(t, fmt.Sprintf("Not equal value:\nexpect: %#v\nactual: %#v", want, got), fmtAndArgs...)
+	}
+}
+
+func (s *suite) testMiss(t *testing.T) {
+	c := s.factory(t)
+	is := assert.New(t)
+
+	is.False(c.Has("not-exist"))
+	is.Nil(c.Get("not-exist"))
+	is.NoErr(c.Del("not-exist"))
+	is.NoErr(c.DelMulti([]string{"not-exist", "not-exist2"}))
+
+	// not exists keys are ignored or nil
+	vals := c.GetMulti([]string{"not-exist", "not-exist2"})
+	is.Nil(vals["not-exist"])
+	is.Nil(vals["not-exist2"])
+}
+
+func (s *suite) testSetGet(t *testing.T) {
+	c := s.factory(t)
+	is := assert.New(t)
+
+	values := map[string]any{
+		"string": "value",
+		"int":    23,
+		"float":  2.5,
+		"bool":   true,
+		"slice":  []string{"a", "b"},
+		"map":    map[string]any{"name": "inhere"},
+	}
+
+	for key, val := range values {
+		is.NoErr(c.Set(key, val, 0), key)
+		is.True(c.Has(key), key)
+		EqualValue(t, val, c.Get(key), key)
+	}
+
+	is.NoErr(c.Del("string"))
+	is.False(c.Has("string"))
+	is.Nil(c.Get("string"))
+	is.True(c.Has("int"))
+}
+
+func (s *suite) testOverwrite(t *testing.T) {
+	c := s.factory(t)
+	is := assert.New(t)
+	ttl, wait := s.ttl()
+
+	is.NoErr(c.Set("key", "v1", 0))
+	is.NoErr(c.Set("key", "v2", 0))
+	EqualValue(t, "v2", c.Get("key"))
+
+	// overwrite the ttl
+	is.NoErr(c.Set("key", "v3", ttl))
+	is.NoErr(c.Set("key", "v4", cache.Forever))
+	time.Sleep(wait)
+	EqualValue(t, "v4", c.Get("key"))
+}
+
+func (s *suite) testExpire(t *testing.T) {
+	c := s.factory(t)
+	is := assert.New(t)
+	ttl, wait := s.ttl()
+
+	is.NoErr(c.Set("expire", "value", ttl))
+	is.NoErr(c.Set("forever", "value", cache.Forever))
+	is.NoErr(c.SetMulti(map[string]any{"multi1": "v1", "multi2": "v2"}, ttl))
+	is.True(c.Has("expire"))
+	is.True(c.Has("multi1"))
+
+	time.Sleep(wait)
+	is.False(c.Has("expire"))
+	is.Nil(c.Get("expire"))
+	is.Nil(c.Get("multi1"))
+	is.Nil(c.GetMulti([]string{"multi2"})["multi2"])
+
+	// zero ttl means forever
+	is.True(c.Has("forever"))
+	EqualValue(t, "value", c.Get("forever"))
+}
+
+func (s *suite) testMulti(t *testing.T) {
+	c := s.factory(t)
+	is := assert.New(t)
+
+	is.NoErr(c.SetMulti(map[string]any{"k1": "v1", "k2": 2, "k3": "v3"}, 0))
+	vals := c.GetMulti([]string{"k1", "k2", "k4"})
+	EqualValue(t, "v1", vals["k1"])
+	EqualValue(t, 2, vals["k2"])
+	is.Nil(vals["k4"])
+	_, ok := vals["k3"]
+	is.False(ok, "should not return the key not requested")
+
+	is.NoErr(c.DelMulti([]string{"k1", "k2", "k4"}))
+	is.False(c.Has("k1"))
+	is.False(c.Has("k2"))
+	is.True(c.Has("k3"))
+}
+
+func (s *suite) testClear(t *testing.T) {
+	c := s.factory(t)
+	is := assert.New(t)
+
+	is.NoErr(c.SetMulti(map[string]any{"k1": "v1", "k2": "v2"}, 0))
+	is.NoErr(c.Set("k3", "v3", cache.Seconds30))
+	is.NoErr(c.Clear())
+
+	is.False(c.Has("k1"))
+	is.False(c.Has("k2"))
+	is.False(c.Has("k3"))
+
+	// clear an empty cache
+	is.NoErr(c.Clear())
+
+	// can be used after clear
+	is.NoErr(c.Set("k1", "v1", 0))
+	EqualValue(t, "v1", c.Get("k1"))
+}
+
+func (s *suite) testPrefix(t *testing.T) {
+	c := s.factory(t)
+	o, ok := c.(optioner)
+	if !ok {
+		t.Skip("the driver does not support options")
+	}
+
+	is := assert.New(t)
+	o.WithOptions(cache.WithPrefix(""))
+	is.NoErr(c.Set("other", "value", 0))
+
+	o.WithOptions(cache.WithPrefix("p1:"))
+	is.False(c.Has("other"))
+	is.NoErr(c.Set("key", "v1", 0))
+	is.NoErr(c.SetMulti(map[string]any{"k1": "v1", "k2": "v2"}, 0))
+	EqualValue(t, "v1", c.Get("key"))
+	is.Eq(2, len(c.GetMulti([]string{"k1", "k2"})))
+	EqualValue(t, "v2", c.GetMulti([]string{"k1", "k2"})["k2"])
+
+	// the keys are isolated by prefix
+	o.WithOptions(cache.WithPrefix(""))
+	is.False(c.Has("key"))
+	is.False(c.Has("k2"))
+	o.WithOptions(cache.WithPrefix("p2:"))
+	is.False(c.Has("key"))
+	is.NoErr(c.Set("key", "v2", 0))
+
+	// Clear scope
+	o.WithOptions(cache.WithPrefix("p1:"))
+	EqualValue(t, "v1", c.Get("key"))
+	is.NoErr(c.DelMulti([]string{"k1"}))
+	is.NoErr(c.Clear())
+	is.False(c.Has("key"))
+	is.False(c.Has("k2"))
+
+	o.WithOptions(cache.WithPrefix(""))
+	if !s.ClearAll {
+		o.WithOptions(cache.WithPrefix("p2:"))
+		EqualValue(t, "v2", c.Get("key"), "Clear() with prefix should only delete the keys with prefix")
+		o.WithOptions(cache.WithPrefix(""))
+		is.True(c.Has("other"), "Clear() with prefix should only delete the keys with prefix")
+	}
+}
+
+func (s *suite) testConcurrency(t *testing.T) {
+	c := s.factory(t)
+	is := assert.New(t)
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, s.Concurrency)
+
+	for i := 0; i < s.Concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			own := fmt.Sprintf("own%d", i)
+
+			for j := 0; j < 20; j++ {
+				// shared keys
+				key := fmt.Sprintf("shared%d", j%4)
+				if err := c.Set(key, j, 0); err != nil {
+					errCh <- err
+					return
+				}
+				c.Get(key)
+				c.Has(key)
+				c.GetMulti([]string{key, own})
+
+				// own keys
+				if err := c.Set(own, j, 0); err != nil {
+					errCh <- err
+					return
+				}
+				if !cache.EqualValue(j, c.Get(own)) {
+					errCh <- fmt.Errorf("the key %q value should be %d", own, j)
+					return
+				}
+			}
+
+			if err := c.Del(own); err != nil {
+				errCh <- err
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		is.NoErr(err)
+	}
+
+	for i := 0; i < s.Concurrency; i++ {
+		is.False(c.Has(fmt.Sprintf("own%d", i)))
+	}
+}
+
+func (s *suite) testCounter(t *testing.T) {
+	c := s.factory(t)
+	ct, ok := c.(cache.Counter)
+	if !ok {
+		t.Skip("the driver does not implement cache.Counter")
+	}
+
+	is := assert.New(t)
+	ttl, wait := s.ttl()
+
+	num, err := ct.Incr("num")
+	is.NoErr(err)
+	is.Eq(int64(1), num)
+	num, err = ct.IncrBy("num", 10)
+	is.NoErr(err)
+	is.Eq(int64(11), num)
+	num, err = ct.Decr("num")
+	is.NoErr(err)
+	is.Eq(int64(10), num)
+	EqualValue(t, 10, c.Get("num"))
+
+	// the ttl only used on create
+	num, err = ct.IncrBy("expire", 5, ttl)
+	is.NoErr(err)
+	is.Eq(int64(5), num)
+	_, err = ct.Incr("num", ttl)
+	is.NoErr(err)
+
+	// not a number
+	is.NoErr(c.Set("str", "abc", 0))
+	_, err = ct.Incr("str")
+	is.Err(err)
+
+	f, err := ct.IncrByFloat("float", 1.5)
+	if !errors.Is(err, cache.ErrNotSupported) {
+		is.NoErr(err)
+		is.Eq(1.5, f)
+		f, err = ct.IncrByFloat("float", 1)
+		is.NoErr(err)
+		is.Eq(2.5, f)
+	}
+
+	// concurrency
+	var wg sync.WaitGroup
+	for i := 0; i < s.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				_, _ = ct.Incr("concurrency")
+			}
+		}()
+	}
+	wg.Wait()
+	num, err = ct.IncrBy("concurrency", 0)
+	is.NoErr(err)
+	is.Eq(int64(s.Concurrency*10), num)
+
+	time.Sleep(wait)
+	is.False(c.Has("expire"))
+	is.True(c.Has("num"))
+}
+
+func (s *suite) testConditionalSetter(t *testing.T) {
+	c := s.factory(t)
+	cs, ok := c.(cache.ConditionalSetter)
+	if !ok {
+		t.Skip("the driver does not implement cache.ConditionalSetter")
+	}
+
+	is := assert.New(t)
+	ttl, wait := s.ttl()
+
+	ok, err := cs.Replace("key", "v0", 0)
+	is.NoErr(err)
+	is.False(ok)
+	is.False(c.Has("key"))
+
+	ok, err = cs.Add("key", "v1", 0)
+	is.NoErr(err)
+	is.True(ok)
+	ok, err = cs.Add("key", "v2", 0)
+	is.NoErr(err)
+	is.False(ok)
+	EqualValue(t, "v1", c.Get("key"))
+
+	ok, err = cs.Replace("key", "v2", 0)
+	is.NoErr(err)
+	is.True(ok)
+	EqualValue(t, "v2", c.Get("key"))
+
+	ok, err = cs.CompareAndSwap("key", "v1", "v3", 0)
+	is.NoErr(err)
+	is.False(ok)
+	ok, err = cs.CompareAndSwap("not-exist", "v1", "v3", 0)
+	is.NoErr(err)
+	is.False(ok)
+	ok, err = cs.CompareAndSwap("key", "v2", "v3", ttl)
+	is.NoErr(err)
+	is.True(ok)
+	EqualValue(t, "v3", c.Get("key"))
+
+	// expired key can be added again
+	ok, err = cs.Add("expire", "v1", ttl)
+	is.NoErr(err)
+	is.True(ok)
+	time.Sleep(wait)
+	is.False(c.Has("key"))
+	ok, err = cs.Add("expire", "v2", 0)
+	is.NoErr(err)
+	is.True(ok)
+
+	// only one winner
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var wins int
+	for i := 0; i < s.Concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if ok, err := cs.Add("race", i, 0); err == nil && ok {
+				mu.Lock()
+				wins++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	is.Eq(1, wins)
+}
+
+func (s *suite) testCompareDeleter(t *testing.T) {
+	c := s.factory(t)
+	cd, ok := c.(cache.CompareDeleter)
+	if !ok {
+		t.Skip("the driver does not implement cache.CompareDeleter")
+	}
+
+	is := assert.New(t)
+	ok, err := cd.CompareAndDelete("not-exist", "v1")
+	is.NoErr(err)
+	is.False(ok)
+
+	is.NoErr(c.Set("key", "v1", 0))
+	ok, err = cd.CompareAndDelete("key", "v2")
+	is.NoErr(err)
+	is.False(ok)
+	is.True(c.Has("key"))
+
+	ok, err = cd.CompareAndDelete("key", "v1")
+	is.NoErr(err)
+	is.True(ok)
+	is.False(c.Has("key"))
+}
+
+// the CompareAndSwap, CompareAndDelete compare the values by the codec of driver, not the JSON.
+func (s *suite) testCompareCodec(t *testing.T) {
+	c := s.factory(t)
+	o, ok := c.(optioner)
+	if !ok {
+		t.Skip("the driver does not support options")
+	}
+	cs, ok := c.(cache.ConditionalSetter)
+	if !ok {
+		t.Skip("the driver does not implement cache.ConditionalSetter")
+	}
+
+	is := assert.New(t)
+	o.WithOptions(cache.WithCodec(cache.RawCodec{}))
+	if err := c.Set("key", "abc", 0); err != nil {
+		t.Skipf("the driver does not support the raw codec: %v", err)
+	}
+
+	ok, err := cs.CompareAndSwap("key", "abc", "def", 0)
+	is.NoErr(err)
+	is.True(ok)
+	EqualValue(t, []byte("def"), c.Get("key"))
+
+	if cd, ok := c.(cache.CompareDeleter); ok {
+		ok, err = cd.CompareAndDelete("key", []byte("def"))
+		is.NoErr(err)
+		is.True(ok)
+		is.False(c.Has("key"))
+	}
+}
+
+func (s *suite) testPrefixClearer(t *testing.T) {
+	c := s.factory(t)
+	pc, ok := c.(cache.PrefixClearer)
+	if !ok {
+		t.Skip("the driver does not implement cache.PrefixClearer")
+	}
+
+	is := assert.New(t)
+	is.NoErr(c.SetMulti(map[string]any{"ns1:k1": "v1", "ns1:k2": "v2", "ns2:k1": "v3", "other": "v4"}, 0))
+
+	err := pc.ClearPrefix("ns1:")
+	if errors.Is(err, cache.ErrNotSupported) {
+		t.Skip("the driver does not support ClearPrefix")
+	}
+	is.NoErr(err)
+	is.False(c.Has("ns1:k1"))
+	is.False(c.Has("ns1:k2"))
+	EqualValue(t, "v3", c.Get("ns2:k1"))
+	EqualValue(t, "v4", c.Get("other"))
+
+	// with the prefix option
+	if o, ok := c.(optioner); ok {
+		o.WithOptions(cache.WithPrefix("p2:"))
+		is.NoErr(c.Set("ns2:k1", "v6", 0))
+		o.WithOptions(cache.WithPrefix("p1:"))
+		is.NoErr(c.Set("ns2:k1", "v5", 0))
+		is.NoErr(pc.ClearPrefix("ns2:"))
+		is.False(c.Has("ns2:k1"))
+
+		o.WithOptions(cache.WithPrefix("p2:"))
+		EqualValue(t, "v6", c.Get("ns2:k1"))
+	}
+}
+
+func (s *suite) testTTLer(t *testing.T) {
+	c := s.factory(t)
+	tl, ok := c.(cache.TTLer)
+	if !ok {
+		t.Skip("the driver does not implement cache.TTLer")
+	}
+
+	is := assert.New(t)
+	ttl, wait := s.ttl()
+
+	is.NoErr(c.Set("forever", "value", 0))
+	is.NoErr(c.Set("key", "value", cache.Seconds30))
+
+	left, err := tl.TTL("key")
+	supportTTL := !errors.Is(err, cache.ErrNotSupported)
+	if supportTTL {
+		is.NoErr(err)
+		is.True(left > 0 && left <= cache.Seconds30, "invalid ttl: %s", left)
+
+		left, err = tl.TTL("forever")
+		is.NoErr(err)
+		is.Eq(time.Duration(cache.Forever), left)
+
+		_, err = tl.TTL("not-exist")
+		is.ErrIs(err, cache.ErrNotFound)
+	}
+
+	// persist
+	is.NoErr(tl.Persist("key"))
+	if supportTTL {
+		left, err = tl.TTL("key")
+		is.NoErr(err)
+		is.Eq(time.Duration(cache.Forever), left)
+	}
+
+	// expire and touch
+	is.ErrIs(tl.Expire("not-exist", ttl), cache.ErrNotFound)
+	is.NoErr(tl.Touch("not-exist", ttl))
+	is.False(c.Has("not-exist"))
+
+	is.NoErr(tl.Expire("key", ttl))
+	is.NoErr(tl.Touch("forever", ttl))
+	is.NoErr(c.Set("persist", "value", ttl))
+	is.NoErr(tl.Expire("persist", 0))
+
+	time.Sleep(wait)
+	is.False(c.Has("key"))
+	is.False(c.Has("forever"))
+	EqualValue(t, "value", c.Get("persist"))
+}
+
+func (s *suite) testContextCacher(t *testing.T) {
+	c := s.factory(t)
+	cc, ok := c.(gsr.ContextCacher)
+	if !ok {
+		t.Skip("the driver does not implement gsr.ContextCacher")
+	}
+
+	is := assert.New(t)
+	is.NoErr(c.Set("key", "value", 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c2 := cc.WithContext(ctx)
+	is.NoErr(c2.Set("key2", "value2", 0))
+	EqualValue(t, "value", c2.Get("key"))
+	EqualValue(t, "value2", c.Get("key2"))
+
+	cancel()
+	is.ErrIs(c2.Set("key", "value3", 0), context.Canceled)
+	is.ErrIs(c2.Del("key"), context.Canceled)
+	is.ErrIs(c2.SetMulti(map[string]any{"key": "value3"}, 0), context.Canceled)
+	is.Nil(c2.Get("key"))
+	EqualValue(t, "value", c.Get("key"))
+
+	// the origin driver is not affected
+	is.NoErr(c.Set("key", "value4", 0))
+	EqualValue(t, "value4", c.Get("key"))
+}
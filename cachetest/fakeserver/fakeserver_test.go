@@ -0,0 +1,119 @@
+package fakeserver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/gomodule/redigo/redis"
+	"github.com/gookit/cache/cachetest/fakeserver"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func TestRedis(t *testing.T) {
+	is := assert.New(t)
+	srv, err := fakeserver.NewRedis()
+	is.NoErr(err)
+	defer srv.Close()
+
+	conn, err := redis.Dial("tcp", srv.Addr())
+	is.NoErr(err)
+	defer conn.Close()
+
+	_, err = conn.Do("NOT-EXISTS")
+	is.ErrMsgContains(err, "unknown command")
+	_, err = conn.Do("GET")
+	is.ErrMsgContains(err, "wrong number of arguments")
+
+	// set, get
+	is.Eq("OK", mustDo(t, redis.String, conn, "SET", "key", "value", "PX", 50))
+	is.Eq("value", mustDo(t, redis.String, conn, "GET", "key"))
+	ok, err := redis.Bool(conn.Do("SET", "key", "value2", "NX"))
+	is.Err(err) // nil reply
+	is.False(ok)
+
+	time.Sleep(60 * time.Millisecond)
+	is.Eq(int64(-2), mustDo(t, redis.Int64, conn, "PTTL", "key"))
+
+	// scan by pattern
+	_, err = conn.Do("MSET", "a:1", 1, "a:2", 2, "b:1", 3, "a[x]", 4)
+	is.NoErr(err)
+	values, err := redis.Values(conn.Do("SCAN", 0, "MATCH", "a:*"))
+	is.NoErr(err)
+	keys, err := redis.Strings(values[1], nil)
+	is.NoErr(err)
+	is.Eq([]string{"a:1", "a:2"}, keys)
+	values, err = redis.Values(conn.Do("SCAN", 0, "MATCH", `a\[?]`))
+	is.NoErr(err)
+	keys, _ = redis.Strings(values[1], nil)
+	is.Eq([]string{"a[x]"}, keys)
+
+	// transaction
+	is.NoErr(conn.Send("MULTI"))
+	is.NoErr(conn.Send("INCRBY", "num", 2))
+	is.NoErr(conn.Send("INCRBYFLOAT", "num", 1.5))
+	replies, err := redis.Values(conn.Do("EXEC"))
+	is.NoErr(err)
+	is.Eq(int64(2), replies[0])
+	is.Eq([]byte("3.5"), replies[1])
+	_, err = conn.Do("INCR", "num")
+	is.ErrMsgContains(err, "not an integer")
+
+	// select db
+	is.Eq("OK", mustDo(t, redis.String, conn, "SELECT", 1))
+	is.Eq(int64(0), mustDo(t, redis.Int64, conn, "EXISTS", "a:1"))
+	is.Eq(int64(0), mustDo(t, redis.Int64, conn, "DBSIZE"))
+
+	// scripts
+	_, err = redis.NewScript(0, "return 1").Do(conn)
+	is.ErrMsgContains(err, "unsupported script")
+}
+
+func TestMemcached(t *testing.T) {
+	is := assert.New(t)
+	srv, err := fakeserver.NewMemcached()
+	is.NoErr(err)
+	defer srv.Close()
+
+	c := memcache.New(srv.Addr())
+	is.NoErr(c.Ping())
+
+	// set, append, prepend
+	is.NoErr(c.Set(&memcache.Item{Key: "key", Value: []byte("b"), Flags: 3}))
+	is.NoErr(c.Append(&memcache.Item{Key: "key", Value: []byte("c")}))
+	is.NoErr(c.Prepend(&memcache.Item{Key: "key", Value: []byte("a")}))
+	it, err := c.Get("key")
+	is.NoErr(err)
+	is.Eq("abc", string(it.Value))
+	is.Eq(uint32(3), it.Flags)
+
+	// cas
+	it.Value = []byte("new")
+	is.NoErr(c.CompareAndSwap(it))
+	is.ErrIs(c.CompareAndSwap(it), memcache.ErrCASConflict)
+
+	// counter
+	_, err = c.Increment("key", 1)
+	is.ErrMsgContains(err, "non-numeric value")
+	is.NoErr(c.Set(&memcache.Item{Key: "num", Value: []byte("1")}))
+	n, err := c.Decrement("num", 5)
+	is.NoErr(err)
+	is.Eq(uint64(0), n)
+
+	// expiration
+	is.NoErr(c.Touch("num", -1))
+	_, err = c.Get("num")
+	is.ErrIs(err, memcache.ErrCacheMiss)
+	is.ErrIs(c.Touch("num", 1), memcache.ErrCacheMiss)
+
+	is.NoErr(c.DeleteAll())
+	_, err = c.Get("key")
+	is.ErrIs(err, memcache.ErrCacheMiss)
+}
+
+func mustDo[T any](t *testing.T, conv func(any, error) (T, error), conn redis.Conn, cmd string, args ...any) T {
+	t.Helper()
+	val, err := conv(conn.Do(cmd, args...))
+	assert.NoErr(t, err)
+	return val
+}
@@ -0,0 +1,332 @@
+package fakeserver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memcached a fake memcached server, speak the text protocol.
+//
+// Supported commands:
+//
+//	get gets set add replace append prepend cas delete
+//	incr decr touch flush_all version verbosity quit
+type Memcached struct {
+	server
+	mu    sync.Mutex
+	items map[string]*memcachedItem
+	// last cas unique id
+	casID uint64
+}
+
+type memcachedItem struct {
+	val   []byte
+	flags uint32
+	casID uint64
+	// expire time, zero is never expired
+	exp time.Time
+}
+
+// max relative expiration seconds, the larger value is a unix timestamp
+const maxRelativeExpire = 30 * 24 * 3600
+
+// NewMemcached create and start a fake memcached server on a random local port.
+func NewMemcached() (*Memcached, error) {
+	s := &Memcached{items: make(map[string]*memcachedItem)}
+	if err := s.start(s.handle); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// FlushAll delete all items
+func (s *Memcached) FlushAll() {
+	s.mu.Lock()
+	s.items = make(map[string]*memcachedItem)
+	s.mu.Unlock()
+}
+
+// handle a client connection
+func (s *Memcached) handle(rd *bufio.Reader, wr *bufio.Writer) error {
+	for {
+		line, err := readLine(rd)
+		if err != nil {
+			return err
+		}
+
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			_, _ = wr.WriteString("ERROR\r\n")
+		} else if args[0] == "quit" {
+			return nil
+		} else if err = s.dispatch(rd, wr, args); err != nil {
+			return err
+		}
+
+		if err = wr.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// dispatch the command. only returns error on the connection should be closed.
+func (s *Memcached) dispatch(rd *bufio.Reader, wr *bufio.Writer, args []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reply string
+	switch args[0] {
+	case "get", "gets":
+		s.get(wr, args)
+		return nil
+	case "set", "add", "replace", "append", "prepend", "cas":
+		return s.store(rd, wr, args)
+	case "delete":
+		reply = s.delete(args)
+	case "incr", "decr":
+		reply = s.incr(args)
+	case "touch":
+		reply = s.touch(args)
+	case "flush_all":
+		s.items = make(map[string]*memcachedItem)
+		reply = "OK"
+	case "version":
+		reply = "VERSION 1.6.0-fake"
+	case "verbosity":
+		reply = "OK"
+	default:
+		reply = "ERROR"
+	}
+
+	if reply != "" && !isNoReply(args) {
+		_, _ = wr.WriteString(reply + "\r\n")
+	}
+	return nil
+}
+
+// lookup the key, the expired item will be deleted.
+func (s *Memcached) lookup(key string) *memcachedItem {
+	it, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+
+	if !it.exp.IsZero() && !time.Now().Before(it.exp) {
+		delete(s.items, key)
+		return nil
+	}
+	return it
+}
+
+// get <key>*, gets <key>*
+func (s *Memcached) get(wr *bufio.Writer, args []string) {
+	for _, key := range args[1:] {
+		it := s.lookup(key)
+		if it == nil {
+			continue
+		}
+
+		if args[0] == "gets" {
+			_, _ = fmt.Fprintf(wr, "VALUE %s %d %d %d\r\n", key, it.flags, len(it.val), it.casID)
+		} else {
+			_, _ = fmt.Fprintf(wr, "VALUE %s %d %d\r\n", key, it.flags, len(it.val))
+		}
+		_, _ = wr.Write(it.val)
+		_, _ = wr.WriteString("\r\n")
+	}
+	_, _ = wr.WriteString("END\r\n")
+}
+
+// <command> <key> <flags> <exptime> <bytes> [noreply]
+// cas <key> <flags> <exptime> <bytes> <cas unique> [noreply]
+func (s *Memcached) store(rd *bufio.Reader, wr *bufio.Writer, args []string) error {
+	cmd := args[0]
+	minArgs := 5
+	if cmd == "cas" {
+		minArgs = 6
+	}
+	if len(args) < minArgs {
+		_, _ = wr.WriteString("ERROR\r\n")
+		return nil
+	}
+
+	size, err := strconv.Atoi(args[4])
+	if err != nil || size < 0 {
+		_, _ = wr.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return nil
+	}
+
+	data := make([]byte, size+2)
+	if _, err = io.ReadFull(rd, data); err != nil {
+		return err
+	}
+	if string(data[size:]) != "\r\n" {
+		_, _ = wr.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return nil
+	}
+
+	flags, err1 := strconv.ParseUint(args[2], 10, 32)
+	exptime, err2 := strconv.ParseInt(args[3], 10, 64)
+	if err1 != nil || err2 != nil {
+		_, _ = wr.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return nil
+	}
+
+	reply := s.storeItem(cmd, args, data[:size], uint32(flags), exptime)
+	if !isNoReply(args) {
+		_, _ = wr.WriteString(reply + "\r\n")
+	}
+	return nil
+}
+
+func (s *Memcached) storeItem(cmd string, args []string, val []byte, flags uint32, exptime int64) string {
+	key := args[1]
+	old := s.lookup(key)
+	keepExp := false
+
+	switch cmd {
+	case "add":
+		if old != nil {
+			return "NOT_STORED"
+		}
+	case "replace":
+		if old == nil {
+			return "NOT_STORED"
+		}
+	case "append", "prepend":
+		if old == nil {
+			return "NOT_STORED"
+		}
+
+		if cmd == "append" {
+			val = append(append([]byte{}, old.val...), val...)
+		} else {
+			val = append(val, old.val...)
+		}
+		flags, keepExp = old.flags, true
+	case "cas":
+		casID, err := strconv.ParseUint(args[5], 10, 64)
+		if err != nil {
+			return "CLIENT_ERROR bad command line format"
+		}
+		if old == nil {
+			return "NOT_FOUND"
+		}
+		if old.casID != casID {
+			return "EXISTS"
+		}
+	}
+
+	s.casID++
+	it := &memcachedItem{val: val, flags: flags, casID: s.casID}
+	if keepExp {
+		it.exp = old.exp
+	} else if exp, expired := expireAt(exptime); expired {
+		// store an expired item is same as delete it
+		delete(s.items, key)
+		return "STORED"
+	} else {
+		it.exp = exp
+	}
+
+	s.items[key] = it
+	return "STORED"
+}
+
+// delete <key> [noreply]
+func (s *Memcached) delete(args []string) string {
+	if len(args) < 2 {
+		return "ERROR"
+	}
+
+	if s.lookup(args[1]) == nil {
+		return "NOT_FOUND"
+	}
+
+	delete(s.items, args[1])
+	return "DELETED"
+}
+
+// incr <key> <value> [noreply], decr <key> <value> [noreply]
+func (s *Memcached) incr(args []string) string {
+	if len(args) < 3 {
+		return "ERROR"
+	}
+
+	delta, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR invalid numeric delta argument"
+	}
+
+	it := s.lookup(args[1])
+	if it == nil {
+		return "NOT_FOUND"
+	}
+
+	num, err := strconv.ParseUint(string(it.val), 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR cannot increment or decrement non-numeric value"
+	}
+
+	if args[0] == "incr" {
+		num += delta // wrap around on overflow
+	} else if delta > num {
+		num = 0 // cannot decrement below 0
+	} else {
+		num -= delta
+	}
+
+	s.casID++
+	it.casID = s.casID
+	it.val = strconv.AppendUint(nil, num, 10)
+	return string(it.val)
+}
+
+// touch <key> <exptime> [noreply]
+func (s *Memcached) touch(args []string) string {
+	if len(args) < 3 {
+		return "ERROR"
+	}
+
+	exptime, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR invalid exptime argument"
+	}
+
+	it := s.lookup(args[1])
+	if it == nil {
+		return "NOT_FOUND"
+	}
+
+	if exp, expired := expireAt(exptime); expired {
+		delete(s.items, args[1])
+	} else {
+		it.exp = exp
+	}
+	return "TOUCHED"
+}
+
+// convert the exptime to expire time.
+// 0 is never expired, negative is expired immediately, larger than 30 days is a unix timestamp.
+func expireAt(exptime int64) (exp time.Time, expired bool) {
+	switch {
+	case exptime == 0:
+		return time.Time{}, false
+	case exptime < 0:
+		return time.Time{}, true
+	case exptime > maxRelativeExpire:
+		exp = time.Unix(exptime, 0)
+		return exp, !time.Now().Before(exp)
+	default:
+		return time.Now().Add(time.Duration(exptime) * time.Second), false
+	}
+}
+
+func isNoReply(args []string) bool {
+	return len(args) > 1 && args[len(args)-1] == "noreply"
+}
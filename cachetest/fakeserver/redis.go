@@ -0,0 +1,966 @@
+package fakeserver
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/cache/internal/rscript"
+)
+
+// Redis a fake redis server, speak the RESP2 protocol.
+//
+// Supported commands:
+//
+//	PING ECHO AUTH SELECT CLIENT QUIT
+//	GET SET SETNX SETEX PSETEX MGET MSET DEL EXISTS
+//	INCR DECR INCRBY DECRBY INCRBYFLOAT
+//	TTL PTTL EXPIRE PEXPIRE PERSIST
+//	DBSIZE FLUSHDB FLUSHALL SCAN
+//	MULTI EXEC DISCARD
+//	SUBSCRIBE UNSUBSCRIBE PUBLISH
+//	EVAL EVALSHA SCRIPT(LOAD, EXISTS) - only the scripts used by the drivers.
+type Redis struct {
+	server
+	mu  sync.Mutex
+	dbs map[int]map[string]*redisItem
+	// channel subscribers
+	subs map[string]map[*redisConn]struct{}
+}
+
+type redisItem struct {
+	val []byte
+	// expire time, zero is never expired
+	exp time.Time
+}
+
+// the state of a client connection
+type redisConn struct {
+	db    int
+	multi bool
+	queue [][]string
+	// subscribed channels
+	channels map[string]struct{}
+	// lock for write, the messages will be pushed by other connections
+	wmu sync.Mutex
+	wr  *bufio.Writer
+}
+
+// write the reply to the connection
+func (c *redisConn) write(reply any) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	writeReply(c.wr, reply)
+	return c.wr.Flush()
+}
+
+type (
+	// status reply. eg: +OK
+	redisStatus string
+	// error reply. eg: -ERR message
+	redisError string
+	// multi replies for one command. eg: SUBSCRIBE with multi channels
+	redisReplies []any
+)
+
+const (
+	replyOK     = redisStatus("OK")
+	replyQueued = redisStatus("QUEUED")
+	errSyntax   = redisError("ERR syntax error")
+	errNotInt   = redisError("ERR value is not an integer or out of range")
+	errNotFloat = redisError("ERR value is not a valid float")
+	errNoScript = redisError("NOSCRIPT No matching script. Please use EVAL.")
+)
+
+// the null bulk reply
+var nullBulk []byte
+
+// redis command handler. the args[0] is the command name.
+type redisHandler func(s *Redis, c *redisConn, args []string) any
+
+var redisCommands map[string]redisHandler
+
+// the scripts supported by EVAL and EVALSHA. key is the sha1 of the script.
+var redisScripts = map[string]func(s *Redis, c *redisConn, keys, args []string) any{
+	scriptSHA(rscript.CompareAndSwap):   scriptCompareAndSwap,
+	scriptSHA(rscript.CompareAndDelete): scriptCompareAndDelete,
+}
+
+func init() {
+	// init here for avoid initialization cycle: cmdExec -> redisCommands
+	redisCommands = map[string]redisHandler{
+		"ping":        cmdPing,
+		"echo":        cmdEcho,
+		"auth":        cmdOK,
+		"client":      cmdOK,
+		"quit":        cmdOK,
+		"select":      cmdSelect,
+		"get":         cmdGet,
+		"set":         cmdSet,
+		"setnx":       cmdSetNX,
+		"setex":       cmdSetEX,
+		"psetex":      cmdSetEX,
+		"mget":        cmdMGet,
+		"mset":        cmdMSet,
+		"del":         cmdDel,
+		"exists":      cmdExists,
+		"incr":        cmdIncrBy,
+		"decr":        cmdIncrBy,
+		"incrby":      cmdIncrBy,
+		"decrby":      cmdIncrBy,
+		"incrbyfloat": cmdIncrByFloat,
+		"ttl":         cmdTTL,
+		"pttl":        cmdTTL,
+		"expire":      cmdExpire,
+		"pexpire":     cmdExpire,
+		"persist":     cmdPersist,
+		"dbsize":      cmdDBSize,
+		"flushdb":     cmdFlushDB,
+		"flushall":    cmdFlushAll,
+		"scan":        cmdScan,
+		"multi":       cmdMulti,
+		"exec":        cmdExec,
+		"discard":     cmdDiscard,
+		"eval":        cmdEval,
+		"evalsha":     cmdEval,
+		"script":      cmdScript,
+		"subscribe":   cmdSubscribe,
+		"unsubscribe": cmdUnsubscribe,
+		"publish":     cmdPublish,
+	}
+}
+
+// the number of arguments for the commands, negative means at least -n.
+var redisArity = map[string]int{
+	"ping": -1, "echo": 2, "auth": -2, "client": -2, "quit": 1, "select": 2,
+	"get": 2, "set": -3, "setnx": 3, "setex": 4, "psetex": 4, "mget": -2, "mset": -3,
+	"del": -2, "exists": -2, "incr": 2, "decr": 2, "incrby": 3, "decrby": 3, "incrbyfloat": 3,
+	"ttl": 2, "pttl": 2, "expire": 3, "pexpire": 3, "persist": 2,
+	"dbsize": 1, "flushdb": -1, "flushall": -1, "scan": -2,
+	"multi": 1, "exec": 1, "discard": 1, "eval": -3, "evalsha": -3, "script": -2,
+	"subscribe": -2, "unsubscribe": -1, "publish": 3,
+}
+
+// NewRedis create and start a fake redis server on a random local port.
+func NewRedis() (*Redis, error) {
+	s := &Redis{
+		dbs:  make(map[int]map[string]*redisItem),
+		subs: make(map[string]map[*redisConn]struct{}),
+	}
+	if err := s.start(s.handle); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// FlushAll delete all keys of all databases
+func (s *Redis) FlushAll() {
+	s.mu.Lock()
+	s.dbs = make(map[int]map[string]*redisItem)
+	s.mu.Unlock()
+}
+
+// handle a client connection
+func (s *Redis) handle(rd *bufio.Reader, wr *bufio.Writer) error {
+	c := &redisConn{wr: wr}
+	defer s.unsubscribe(c)
+
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		if err = c.write(s.dispatch(c, args)); err != nil {
+			return err
+		}
+
+		if strings.EqualFold(args[0], "quit") {
+			return nil
+		}
+	}
+}
+
+// dispatch the command to handler
+func (s *Redis) dispatch(c *redisConn, args []string) any {
+	name := strings.ToLower(args[0])
+	fn, ok := redisCommands[name]
+	if !ok {
+		return redisError(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+
+	n := redisArity[name]
+	if (n > 0 && len(args) != n) || (n < 0 && len(args) < -n) {
+		return redisError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+	}
+
+	switch name {
+	case "multi", "exec", "discard":
+	case "subscribe", "unsubscribe", "ping", "quit":
+		if name == "ping" && len(c.channels) > 0 {
+			return []any{[]byte("pong"), []byte(strings.Join(args[1:], ""))}
+		}
+	default:
+		if len(c.channels) > 0 {
+			return redisError(fmt.Sprintf("ERR Can't execute '%s': only SUBSCRIBE / UNSUBSCRIBE / PING / QUIT are allowed in this context", name))
+		}
+		if c.multi {
+			c.queue = append(c.queue, args)
+			return replyQueued
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s, c, args)
+}
+
+// get the database of the connection
+func (s *Redis) db(c *redisConn) map[string]*redisItem {
+	db, ok := s.dbs[c.db]
+	if !ok {
+		db = make(map[string]*redisItem)
+		s.dbs[c.db] = db
+	}
+	return db
+}
+
+// lookup the key, the expired key will be deleted.
+func (s *Redis) lookup(c *redisConn, key string) *redisItem {
+	db := s.db(c)
+	it, ok := db[key]
+	if !ok {
+		return nil
+	}
+
+	if !it.exp.IsZero() && !time.Now().Before(it.exp) {
+		delete(db, key)
+		return nil
+	}
+	return it
+}
+
+/*************************************************************
+ * connection commands
+ *************************************************************/
+
+func cmdOK(*Redis, *redisConn, []string) any { return replyOK }
+
+func cmdPing(_ *Redis, _ *redisConn, args []string) any {
+	if len(args) > 1 {
+		return []byte(args[1])
+	}
+	return redisStatus("PONG")
+}
+
+func cmdEcho(_ *Redis, _ *redisConn, args []string) any {
+	return []byte(args[1])
+}
+
+func cmdSelect(_ *Redis, c *redisConn, args []string) any {
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 0 {
+		return redisError("ERR DB index is out of range")
+	}
+
+	c.db = n
+	return replyOK
+}
+
+/*************************************************************
+ * string commands
+ *************************************************************/
+
+func cmdGet(s *Redis, c *redisConn, args []string) any {
+	if it := s.lookup(c, args[1]); it != nil {
+		return it.val
+	}
+	return nullBulk
+}
+
+// SET key value [NX | XX] [GET] [EX seconds | PX milliseconds | KEEPTTL]
+func cmdSet(s *Redis, c *redisConn, args []string) any {
+	var nx, xx, get, keepTTL bool
+	var ttl time.Duration
+
+	for i := 3; i < len(args); i++ {
+		switch opt := strings.ToLower(args[i]); opt {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "get":
+			get = true
+		case "keepttl":
+			keepTTL = true
+		case "ex", "px":
+			if i++; i >= len(args) || ttl != 0 {
+				return errSyntax
+			}
+
+			n, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil {
+				return errNotInt
+			}
+			if n <= 0 {
+				return redisError("ERR invalid expire time in 'set' command")
+			}
+			ttl = toDuration(opt == "ex", n)
+		default:
+			return errSyntax
+		}
+	}
+
+	if (nx && xx) || (keepTTL && ttl != 0) {
+		return errSyntax
+	}
+
+	key := args[1]
+	old := s.lookup(c, key)
+
+	var reply any = replyOK
+	if get {
+		reply = nullBulk
+		if old != nil {
+			reply = old.val
+		}
+	}
+
+	if (nx && old != nil) || (xx && old == nil) {
+		if get {
+			return reply
+		}
+		return nullBulk
+	}
+
+	it := &redisItem{val: []byte(args[2])}
+	if ttl > 0 {
+		it.exp = time.Now().Add(ttl)
+	} else if keepTTL && old != nil {
+		it.exp = old.exp
+	}
+
+	s.db(c)[key] = it
+	return reply
+}
+
+func cmdSetNX(s *Redis, c *redisConn, args []string) any {
+	if s.lookup(c, args[1]) != nil {
+		return int64(0)
+	}
+
+	s.db(c)[args[1]] = &redisItem{val: []byte(args[2])}
+	return int64(1)
+}
+
+// SETEX key seconds value, PSETEX key milliseconds value
+func cmdSetEX(s *Redis, c *redisConn, args []string) any {
+	n, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return errNotInt
+	}
+	if n <= 0 {
+		return redisError(fmt.Sprintf("ERR invalid expire time in '%s' command", strings.ToLower(args[0])))
+	}
+
+	ttl := toDuration(strings.EqualFold(args[0], "setex"), n)
+	s.db(c)[args[1]] = &redisItem{val: []byte(args[3]), exp: time.Now().Add(ttl)}
+	return replyOK
+}
+
+func cmdMGet(s *Redis, c *redisConn, args []string) any {
+	list := make([]any, 0, len(args)-1)
+	for _, key := range args[1:] {
+		list = append(list, cmdGet(s, c, []string{"get", key}))
+	}
+	return list
+}
+
+func cmdMSet(s *Redis, c *redisConn, args []string) any {
+	if len(args)%2 != 1 {
+		return redisError("ERR wrong number of arguments for 'mset' command")
+	}
+
+	db := s.db(c)
+	for i := 1; i < len(args); i += 2 {
+		db[args[i]] = &redisItem{val: []byte(args[i+1])}
+	}
+	return replyOK
+}
+
+func cmdDel(s *Redis, c *redisConn, args []string) any {
+	var n int64
+	for _, key := range args[1:] {
+		if s.lookup(c, key) != nil {
+			delete(s.db(c), key)
+			n++
+		}
+	}
+	return n
+}
+
+func cmdExists(s *Redis, c *redisConn, args []string) any {
+	var n int64
+	for _, key := range args[1:] {
+		if s.lookup(c, key) != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// INCR, DECR, INCRBY, DECRBY
+func cmdIncrBy(s *Redis, c *redisConn, args []string) any {
+	name := strings.ToLower(args[0])
+	delta := int64(1)
+	if len(args) > 2 {
+		n, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return errNotInt
+		}
+		delta = n
+	}
+	if strings.HasPrefix(name, "decr") {
+		delta = -delta
+	}
+
+	var num int64
+	it := s.lookup(c, args[1])
+	if it != nil {
+		n, err := strconv.ParseInt(string(it.val), 10, 64)
+		if err != nil {
+			return errNotInt
+		}
+		num = n
+	} else {
+		it = &redisItem{}
+		s.db(c)[args[1]] = it
+	}
+
+	num += delta
+	it.val = strconv.AppendInt(nil, num, 10)
+	return num
+}
+
+func cmdIncrByFloat(s *Redis, c *redisConn, args []string) any {
+	delta, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return errNotFloat
+	}
+
+	var num float64
+	it := s.lookup(c, args[1])
+	if it != nil {
+		if num, err = strconv.ParseFloat(string(it.val), 64); err != nil {
+			return errNotFloat
+		}
+	} else {
+		it = &redisItem{}
+		s.db(c)[args[1]] = it
+	}
+
+	num += delta
+	if math.IsNaN(num) || math.IsInf(num, 0) {
+		return redisError("ERR increment would produce NaN or Infinity")
+	}
+
+	it.val = strconv.AppendFloat(nil, num, 'f', -1, 64)
+	return it.val
+}
+
+/*************************************************************
+ * expiration commands
+ *************************************************************/
+
+// TTL key, PTTL key
+func cmdTTL(s *Redis, c *redisConn, args []string) any {
+	it := s.lookup(c, args[1])
+	if it == nil {
+		return int64(-2)
+	}
+	if it.exp.IsZero() {
+		return int64(-1)
+	}
+
+	left := time.Until(it.exp)
+	if strings.EqualFold(args[0], "ttl") {
+		return int64((left + 500*time.Millisecond) / time.Second)
+	}
+	return left.Milliseconds()
+}
+
+// EXPIRE key seconds, PEXPIRE key milliseconds
+func cmdExpire(s *Redis, c *redisConn, args []string) any {
+	n, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return errNotInt
+	}
+
+	it := s.lookup(c, args[1])
+	if it == nil {
+		return int64(0)
+	}
+
+	if n <= 0 {
+		delete(s.db(c), args[1])
+	} else {
+		it.exp = time.Now().Add(toDuration(strings.EqualFold(args[0], "expire"), n))
+	}
+	return int64(1)
+}
+
+func cmdPersist(s *Redis, c *redisConn, args []string) any {
+	it := s.lookup(c, args[1])
+	if it == nil || it.exp.IsZero() {
+		return int64(0)
+	}
+
+	it.exp = time.Time{}
+	return int64(1)
+}
+
+/*************************************************************
+ * keyspace commands
+ *************************************************************/
+
+func cmdDBSize(s *Redis, c *redisConn, _ []string) any {
+	var n int64
+	for key := range s.db(c) {
+		if s.lookup(c, key) != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func cmdFlushDB(s *Redis, c *redisConn, _ []string) any {
+	delete(s.dbs, c.db)
+	return replyOK
+}
+
+func cmdFlushAll(s *Redis, _ *redisConn, _ []string) any {
+	s.dbs = make(map[int]map[string]*redisItem)
+	return replyOK
+}
+
+// SCAN cursor [MATCH pattern] [COUNT count]
+//
+// NOTICE: it always returns all the matched keys in one call, and the next cursor is 0.
+func cmdScan(s *Redis, c *redisConn, args []string) any {
+	if _, err := strconv.ParseUint(args[1], 10, 64); err != nil {
+		return redisError("ERR invalid cursor")
+	}
+
+	pattern := "*"
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return errSyntax
+		}
+
+		switch strings.ToLower(args[i]) {
+		case "match":
+			pattern = args[i+1]
+		case "count":
+			if _, err := strconv.Atoi(args[i+1]); err != nil {
+				return errNotInt
+			}
+		default:
+			return errSyntax
+		}
+	}
+
+	keys := make([]string, 0)
+	for key := range s.db(c) {
+		if s.lookup(c, key) != nil && globMatch(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	list := make([]any, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, []byte(key))
+	}
+	return []any{[]byte("0"), list}
+}
+
+/*************************************************************
+ * transaction commands
+ *************************************************************/
+
+func cmdMulti(_ *Redis, c *redisConn, _ []string) any {
+	if c.multi {
+		return redisError("ERR MULTI calls can not be nested")
+	}
+
+	c.multi = true
+	c.queue = nil
+	return replyOK
+}
+
+func cmdExec(s *Redis, c *redisConn, _ []string) any {
+	if !c.multi {
+		return redisError("ERR EXEC without MULTI")
+	}
+
+	queue := c.queue
+	c.multi, c.queue = false, nil
+
+	replies := make([]any, 0, len(queue))
+	for _, args := range queue {
+		replies = append(replies, redisCommands[strings.ToLower(args[0])](s, c, args))
+	}
+	return replies
+}
+
+func cmdDiscard(_ *Redis, c *redisConn, _ []string) any {
+	if !c.multi {
+		return redisError("ERR DISCARD without MULTI")
+	}
+
+	c.multi, c.queue = false, nil
+	return replyOK
+}
+
+/*************************************************************
+ * pub/sub commands
+ *************************************************************/
+
+// SUBSCRIBE channel [channel ...]
+func cmdSubscribe(s *Redis, c *redisConn, args []string) any {
+	if c.channels == nil {
+		c.channels = make(map[string]struct{})
+	}
+
+	replies := make(redisReplies, 0, len(args)-1)
+	for _, ch := range args[1:] {
+		c.channels[ch] = struct{}{}
+		if s.subs[ch] == nil {
+			s.subs[ch] = make(map[*redisConn]struct{})
+		}
+		s.subs[ch][c] = struct{}{}
+
+		replies = append(replies, []any{[]byte("subscribe"), []byte(ch), int64(len(c.channels))})
+	}
+	return replies
+}
+
+// UNSUBSCRIBE [channel [channel ...]]
+func cmdUnsubscribe(s *Redis, c *redisConn, args []string) any {
+	channels := args[1:]
+	if len(channels) == 0 {
+		for ch := range c.channels {
+			channels = append(channels, ch)
+		}
+		sort.Strings(channels)
+	}
+
+	if len(channels) == 0 {
+		return []any{[]byte("unsubscribe"), nullBulk, int64(0)}
+	}
+
+	replies := make(redisReplies, 0, len(channels))
+	for _, ch := range channels {
+		delete(c.channels, ch)
+		delete(s.subs[ch], c)
+
+		replies = append(replies, []any{[]byte("unsubscribe"), []byte(ch), int64(len(c.channels))})
+	}
+	return replies
+}
+
+// PUBLISH channel message
+func cmdPublish(s *Redis, _ *redisConn, args []string) any {
+	msg := []any{[]byte("message"), []byte(args[1]), []byte(args[2])}
+	for sub := range s.subs[args[1]] {
+		_ = sub.write(msg)
+	}
+	return int64(len(s.subs[args[1]]))
+}
+
+// remove all subscriptions of the connection
+func (s *Redis) unsubscribe(c *redisConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range c.channels {
+		delete(s.subs[ch], c)
+	}
+	c.channels = nil
+}
+
+/*************************************************************
+ * scripting commands
+ *************************************************************/
+
+// EVAL script numkeys [key ...] [arg ...], EVALSHA sha1 numkeys [key ...] [arg ...]
+func cmdEval(s *Redis, c *redisConn, args []string) any {
+	sha := args[1]
+	if strings.EqualFold(args[0], "eval") {
+		sha = scriptSHA(args[1])
+	}
+
+	fn, ok := redisScripts[strings.ToLower(sha)]
+	if !ok {
+		if strings.EqualFold(args[0], "eval") {
+			return redisError("ERR unsupported script")
+		}
+		return errNoScript
+	}
+
+	n, err := strconv.Atoi(args[2])
+	if err != nil || n < 0 || n > len(args)-3 {
+		return redisError("ERR Number of keys can't be greater than number of args")
+	}
+
+	return fn(s, c, args[3:3+n], args[3+n:])
+}
+
+// SCRIPT LOAD script, SCRIPT EXISTS sha1 [sha1 ...]
+func cmdScript(_ *Redis, _ *redisConn, args []string) any {
+	switch strings.ToLower(args[1]) {
+	case "load":
+		if len(args) != 3 {
+			return errSyntax
+		}
+
+		sha := scriptSHA(args[2])
+		if _, ok := redisScripts[sha]; !ok {
+			return redisError("ERR unsupported script")
+		}
+		return []byte(sha)
+	case "exists":
+		list := make([]any, 0, len(args)-2)
+		for _, sha := range args[2:] {
+			_, ok := redisScripts[strings.ToLower(sha)]
+			list = append(list, boolInt(ok))
+		}
+		return list
+	case "flush":
+		return replyOK
+	}
+	return errSyntax
+}
+
+// native implement of the rscript.CompareAndSwap
+func scriptCompareAndSwap(s *Redis, c *redisConn, keys, args []string) any {
+	if len(keys) != 1 || len(args) != 3 {
+		return redisError("ERR invalid arguments for script")
+	}
+
+	it := s.lookup(c, keys[0])
+	if it == nil || string(it.val) != args[0] {
+		return int64(0)
+	}
+
+	setArgs := []string{"set", keys[0], args[1]}
+	if ms, _ := strconv.ParseFloat(args[2], 64); ms > 0 {
+		setArgs = append(setArgs, "px", args[2])
+	}
+
+	if err, ok := cmdSet(s, c, setArgs).(redisError); ok {
+		return err
+	}
+	return int64(1)
+}
+
+// native implement of the rscript.CompareAndDelete
+func scriptCompareAndDelete(s *Redis, c *redisConn, keys, args []string) any {
+	if len(keys) != 1 || len(args) != 1 {
+		return redisError("ERR invalid arguments for script")
+	}
+
+	it := s.lookup(c, keys[0])
+	if it == nil || string(it.val) != args[0] {
+		return int64(0)
+	}
+	return cmdDel(s, c, []string{"del", keys[0]})
+}
+
+/*************************************************************
+ * protocol helpers
+ *************************************************************/
+
+// read a command. support the RESP array of bulk strings and the inline command.
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := readLine(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, errors.New("invalid multibulk length")
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if line, err = readLine(rd); err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, errors.New("expected '$', got: " + line)
+		}
+
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, errors.New("invalid bulk length")
+		}
+
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+// read a line and trim the "\r\n"
+func readLine(rd *bufio.Reader) (string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// write the reply in RESP2 format
+func writeReply(wr *bufio.Writer, reply any) {
+	switch v := reply.(type) {
+	case redisStatus:
+		_, _ = fmt.Fprintf(wr, "+%s\r\n", v)
+	case redisError:
+		_, _ = fmt.Fprintf(wr, "-%s\r\n", v)
+	case redisReplies:
+		for _, item := range v {
+			writeReply(wr, item)
+		}
+	case int64:
+		_, _ = fmt.Fprintf(wr, ":%d\r\n", v)
+	case []byte:
+		if v == nil {
+			_, _ = wr.WriteString("$-1\r\n")
+			return
+		}
+		_, _ = fmt.Fprintf(wr, "$%d\r\n", len(v))
+		_, _ = wr.Write(v)
+		_, _ = wr.WriteString("\r\n")
+	case []any:
+		_, _ = fmt.Fprintf(wr, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(wr, item)
+		}
+	default:
+		panic(fmt.Sprintf("fakeserver: invalid reply type %T", reply))
+	}
+}
+
+// globMatch match the key by redis glob-style pattern. supports: * ? [abc] [^a] [a-z] and \x
+func globMatch(pattern, str string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(str); i++ {
+				if globMatch(pattern[1:], str[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(str) == 0 {
+				return false
+			}
+		case '[':
+			if len(str) == 0 {
+				return false
+			}
+
+			end := strings.IndexByte(pattern[1:], ']') + 1
+			if end <= 0 { // no close bracket, as literal
+				if str[0] != '[' {
+					return false
+				}
+				break
+			}
+
+			class, not := pattern[1:end], false
+			if len(class) > 0 && class[0] == '^' {
+				class, not = class[1:], true
+			}
+			if matchClass(class, str[0]) == not {
+				return false
+			}
+			pattern = pattern[end:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(str) == 0 || pattern[0] != str[0] {
+				return false
+			}
+		}
+
+		pattern, str = pattern[1:], str[1:]
+	}
+	return len(str) == 0
+}
+
+// check the char is in the class. eg: "abc", "a-z"
+func matchClass(class string, ch byte) bool {
+	for i := 0; i < len(class); i++ {
+		if i+2 < len(class) && class[i+1] == '-' {
+			if class[i] <= ch && ch <= class[i+2] {
+				return true
+			}
+			i += 2
+		} else if class[i] == ch {
+			return true
+		}
+	}
+	return false
+}
+
+func scriptSHA(src string) string {
+	sum := sha1.Sum([]byte(src))
+	return hex.EncodeToString(sum[:])
+}
+
+func toDuration(seconds bool, n int64) time.Duration {
+	if seconds {
+		return time.Duration(n) * time.Second
+	}
+	return time.Duration(n) * time.Millisecond
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+// Package fakeserver provide lightweight in-process redis and memcached servers for the driver tests.
+//
+// The servers listen on a random local port, and only implement the subset of
+// commands used by the drivers of this module.
+//
+// Usage:
+//
+//	srv, err := fakeserver.NewRedis()
+//	if err != nil {
+//		panic(err)
+//	}
+//	defer srv.Close()
+//
+//	c := goredis.Connect(srv.Addr(), "", 0)
+package fakeserver
+
+import (
+	"bufio"
+	"net"
+	"sync"
+)
+
+// server the common tcp server
+type server struct {
+	ln net.Listener
+	wg sync.WaitGroup
+	// active connections
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	// handle the connection
+	handler func(rd *bufio.Reader, wr *bufio.Writer) error
+}
+
+// listen on a random local port and start serve
+func (s *server) start(handler func(rd *bufio.Reader, wr *bufio.Writer) error) error {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	s.ln = ln
+	s.handler = handler
+	s.conns = make(map[net.Conn]struct{})
+
+	s.wg.Add(1)
+	go s.serve()
+	return nil
+}
+
+// Addr get the server listen address. eg: "127.0.0.1:34567"
+func (s *server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close the server and all active connections
+func (s *server) Close() error {
+	err := s.ln.Close()
+
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// CloseClients close all active client connections, it can be used for test the reconnection.
+func (s *server) CloseClients() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
+func (s *server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+
+		_ = conn.Close()
+		s.wg.Done()
+	}()
+
+	_ = s.handler(bufio.NewReader(conn), bufio.NewWriter(conn))
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+func init() {
+	// for the FileCache and the common values with GobCodec
+	gob.Register(&Item{})
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+}
+
+// Codec interface definition. for encode and decode the cache values of a driver.
+//
+// The drivers decode the values to *any on Get(), so the codec should support it.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, ptr any) error
+}
+
+// FuncCodec create a codec by the marshal and unmarshal funcs. eg: FuncCodec(yaml.Marshal, yaml.Unmarshal)
+func FuncCodec(marshal MarshalFunc, unmarshal UnmarshalFunc) Codec {
+	return &funcCodec{marshal: marshal, unmarshal: unmarshal}
+}
+
+type funcCodec struct {
+	marshal   MarshalFunc
+	unmarshal UnmarshalFunc
+}
+
+func (c *funcCodec) Marshal(v any) ([]byte, error) {
+	return c.marshal(v)
+}
+
+func (c *funcCodec) Unmarshal(data []byte, ptr any) error {
+	return c.unmarshal(data, ptr)
+}
+
+// JSONCodec the codec by encoding/json
+type JSONCodec struct{}
+
+// Marshal value to JSON
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal JSON data to ptr
+func (JSONCodec) Unmarshal(data []byte, ptr any) error {
+	return json.Unmarshal(data, ptr)
+}
+
+// GobCodec the codec by encoding/gob, the value is encoded as interface,
+// so the custom types should be registered by gob.Register().
+// it can unmarshal a *T value to the ptr of T.
+type GobCodec struct{}
+
+// Marshal value by GobEncode
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	return GobEncode(&v)
+}
+
+// Unmarshal data by GobDecode. the ptr can be *any or pointer of the encoded type.
+func (GobCodec) Unmarshal(data []byte, ptr any) error {
+	if p, ok := ptr.(*any); ok {
+		return GobDecode(data, p)
+	}
+
+	var val any
+	if err := GobDecode(data, &val); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cache: gob codec unmarshal to non-pointer %T", ptr)
+	}
+
+	vv := reflect.ValueOf(val)
+	if !vv.IsValid() {
+		rv.Elem().SetZero()
+		return nil
+	}
+	typ := rv.Elem().Type()
+	if vv.Kind() == reflect.Pointer && !vv.Type().AssignableTo(typ) && !vv.IsNil() {
+		vv = vv.Elem()
+	}
+	if !vv.Type().AssignableTo(typ) {
+		return fmt.Errorf("cache: gob codec cannot unmarshal %T to %T", val, ptr)
+	}
+	rv.Elem().Set(vv)
+	return nil
+}
+
+// RawCodec the passthrough codec for []byte and string values.
+// the value is decoded as []byte for *any.
+type RawCodec struct{}
+
+// Marshal the []byte or string value
+func (RawCodec) Marshal(v any) ([]byte, error) {
+	switch typVal := v.(type) {
+	case []byte:
+		return typVal, nil
+	case string:
+		return []byte(typVal), nil
+	}
+	return nil, fmt.Errorf("cache: raw codec not support marshal %T", v)
+}
+
+// Unmarshal data to *[]byte, *string or *any
+func (RawCodec) Unmarshal(data []byte, ptr any) error {
+	switch p := ptr.(type) {
+	case *[]byte:
+		*p = append([]byte(nil), data...)
+	case *string:
+		*p = string(data)
+	case *any:
+		*p = append([]byte(nil), data...)
+	default:
+		return fmt.Errorf("cache: raw codec not support unmarshal to %T", ptr)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Package codec provide the codecs with third-party dependencies for the drivers.
+//
+// Usage:
+//
+//	c := goredis.Connect("127.0.0.1:6379", "", 0)
+//	c.WithOptions(cache.WithCodec(codec.MsgPack{}))
+package codec
+
+import (
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// MsgPack the codec by msgpack
+type MsgPack struct{}
+
+// Marshal value to msgpack
+func (MsgPack) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal msgpack data to ptr
+func (MsgPack) Unmarshal(data []byte, ptr any) error {
+	return msgpack.Unmarshal(data, ptr)
+}
+
+// Proto the codec for proto.Message values.
+//
+// The message is stored as anypb.Any with the type url, so it can be unmarshal to *any
+// by the registered message types. eg: the value of Get()
+type Proto struct{}
+
+// Marshal the proto.Message value
+func (Proto) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("codec: proto codec not support marshal %T", v)
+	}
+
+	wrapped, err := anypb.New(msg)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(wrapped)
+}
+
+// Unmarshal data to proto.Message or *any
+func (Proto) Unmarshal(data []byte, ptr any) error {
+	wrapped := &anypb.Any{}
+	if err := proto.Unmarshal(data, wrapped); err != nil {
+		return err
+	}
+
+	switch p := ptr.(type) {
+	case proto.Message:
+		return wrapped.UnmarshalTo(p)
+	case *any:
+		msg, err := wrapped.UnmarshalNew()
+		if err != nil {
+			return err
+		}
+		*p = msg
+		return nil
+	}
+	return fmt.Errorf("codec: proto codec not support unmarshal to %T", ptr)
+}
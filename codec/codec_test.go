@@ -0,0 +1,71 @@
+package codec_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/codec"
+	"github.com/gookit/goutil/testutil/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func Example() {
+	c := cache.NewFileCache("../testdata")
+	c.WithOptions(cache.WithCodec(codec.MsgPack{}))
+
+	_ = c.Set("name", "inhere", 0)
+
+	c2 := cache.NewFileCache("../testdata")
+	c2.WithOptions(cache.WithCodec(codec.MsgPack{}))
+	fmt.Println(c2.Get("name"))
+
+	// Output:
+	// inhere
+}
+
+type user struct {
+	Name string `msgpack:"name"`
+	Age  int    `msgpack:"age"`
+}
+
+func TestMsgPack(t *testing.T) {
+	is := assert.New(t)
+	var c cache.Codec = codec.MsgPack{}
+
+	bs, err := c.Marshal(user{Name: "inhere", Age: 20})
+	is.NoErr(err)
+	var u user
+	is.NoErr(c.Unmarshal(bs, &u))
+	is.Eq(user{Name: "inhere", Age: 20}, u)
+
+	var val any
+	is.NoErr(c.Unmarshal(bs, &val))
+	is.Eq("inhere", val.(map[string]any)["name"])
+	is.Err(c.Unmarshal([]byte{0xc1}, &val))
+}
+
+func TestProto(t *testing.T) {
+	is := assert.New(t)
+	var c cache.Codec = codec.Proto{}
+
+	bs, err := c.Marshal(wrapperspb.String("inhere"))
+	is.NoErr(err)
+	msg := &wrapperspb.StringValue{}
+	is.NoErr(c.Unmarshal(bs, msg))
+	is.Eq("inhere", msg.GetValue())
+
+	// unmarshal to *any by the registered types
+	var val any
+	is.NoErr(c.Unmarshal(bs, &val))
+	is.True(proto.Equal(wrapperspb.String("inhere"), val.(proto.Message)))
+
+	// errors
+	_, err = c.Marshal("string")
+	is.ErrMsg(err, "codec: proto codec not support marshal string")
+	is.Err(c.Unmarshal(bs, &wrapperspb.Int64Value{}))
+	var str string
+	is.ErrMsg(c.Unmarshal(bs, &str), "codec: proto codec not support unmarshal to *string")
+	is.Err(c.Unmarshal([]byte("invalid"), &val))
+}
@@ -0,0 +1,133 @@
+package cache_test
+
+import (
+	"encoding/gob"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+type codecUser struct {
+	Name string
+	Age  int
+}
+
+func init() {
+	gob.Register(codecUser{})
+}
+
+func ExampleWithCodec() {
+	c := cache.NewFileCache("./testdata")
+	c.WithOptions(cache.WithCodec(cache.GobCodec{}))
+
+	_ = c.Set("user", codecUser{Name: "inhere", Age: 20}, 0)
+
+	// read from the file by a new instance
+	c2 := cache.NewFileCache("./testdata")
+	c2.WithOptions(cache.WithCodec(cache.GobCodec{}))
+	fmt.Println(c2.Get("user"))
+
+	// Output:
+	// {inhere 20}
+}
+
+func TestFileCache_codec_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		c := cache.NewFileCache(t.TempDir())
+		c.WithOptions(cache.WithCodec(cache.GobCodec{}))
+		return c
+	}, cachetest.WithTTLPrecision(time.Second))
+}
+
+func TestCodecs(t *testing.T) {
+	is := assert.New(t)
+	user := codecUser{Name: "inhere", Age: 20}
+
+	// json
+	bs, err := cache.JSONCodec{}.Marshal(user)
+	is.NoErr(err)
+	is.Eq(`{"Name":"inhere","Age":20}`, string(bs))
+	var ju codecUser
+	is.NoErr(cache.JSONCodec{}.Unmarshal(bs, &ju))
+	is.Eq(user, ju)
+
+	// gob
+	gc := cache.GobCodec{}
+	bs, err = gc.Marshal(user)
+	is.NoErr(err)
+	var val any
+	is.NoErr(gc.Unmarshal(bs, &val))
+	is.Eq(user, val)
+	var gu codecUser
+	is.NoErr(gc.Unmarshal(bs, &gu))
+	is.Eq(user, gu)
+	var up *codecUser
+	is.Err(gc.Unmarshal(bs, up))
+	is.Err(gc.Unmarshal([]byte("invalid"), &gu))
+
+	bs, err = gc.Marshal(&user)
+	is.NoErr(err)
+	gu = codecUser{}
+	is.NoErr(gc.Unmarshal(bs, &gu))
+	is.Eq(user, gu)
+	var str string
+	is.ErrMsg(gc.Unmarshal(bs, &str), "cache: gob codec cannot unmarshal cache_test.codecUser to *string")
+
+	// raw
+	rc := cache.RawCodec{}
+	bs, err = rc.Marshal("value")
+	is.NoErr(err)
+	is.NoErr(rc.Unmarshal(bs, &str))
+	is.Eq("value", str)
+	bs, err = rc.Marshal([]byte("bytes"))
+	is.NoErr(err)
+	is.NoErr(rc.Unmarshal(bs, &val))
+	is.Eq([]byte("bytes"), val)
+	var raw []byte
+	is.NoErr(rc.Unmarshal(bs, &raw))
+	is.Eq([]byte("bytes"), raw)
+	_, err = rc.Marshal(12)
+	is.ErrMsg(err, "cache: raw codec not support marshal int")
+	is.Err(rc.Unmarshal(bs, &gu))
+
+	// func
+	fc := cache.FuncCodec(cache.GobEncode, cache.GobDecode)
+	bs, err = fc.Marshal(user)
+	is.NoErr(err)
+	gu = codecUser{}
+	is.NoErr(fc.Unmarshal(bs, &gu))
+	is.Eq(user, gu)
+}
+
+func TestWithCodec(t *testing.T) {
+	is := assert.New(t)
+	dir := t.TempDir()
+
+	// the codec is per driver, the others use the global Marshal and Unmarshal
+	gc := cache.NewFileCache(dir)
+	gc.WithOptions(cache.WithCodec(cache.GobCodec{}))
+	is.Eq(cache.GobCodec{}, gc.Codec())
+	is.NoErr(gc.Set("num", 12, 0))
+
+	jc := cache.NewFileCache(dir)
+	is.Nil(jc.Codec())
+	is.NoErr(jc.Set("json-num", 12, 0))
+
+	gc2 := cache.NewFileCache(dir)
+	gc2.WithOptions(cache.WithCodec(cache.GobCodec{}))
+	is.Eq(12, gc2.Get("num"))
+	is.Eq(float64(12), cache.NewFileCache(dir).Get("json-num"))
+
+	// the gob codec can not decode the item of json
+	is.Nil(gc2.Get("json-num"))
+	is.Err(gc2.LastErr("json-num"))
+
+	// the file cache can not save the item by raw codec
+	rc := cache.NewFileCache(dir)
+	rc.WithOptions(cache.WithCodec(cache.RawCodec{}))
+	is.Err(rc.Set("raw", "value", 0))
+}
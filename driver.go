@@ -1,8 +1,16 @@
 package cache
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gookit/gsr"
 )
@@ -15,7 +23,9 @@ type (
 	UnmarshalFunc func(data []byte, v any) error
 )
 
-// data (Un)marshal func
+// data (Un)marshal func, they are used by the drivers without codec.
+//
+// Deprecated: they are shared by all drivers, please use WithCodec() for the driver.
 var (
 	Marshal   MarshalFunc   = json.Marshal
 	Unmarshal UnmarshalFunc = json.Unmarshal
@@ -29,7 +39,16 @@ type Option struct {
 	Debug bool
 	// Encode (Un)marshal save data
 	Encode bool
+	// Codec for (un)marshal the data. default use the global Marshal and Unmarshal func
+	Codec Codec
+	// Logger the printf style logger. the Slog is preferred if both are set.
 	Logger gsr.Printer
+	// Slog the structured logger. the logs have attrs: driver, op, key, duration and error.
+	Slog *slog.Logger
+	// ErrorLevel the level for log the operation errors. default is slog.LevelError
+	ErrorLevel slog.Leveler
+	// DebugLevel the level for log the debug messages. eg: the operation elapsed time. default is slog.LevelDebug
+	DebugLevel slog.Leveler
 	// Prefix key prefix
 	Prefix string
 }
@@ -41,8 +60,13 @@ type Option struct {
 // BaseDriver struct
 type BaseDriver struct {
 	opt Option
-	// last error
-	lastErr error
+	// context for operate
+	ctx context.Context
+	// last error, and the number of the saved errors
+	lastErr  error
+	errCount uint64
+	// driver name for logs
+	name string
 }
 
 // WithDebug add option: debug
@@ -59,6 +83,16 @@ func WithEncode(encode bool) func(opt *Option) {
 	}
 }
 
+// WithCodec add option: the codec for (un)marshal the data, it also enables the Encode if codec is not nil.
+func WithCodec(codec Codec) func(opt *Option) {
+	return func(opt *Option) {
+		opt.Codec = codec
+		if codec != nil {
+			opt.Encode = true
+		}
+	}
+}
+
 // WithPrefix add option: prefix
 func WithPrefix(prefix string) func(opt *Option) {
 	return func(opt *Option) {
@@ -66,6 +100,21 @@ func WithPrefix(prefix string) func(opt *Option) {
 	}
 }
 
+// WithSlog add option: the structured logger
+func WithSlog(logger *slog.Logger) func(opt *Option) {
+	return func(opt *Option) {
+		opt.Slog = logger
+	}
+}
+
+// WithLogLevels add option: the levels for log the errors and debug messages. nil will use the default.
+func WithLogLevels(errLevel, debugLevel slog.Leveler) func(opt *Option) {
+	return func(opt *Option) {
+		opt.ErrorLevel = errLevel
+		opt.DebugLevel = debugLevel
+	}
+}
+
 // WithOptions for driver
 func (l *BaseDriver) WithOptions(optFns ...func(option *Option)) {
 	for _, optFn := range optFns {
@@ -73,25 +122,54 @@ func (l *BaseDriver) WithOptions(optFns ...func(option *Option)) {
 	}
 }
 
+// Codec get the codec of driver, returns nil if not set.
+func (l *BaseDriver) Codec() Codec {
+	return l.opt.Codec
+}
+
 // MustMarshal cache value
 func (l *BaseDriver) MustMarshal(val any) ([]byte, error) {
+	if l.opt.Codec != nil {
+		return l.opt.Codec.Marshal(val)
+	}
 	if Marshal == nil {
 		return nil, errNoMarshal
 	}
 	return Marshal(val)
 }
 
+// EqualValue check the cache value is equals to oldVal. for the CompareAndSwap, CompareAndDelete.
+// will compare the data encoded by the codec of driver if they are not deep equal, like the redis drivers.
+func (l *BaseDriver) EqualValue(val, oldVal any) bool {
+	if reflect.DeepEqual(val, oldVal) {
+		return true
+	}
+
+	bs1, err1 := l.MustMarshal(val)
+	bs2, err2 := l.MustMarshal(oldVal)
+	return err1 == nil && err2 == nil && bytes.Equal(bs1, bs2)
+}
+
 // Marshal cache value
 func (l *BaseDriver) Marshal(val any) (any, error) {
-	if l.opt.Encode && Marshal != nil {
-		return Marshal(val)
+	if !l.opt.Encode {
+		return val, nil
 	}
 
+	if l.opt.Codec != nil {
+		return l.opt.Codec.Marshal(val)
+	}
+	if Marshal != nil {
+		return Marshal(val)
+	}
 	return val, nil
 }
 
 // UnmarshalTo cache value
 func (l *BaseDriver) UnmarshalTo(bts []byte, ptr any) error {
+	if l.opt.Codec != nil {
+		return l.opt.Codec.Unmarshal(bts, ptr)
+	}
 	if Unmarshal == nil {
 		return errNoUnmarshal
 	}
@@ -106,6 +184,10 @@ func (l *BaseDriver) Unmarshal(val []byte, err error) any {
 	}
 
 	var newV any
+	if l.opt.Encode && l.opt.Codec != nil {
+		l.SetLastErr(l.opt.Codec.Unmarshal(val, &newV))
+		return newV
+	}
 	if l.opt.Encode && Unmarshal != nil {
 		err := Unmarshal(val, &newV)
 		l.SetLastErr(err)
@@ -137,26 +219,128 @@ func (l *BaseDriver) BuildKeys(keys []string) []string {
 	return rks
 }
 
+// SetName set the driver name, it is added to the logs.
+func (l *BaseDriver) SetName(name string) {
+	l.name = name
+}
+
+// Name get the driver name
+func (l *BaseDriver) Name() string {
+	return l.name
+}
+
 // Debugf print an debug message
 func (l *BaseDriver) Debugf(format string, v ...any) {
-	if l.opt.Debug && l.opt.Logger != nil {
-		l.opt.Logger.Printf(format, v...)
+	if l.DebugEnabled() {
+		l.Log(l.debugLevel(), fmt.Sprintf(format, v...))
 	}
 }
 
 // Logf print an log message
 func (l *BaseDriver) Logf(format string, v ...any) {
+	l.Log(slog.LevelInfo, fmt.Sprintf(format, v...))
+}
+
+// Log print a structured log message, the driver name will be added as attr "driver".
+//
+// It uses the Slog if set, otherwise the Logger prints it as "msg driver=name key=value ...".
+func (l *BaseDriver) Log(level slog.Level, msg string, attrs ...slog.Attr) {
+	if l.opt.Slog != nil {
+		if l.name != "" {
+			attrs = append([]slog.Attr{slog.String("driver", l.name)}, attrs...)
+		}
+		l.opt.Slog.LogAttrs(l.Context(), level, msg, attrs...)
+		return
+	}
+
 	if l.opt.Logger != nil {
-		l.opt.Logger.Printf(format, v...)
+		var sb strings.Builder
+		sb.WriteString(msg)
+		if l.name != "" {
+			sb.WriteString(" driver=")
+			sb.WriteString(l.name)
+		}
+		for _, attr := range attrs {
+			sb.WriteByte(' ')
+			sb.WriteString(attr.String())
+		}
+		l.opt.Logger.Printf("%s\n", sb.String())
 	}
 }
 
-// SetLastErr save last error
-func (l *BaseDriver) SetLastErr(err error) {
+// HasLogger check the Slog or Logger is set
+func (l *BaseDriver) HasLogger() bool {
+	return l.opt.Slog != nil || l.opt.Logger != nil
+}
+
+// DebugEnabled check the debug messages should be logged.
+// it is true if the Debug option is set, or the Slog is enabled for the DebugLevel.
+func (l *BaseDriver) DebugEnabled() bool {
+	if l.opt.Debug {
+		return l.HasLogger()
+	}
+	return l.opt.Slog != nil && l.opt.Slog.Enabled(l.Context(), l.debugLevel())
+}
+
+// DebugOp log the elapsed time and error of an operation if the debug is enabled.
+//
+// Usage:
+//
+//	st := time.Now()
+//	err := doSomething()
+//	c.DebugOp("Get", key, st, err)
+func (l *BaseDriver) DebugOp(op, key string, start time.Time, err error) {
+	if !l.DebugEnabled() {
+		return
+	}
+
+	attrs := []slog.Attr{slog.String("op", op), slog.String("key", key), slog.Duration("duration", time.Since(start))}
 	if err != nil {
-		l.lastErr = err
-		l.Logf("redis error: %s\n", err.Error())
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	l.Log(l.debugLevel(), "cache operation", attrs...)
+}
+
+// SetLastErr save last error, and log it.
+func (l *BaseDriver) SetLastErr(err error) {
+	l.SetOpErr("", "", err)
+}
+
+// SetOpErr save last error of the operation, and log it with the op and key attrs. the empty op, key are omitted.
+func (l *BaseDriver) SetOpErr(op, key string, err error) {
+	if err == nil {
+		return
+	}
+
+	l.lastErr = err
+	atomic.AddUint64(&l.errCount, 1)
+	attrs := make([]slog.Attr, 0, 3)
+	if op != "" {
+		attrs = append(attrs, slog.String("op", op))
+	}
+	if key != "" {
+		attrs = append(attrs, slog.String("key", key))
+	}
+	l.Log(l.errorLevel(), "cache operation failed", append(attrs, slog.Any("error", err))...)
+}
+
+// LogErr log an error of the background task with the attrs. eg: sweep the expired keys
+func (l *BaseDriver) LogErr(msg string, err error, attrs ...slog.Attr) {
+	l.Log(l.errorLevel(), msg, append(attrs, slog.Any("error", err))...)
+}
+
+func (l *BaseDriver) errorLevel() slog.Level {
+	if l.opt.ErrorLevel != nil {
+		return l.opt.ErrorLevel.Level()
 	}
+	return slog.LevelError
+}
+
+func (l *BaseDriver) debugLevel() slog.Level {
+	if l.opt.DebugLevel != nil {
+		return l.opt.DebugLevel.Level()
+	}
+	return slog.LevelDebug
 }
 
 // LastErr get
@@ -164,6 +348,32 @@ func (l *BaseDriver) LastErr(key string) error {
 	return l.lastErr
 }
 
+// ErrCount get the number of the errors saved by SetOpErr. it can be used to check a read is failed.
+func (l *BaseDriver) ErrCount() uint64 {
+	return atomic.LoadUint64(&l.errCount)
+}
+
+// SetContext set the context for operate
+func (l *BaseDriver) SetContext(ctx context.Context) {
+	l.ctx = ctx
+}
+
+// Context get the context for operate. default is context.Background()
+func (l *BaseDriver) Context() context.Context {
+	if l.ctx != nil {
+		return l.ctx
+	}
+	return context.Background()
+}
+
+// ContextErr returns the context error if it is done
+func (l *BaseDriver) ContextErr() error {
+	if l.ctx != nil {
+		return l.ctx.Err()
+	}
+	return nil
+}
+
 // IsDebug get
 func (l *BaseDriver) IsDebug() bool {
 	return l.opt.Debug
@@ -1,20 +1,25 @@
 package cache
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/gookit/goutil/mathutil"
+	"github.com/gookit/gsr"
 )
 
 // FileCache definition.
 type FileCache struct {
 	BaseDriver
-	// caches in memory
-	MemoryCache
+	// caches in memory. it is not embedded, the ClearPrefix of it can not clear the cache files.
+	mem *MemoryCache
 	// cache directory path
 	cacheDir string
 	// DisableMemCache disable cache in memory
@@ -27,15 +32,16 @@ type FileCache struct {
 
 // NewFileCache create a FileCache instance
 func NewFileCache(dir string, pfxAndKey ...string) *FileCache {
-	if dir == "" { // empty, use system tmp dir
-		dir = os.TempDir()
+	if dir == "" { // empty, use a sub dir of system tmp dir
+		dir = filepath.Join(os.TempDir(), "gookit-cache")
 	}
 
 	c := &FileCache{
 		cacheDir: dir,
 		// init a memory cache.
-		MemoryCache: MemoryCache{caches: make(map[string]*Item)},
+		mem: NewMemoryCache(),
 	}
+	c.SetName(DvrFile)
 
 	if ln := len(pfxAndKey); ln > 0 {
 		// c.prefix = pfxAndKey[0]
@@ -49,75 +55,104 @@ func NewFileCache(dir string, pfxAndKey ...string) *FileCache {
 	return c
 }
 
+// WithContext returns a copy of the cache for operate with ctx.
+// the copy shares the cache data with the origin.
+func (c *FileCache) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *c
+	cp.SetContext(ctx)
+	return &cp
+}
+
 // Has cache key. will check expire time
 func (c *FileCache) Has(key string) bool {
-	return c.get(key) != nil
+	return c.Get(key) != nil
 }
 
 // Get value by key
 func (c *FileCache) Get(key string) any {
+	if err := c.ContextErr(); err != nil {
+		c.SetOpErr("Get", key, err)
+		return nil
+	}
+
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
+
 	return c.get(key)
 }
 
 func (c *FileCache) get(key string) any {
+	if item := c.getItem(key); item != nil {
+		return item.Val
+	}
+	return nil
+}
+
+// getItem read cache item from memory or file. must hold the lock.
+func (c *FileCache) getItem(key string) *Item {
 	// read cache from memory
-	c.lock.RLock()
-	val := c.MemoryCache.get(key)
-	c.lock.RUnlock()
-	if val != nil {
-		return val
+	if item, ok := c.mem.caches[c.Key(key)]; ok && !item.Expired() {
+		return item
 	}
 
 	// read cache from file
 	bs, err := ioutil.ReadFile(c.GetFilename(key))
 	if err != nil {
-		c.SetLastErr(err)
+		// not exists is not an error
+		if !os.IsNotExist(err) {
+			c.SetOpErr("Get", key, err)
+		}
 		return nil
 	}
 
 	item := &Item{}
 	if err = c.UnmarshalTo(bs, item); err != nil {
-		c.SetLastErr(err)
+		c.SetOpErr("Get", key, err)
 		return nil
 	}
 
 	// check expired
 	if item.Expired() {
-		c.SetLastErr(c.del(key))
+		c.mem.evictions.Add(1)
+		c.SetOpErr("DelExpired", key, c.del(key))
 		return nil
 	}
 
-	c.lock.Lock()
-	c.caches[key] = item // save to memory.
-	c.lock.Unlock()
-	return item.Val
+	c.mem.caches[c.Key(key)] = item // save to memory.
+	return item
 }
 
 // Set value by key
 func (c *FileCache) Set(key string, val any, ttl time.Duration) (err error) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	if err = c.ContextErr(); err != nil {
+		return err
+	}
+
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
 
 	return c.set(key, val, ttl)
 }
 
 func (c *FileCache) set(key string, val any, ttl time.Duration) (err error) {
-	err = c.MemoryCache.set(key, val, ttl)
-	if err != nil {
-		return
-	}
+	return c.setItem(key, newItem(val, ttl))
+}
+
+// setItem save cache item to memory and file. must hold the lock.
+func (c *FileCache) setItem(key string, item *Item) (err error) {
+	c.mem.caches[c.Key(key)] = item
 
 	// cache item data to file
-	bs, err := c.MustMarshal(c.caches[key])
+	bs, err := c.MustMarshal(item)
 	if err != nil {
-		c.SetLastErr(err)
+		c.SetOpErr("Set", key, err)
 		return
 	}
 
 	file := c.GetFilename(key)
 	dir := filepath.Dir(file)
 	if err = os.MkdirAll(dir, 0755); err != nil {
-		c.SetLastErr(err)
+		c.SetOpErr("Set", key, err)
 		return
 	}
 
@@ -133,14 +168,18 @@ func (c *FileCache) set(key string, val any, ttl time.Duration) (err error) {
 
 // Del value by key
 func (c *FileCache) Del(key string) error {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
 
 	return c.del(key)
 }
 
 func (c *FileCache) del(key string) error {
-	if err := c.MemoryCache.del(key); err != nil {
+	if err := c.mem.del(c.Key(key)); err != nil {
 		return err
 	}
 
@@ -154,11 +193,15 @@ func (c *FileCache) del(key string) error {
 
 // GetMulti values by multi key
 func (c *FileCache) GetMulti(keys []string) map[string]any {
-	c.lock.RLock()
-	defer c.lock.RUnlock()
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
 
 	data := make(map[string]any, len(keys))
 	for _, key := range keys {
+		if err := c.ContextErr(); err != nil {
+			c.SetOpErr("GetMulti", key, err)
+			break
+		}
 		data[key] = c.get(key)
 	}
 
@@ -167,10 +210,13 @@ func (c *FileCache) GetMulti(keys []string) map[string]any {
 
 // SetMulti values by multi key
 func (c *FileCache) SetMulti(values map[string]any, ttl time.Duration) (err error) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
 
 	for key, val := range values {
+		if err = c.ContextErr(); err != nil {
+			return
+		}
 		if err = c.set(key, val, ttl); err != nil {
 			return
 		}
@@ -180,37 +226,237 @@ func (c *FileCache) SetMulti(values map[string]any, ttl time.Duration) (err erro
 
 // DelMulti values by multi key
 func (c *FileCache) DelMulti(keys []string) error {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
 
 	for _, key := range keys {
+		if err := c.ContextErr(); err != nil {
+			return err
+		}
 		_ = c.del(key)
 	}
 	return nil
 }
 
+// Incr increment the key value by 1
+func (c *FileCache) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1
+func (c *FileCache) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta.
+// the ttl is only used when the key is created.
+func (c *FileCache) IncrBy(key string, delta int64, ttl ...time.Duration) (int64, error) {
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
+
+	item := c.getItem(key)
+	if item == nil {
+		return delta, c.set(key, delta, CreateTTL(ttl))
+	}
+
+	num, err := mathutil.ToInt64(item.Val)
+	if err != nil {
+		return 0, ErrNotNumber
+	}
+
+	num += delta
+	return num, c.setItem(key, &Item{Exp: item.Exp, Val: num})
+}
+
+// IncrByFloat increment the key value by float delta.
+// the ttl is only used when the key is created.
+func (c *FileCache) IncrByFloat(key string, delta float64, ttl ...time.Duration) (float64, error) {
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
+
+	item := c.getItem(key)
+	if item == nil {
+		return delta, c.set(key, delta, CreateTTL(ttl))
+	}
+
+	num, err := mathutil.ToFloat(item.Val)
+	if err != nil {
+		return 0, ErrNotNumber
+	}
+
+	num += delta
+	return num, c.setItem(key, &Item{Exp: item.Exp, Val: num})
+}
+
+// Add set the key value only if the key does not exist
+func (c *FileCache) Add(key string, val any, ttl time.Duration) (bool, error) {
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
+
+	if c.getItem(key) != nil {
+		return false, nil
+	}
+	return true, c.set(key, val, ttl)
+}
+
+// Replace set the key value only if the key already exists
+func (c *FileCache) Replace(key string, val any, ttl time.Duration) (bool, error) {
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
+
+	if c.getItem(key) == nil {
+		return false, nil
+	}
+	return true, c.set(key, val, ttl)
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (c *FileCache) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (bool, error) {
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
+
+	item := c.getItem(key)
+	if item == nil || !c.EqualValue(item.Val, oldVal) {
+		return false, nil
+	}
+	return true, c.set(key, newVal, ttl)
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal
+func (c *FileCache) CompareAndDelete(key string, oldVal any) (bool, error) {
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
+
+	item := c.getItem(key)
+	if item == nil || !c.EqualValue(item.Val, oldVal) {
+		return false, nil
+	}
+	return true, c.del(key)
+}
+
+// TTL get the remaining time to live of the key
+func (c *FileCache) TTL(key string) (time.Duration, error) {
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
+
+	item := c.getItem(key)
+	if item == nil {
+		return 0, ErrNotFound
+	}
+	return item.TTL(), nil
+}
+
+// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration.
+func (c *FileCache) Expire(key string, ttl time.Duration) error {
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
+
+	item := c.getItem(key)
+	if item == nil {
+		return ErrNotFound
+	}
+	return c.setItem(key, &Item{Exp: expireAt(ttl), Val: item.Val})
+}
+
+// Persist remove the key expiration
+func (c *FileCache) Persist(key string) error {
+	return c.Expire(key, Forever)
+}
+
+// Touch refresh the key expiration to ttl from now
+func (c *FileCache) Touch(key string, ttl time.Duration) error {
+	if err := c.Expire(key, ttl); err != ErrNotFound {
+		return err
+	}
+	return nil
+}
+
 // Close cache
 func (c *FileCache) Close() error {
 	return nil
 }
 
-// Clear caches and files
+// Evictions get the number of the evicted items. eg: the expired items are deleted on read.
+func (c *FileCache) Evictions() uint64 {
+	return c.mem.Evictions()
+}
+
+// Count cache item number in memory
+func (c *FileCache) Count() int {
+	c.mem.lock.RLock()
+	defer c.mem.lock.RUnlock()
+	return len(c.mem.caches)
+}
+
+// Clear caches and files. if the prefix is set, only clear the caches with prefix.
+//
+// Only the cache files written by the FileCache are deleted, the other files in the cache dir are kept.
 func (c *FileCache) Clear() error {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	for key := range c.caches {
-		if file := c.GetFilename(key); fileExists(file) {
-			err := os.Remove(file)
-			if err != nil {
-				return err
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+
+	c.mem.lock.Lock()
+	defer c.mem.lock.Unlock()
+
+	prefix := c.opt.Prefix
+	for key := range c.mem.caches {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.mem.caches, key)
+		}
+	}
+
+	// the cache file is: {cacheDir}/{hash[0:6]}/{prefix}{hash}.data
+	var hashDirs []string
+	err := filepath.WalkDir(c.cacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err = c.ContextErr(); err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if d.IsDir() {
+			if path == c.cacheDir {
+				return nil
+			}
+			if !isHashDir(name) || filepath.Dir(path) != filepath.Clean(c.cacheDir) {
+				return fs.SkipDir
 			}
+			hashDirs = append(hashDirs, path)
+			return nil
 		}
+
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".data") {
+			return nil
+		}
+		return os.Remove(path)
+	})
+
+	// remove the empty hash dirs
+	for _, dir := range hashDirs {
+		_ = os.Remove(dir)
 	}
 
-	c.caches = nil
-	// clear cache files
-	return os.RemoveAll(c.cacheDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// isHashDir check the dir name is the hash dir of cache files.
+func isHashDir(name string) bool {
+	if len(name) != 6 {
+		return false
+	}
+
+	for _, ch := range name {
+		if (ch < '0' || ch > '9') && (ch < 'a' || ch > 'f') {
+			return false
+		}
+	}
+	return true
 }
 
 // GetFilename cache file name build
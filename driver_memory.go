@@ -1,8 +1,14 @@
 package cache
 
 import (
+	"context"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/gookit/goutil/mathutil"
+	"github.com/gookit/gsr"
 )
 
 // Item for memory cache
@@ -20,10 +26,14 @@ func (item Item) Expired() bool {
 
 // MemoryCache definition.
 type MemoryCache struct {
-	// locker
-	lock sync.RWMutex
+	// locker. it is shared with the copies by WithContext()
+	lock *sync.RWMutex
 	// cache data in memory. or use sync.Map
 	caches map[string]*Item
+	// the number of evicted items. it is shared with the copies by WithContext()
+	evictions *atomic.Uint64
+	// context for operate
+	ctx context.Context
 	// CacheSize TODO set max cache size
 	CacheSize int
 }
@@ -31,42 +41,82 @@ type MemoryCache struct {
 // NewMemoryCache create a memory cache instance
 func NewMemoryCache() *MemoryCache {
 	return &MemoryCache{
-		caches: make(map[string]*Item),
+		lock:      new(sync.RWMutex),
+		caches:    make(map[string]*Item),
+		evictions: new(atomic.Uint64),
 	}
 }
 
+// WithContext returns a copy of the cache for operate with ctx.
+// the copy shares the cache data with the origin.
+func (c *MemoryCache) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *c
+	cp.ctx = ctx
+	return &cp
+}
+
+// returns the context error if it is done
+func (c *MemoryCache) ctxErr() error {
+	if c.ctx != nil {
+		return c.ctx.Err()
+	}
+	return nil
+}
+
 // Has cache key
 func (c *MemoryCache) Has(key string) bool {
-	c.lock.RLock()
-	defer c.lock.RUnlock()
+	if c.ctxErr() != nil {
+		return false
+	}
 
-	return c.get(key) != nil
+	return c.Get(key) != nil
 }
 
 // Get cache value by key
 func (c *MemoryCache) Get(key string) any {
+	if c.ctxErr() != nil {
+		return nil
+	}
+
 	c.lock.RLock()
-	defer c.lock.RUnlock()
+	val, expired := c.get(key)
+	c.lock.RUnlock()
 
-	return c.get(key)
+	// has been expired, remove it.
+	if expired {
+		c.delExpired(key)
+	}
+	return val
 }
 
-func (c *MemoryCache) get(key string) any {
+// get value by key, must hold the lock. the expired item will return nil and true.
+func (c *MemoryCache) get(key string) (val any, expired bool) {
 	if item, ok := c.caches[key]; ok {
-		// check expire time. if has been expired, remove it.
 		if item.Expired() {
-			_ = c.del(key)
-			return nil
+			return nil, true
 		}
-
-		return item.Val
+		return item.Val, false
 	}
+	return nil, false
+}
 
-	return nil
+// delete the expired item. re-check it under the write lock, the key maybe updated.
+func (c *MemoryCache) delExpired(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if item, ok := c.caches[key]; ok && item.Expired() {
+		delete(c.caches, key)
+		c.evictions.Add(1)
+	}
 }
 
 // Set cache value by key
 func (c *MemoryCache) Set(key string, val any, ttl time.Duration) (err error) {
+	if err = c.ctxErr(); err != nil {
+		return err
+	}
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -74,17 +124,41 @@ func (c *MemoryCache) Set(key string, val any, ttl time.Duration) (err error) {
 }
 
 func (c *MemoryCache) set(key string, val any, ttl time.Duration) (err error) {
-	item := &Item{Val: val}
+	c.caches[key] = newItem(val, ttl)
+	return
+}
+
+func newItem(val any, ttl time.Duration) *Item {
+	return &Item{Val: val, Exp: expireAt(ttl)}
+}
+
+// expireAt build the item expire time by ttl. 0 is never expired.
+func expireAt(ttl time.Duration) int64 {
 	if ttl > 0 {
-		item.Exp = time.Now().Unix() + int64(ttl/time.Second)
+		return time.Now().Unix() + int64(ttl/time.Second)
 	}
+	return 0
+}
 
-	c.caches[key] = item
-	return
+// TTL get the remaining time to live of the item
+func (item Item) TTL() time.Duration {
+	if item.Exp == 0 {
+		return Forever
+	}
+
+	// the Exp is in seconds, round up to avoid returns Forever.
+	if left := time.Until(time.Unix(item.Exp, 0)); left > 0 {
+		return left
+	}
+	return time.Millisecond
 }
 
 // Del cache by key
 func (c *MemoryCache) Del(key string) error {
+	if err := c.ctxErr(); err != nil {
+		return err
+	}
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -102,37 +176,182 @@ func (c *MemoryCache) del(key string) error {
 // GetMulti values by multi key
 func (c *MemoryCache) GetMulti(keys []string) map[string]any {
 	c.lock.RLock()
+	defer c.lock.RUnlock()
 
 	data := make(map[string]any, len(keys))
 	for _, key := range keys {
-		data[key] = c.get(key)
+		if c.ctxErr() != nil {
+			break
+		}
+		data[key], _ = c.get(key)
 	}
-
-	c.lock.RUnlock()
 	return data
 }
 
 // SetMulti values by multi key
 func (c *MemoryCache) SetMulti(values map[string]any, ttl time.Duration) (err error) {
 	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	for key, val := range values {
+		if err = c.ctxErr(); err != nil {
+			return
+		}
 		if err = c.set(key, val, ttl); err != nil {
 			return
 		}
 	}
-
-	c.lock.Unlock()
 	return
 }
 
 // DelMulti values by multi key
 func (c *MemoryCache) DelMulti(keys []string) error {
 	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	for _, key := range keys {
+		if err := c.ctxErr(); err != nil {
+			return err
+		}
 		_ = c.del(key)
 	}
+	return nil
+}
 
-	c.lock.Unlock()
+// Incr increment the key value by 1
+func (c *MemoryCache) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1
+func (c *MemoryCache) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta.
+// the ttl is only used when the key is created.
+func (c *MemoryCache) IncrBy(key string, delta int64, ttl ...time.Duration) (int64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	item, ok := c.caches[key]
+	if !ok || item.Expired() {
+		return delta, c.set(key, delta, CreateTTL(ttl))
+	}
+
+	num, err := mathutil.ToInt64(item.Val)
+	if err != nil {
+		return 0, ErrNotNumber
+	}
+
+	num += delta
+	item.Val = num
+	return num, nil
+}
+
+// IncrByFloat increment the key value by float delta.
+// the ttl is only used when the key is created.
+func (c *MemoryCache) IncrByFloat(key string, delta float64, ttl ...time.Duration) (float64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	item, ok := c.caches[key]
+	if !ok || item.Expired() {
+		return delta, c.set(key, delta, CreateTTL(ttl))
+	}
+
+	num, err := mathutil.ToFloat(item.Val)
+	if err != nil {
+		return 0, ErrNotNumber
+	}
+
+	num += delta
+	item.Val = num
+	return num, nil
+}
+
+// Add set the key value only if the key does not exist
+func (c *MemoryCache) Add(key string, val any, ttl time.Duration) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if item, ok := c.caches[key]; ok && !item.Expired() {
+		return false, nil
+	}
+	return true, c.set(key, val, ttl)
+}
+
+// Replace set the key value only if the key already exists
+func (c *MemoryCache) Replace(key string, val any, ttl time.Duration) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if item, ok := c.caches[key]; !ok || item.Expired() {
+		return false, nil
+	}
+	return true, c.set(key, val, ttl)
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (c *MemoryCache) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	item, ok := c.caches[key]
+	if !ok || item.Expired() || !EqualValue(item.Val, oldVal) {
+		return false, nil
+	}
+	return true, c.set(key, newVal, ttl)
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal
+func (c *MemoryCache) CompareAndDelete(key string, oldVal any) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	item, ok := c.caches[key]
+	if !ok || item.Expired() || !EqualValue(item.Val, oldVal) {
+		return false, nil
+	}
+	return true, c.del(key)
+}
+
+// TTL get the remaining time to live of the key
+func (c *MemoryCache) TTL(key string) (time.Duration, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	item, ok := c.caches[key]
+	if !ok || item.Expired() {
+		return 0, ErrNotFound
+	}
+	return item.TTL(), nil
+}
+
+// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration.
+func (c *MemoryCache) Expire(key string, ttl time.Duration) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	item, ok := c.caches[key]
+	if !ok || item.Expired() {
+		return ErrNotFound
+	}
+
+	item.Exp = expireAt(ttl)
+	return nil
+}
+
+// Persist remove the key expiration
+func (c *MemoryCache) Persist(key string) error {
+	return c.Expire(key, Forever)
+}
+
+// Touch refresh the key expiration to ttl from now
+func (c *MemoryCache) Touch(key string, ttl time.Duration) error {
+	if err := c.Expire(key, ttl); err != ErrNotFound {
+		return err
+	}
 	return nil
 }
 
@@ -143,10 +362,37 @@ func (c *MemoryCache) Close() error {
 
 // Clear all caches
 func (c *MemoryCache) Clear() error {
-	c.caches = nil
+	if err := c.ctxErr(); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	clear(c.caches)
+	c.lock.Unlock()
+	return nil
+}
+
+// ClearPrefix delete the caches which key has the prefix
+func (c *MemoryCache) ClearPrefix(prefix string) error {
+	if err := c.ctxErr(); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	for key := range c.caches {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.caches, key)
+		}
+	}
+	c.lock.Unlock()
 	return nil
 }
 
+// Evictions get the number of the evicted items. eg: the expired items are deleted on read.
+func (c *MemoryCache) Evictions() uint64 {
+	return c.evictions.Load()
+}
+
 // Count cache item number
 func (c *MemoryCache) Count() int {
 	return len(c.caches)
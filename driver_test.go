@@ -1,15 +1,35 @@
 package cache_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest"
 	"github.com/gookit/goutil/dump"
 	"github.com/gookit/goutil/strutil"
 	"github.com/gookit/goutil/testutil/assert"
 )
 
+func TestMemoryCache_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		return cache.NewMemoryCache()
+	}, cachetest.WithTTLPrecision(time.Second))
+}
+
+func TestFileCache_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		return cache.NewFileCache(t.TempDir())
+	}, cachetest.WithTTLPrecision(time.Second))
+}
+
 func TestNewMemoryCache(t *testing.T) {
 	is := assert.New(t)
 	c := cache.NewMemoryCache()
@@ -72,6 +92,139 @@ func TestMemoryCache_expired(t *testing.T) {
 	time.Sleep(cache.Seconds2)
 
 	is.Nil(c.Get(key))
+	is.Eq(uint64(1), c.Evictions())
+
+	// the explicit delete is not eviction
+	is.NoErr(c.Set(key, "value", 0))
+	is.NoErr(c.Del(key))
+	is.Eq(uint64(1), c.Evictions())
+}
+
+func TestMemoryCache_counter(t *testing.T) {
+	is := assert.New(t)
+	c := cache.NewMemoryCache()
+
+	var _ cache.Counter = c
+	key := "counter"
+
+	num, err := c.Incr(key, cache.Seconds1)
+	is.NoError(err)
+	is.Eq(int64(1), num)
+
+	num, err = c.IncrBy(key, 5, cache.OneMinutes)
+	is.NoError(err)
+	is.Eq(int64(6), num)
+
+	num, err = c.Decr(key)
+	is.NoError(err)
+	is.Eq(int64(5), num)
+
+	// ttl only used on create
+	time.Sleep(cache.Seconds2)
+	is.False(c.Has(key))
+
+	f, err := c.IncrByFloat("fcounter", 1.5)
+	is.NoError(err)
+	is.Eq(1.5, f)
+
+	is.NoError(c.Set("str", "abc", 0))
+	_, err = c.Incr("str")
+	is.ErrIs(err, cache.ErrNotNumber)
+}
+
+func TestMemoryCache_conditional(t *testing.T) {
+	is := assert.New(t)
+	c := cache.NewMemoryCache()
+
+	var _ cache.ConditionalSetter = c
+	key := "cond"
+
+	ok, err := c.Replace(key, "v0", 0)
+	is.NoError(err)
+	is.False(ok)
+
+	ok, err = c.Add(key, "v1", 0)
+	is.NoError(err)
+	is.True(ok)
+	ok, err = c.Add(key, "v2", 0)
+	is.NoError(err)
+	is.False(ok)
+
+	ok, err = c.Replace(key, "v2", 0)
+	is.NoError(err)
+	is.True(ok)
+	is.Eq("v2", c.Get(key))
+
+	ok, err = c.CompareAndSwap(key, "v1", "v3", 0)
+	is.NoError(err)
+	is.False(ok)
+	ok, err = c.CompareAndSwap(key, "v2", "v3", 0)
+	is.NoError(err)
+	is.True(ok)
+	is.Eq("v3", c.Get(key))
+
+	var _ cache.CompareDeleter = c
+	ok, err = c.CompareAndDelete(key, "v2")
+	is.NoError(err)
+	is.False(ok)
+	ok, err = c.CompareAndDelete(key, "v3")
+	is.NoError(err)
+	is.True(ok)
+	is.False(c.Has(key))
+}
+
+func TestMemoryCache_ttl(t *testing.T) {
+	is := assert.New(t)
+	c := cache.NewMemoryCache()
+
+	var _ cache.TTLer = c
+	key := "ttl"
+
+	_, err := c.TTL(key)
+	is.ErrIs(err, cache.ErrNotFound)
+	is.ErrIs(c.Expire(key, cache.Seconds3), cache.ErrNotFound)
+	is.NoError(c.Touch(key, cache.Seconds3))
+
+	is.NoError(c.Set(key, "value", 0))
+	ttl, err := c.TTL(key)
+	is.NoError(err)
+	is.Eq(time.Duration(cache.Forever), ttl)
+
+	is.NoError(c.Expire(key, cache.OneMinutes))
+	ttl, err = c.TTL(key)
+	is.NoError(err)
+	is.Gt(ttl, cache.Seconds30)
+	is.Lte(ttl, cache.OneMinutes)
+
+	is.NoError(c.Persist(key))
+	ttl, err = c.TTL(key)
+	is.NoError(err)
+	is.Eq(time.Duration(cache.Forever), ttl)
+
+	is.NoError(c.Touch(key, cache.Seconds1))
+	time.Sleep(cache.Seconds2)
+	is.False(c.Has(key))
+}
+
+func TestMemoryCache_WithContext(t *testing.T) {
+	is := assert.New(t)
+	c := cache.NewMemoryCache()
+	is.NoError(c.Set("key", "value", 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cc := cache.WithContext(c, ctx)
+	is.Eq("value", cc.Get("key"))
+
+	// shares data with origin
+	is.NoError(cc.Set("key1", "value1", 0))
+	is.Eq("value1", c.Get("key1"))
+
+	cancel()
+	is.Nil(cc.Get("key"))
+	is.ErrIs(cc.Set("key2", "value2", 0), context.Canceled)
+	is.ErrIs(cc.SetMulti(map[string]any{"key2": "value2"}, 0), context.Canceled)
+	is.ErrIs(cc.Clear(), context.Canceled)
+	is.Eq("value", c.Get("key"))
 }
 
 func TestNewFileCache(t *testing.T) {
@@ -123,6 +276,139 @@ func TestFileCache_object(t *testing.T) {
 	// dump.P("cache get:", val)
 }
 
+func TestFileCache_counter(t *testing.T) {
+	is := assert.New(t)
+	c := cache.NewFileCache("./testdata")
+	defer c.Del("counter")
+
+	var _ cache.Counter = c
+	key := "counter"
+	is.NoError(c.Del(key))
+
+	num, err := c.Incr(key)
+	is.NoError(err)
+	is.Eq(int64(1), num)
+
+	num, err = c.IncrBy(key, 5)
+	is.NoError(err)
+	is.Eq(int64(6), num)
+
+	// read from file
+	c2 := cache.NewFileCache("./testdata")
+	num, err = c2.Decr(key)
+	is.NoError(err)
+	is.Eq(int64(5), num)
+
+	f, err := c2.IncrByFloat(key, 0.5)
+	is.NoError(err)
+	is.Eq(5.5, f)
+}
+
+func TestFileCache_conditional(t *testing.T) {
+	is := assert.New(t)
+	c := cache.NewFileCache("./testdata")
+	defer c.Del("cond")
+
+	var _ cache.ConditionalSetter = c
+	key := "cond"
+	is.NoError(c.Del(key))
+
+	ok, err := c.Add(key, 1, 0)
+	is.NoError(err)
+	is.True(ok)
+	ok, err = c.Add(key, 2, 0)
+	is.NoError(err)
+	is.False(ok)
+
+	// value read from file is float64
+	c2 := cache.NewFileCache("./testdata")
+	ok, err = c2.CompareAndSwap(key, 1, 3, 0)
+	is.NoError(err)
+	is.True(ok)
+
+	ok, err = c2.Replace(key, 4, 0)
+	is.NoError(err)
+	is.True(ok)
+	is.Eq(4, c2.Get(key))
+}
+
+func TestFileCache_ttl(t *testing.T) {
+	is := assert.New(t)
+	c := cache.NewFileCache("./testdata")
+	defer c.Del("ttl")
+
+	var _ cache.TTLer = c
+	key := "ttl"
+
+	is.NoError(c.Set(key, "value", cache.OneMinutes))
+	is.NoError(c.Persist(key))
+
+	// read from file
+	c2 := cache.NewFileCache("./testdata")
+	ttl, err := c2.TTL(key)
+	is.NoError(err)
+	is.Eq(time.Duration(cache.Forever), ttl)
+
+	is.NoError(c2.Expire(key, cache.OneMinutes))
+	ttl, err = c2.TTL(key)
+	is.NoError(err)
+	is.Gt(ttl, cache.Seconds30)
+	is.Eq("value", c2.Get(key))
+}
+
+func TestFileCache_Clear(t *testing.T) {
+	is := assert.New(t)
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other.txt")
+	is.NoErr(os.WriteFile(other, []byte("keep"), 0644))
+	is.NoErr(os.MkdirAll(filepath.Join(dir, "abcdef", "nested"), 0755))
+
+	c := cache.NewFileCache(dir)
+	is.NoErr(c.Set("key", "value", 0))
+	is.True(fileExists(c.GetFilename("key")))
+	is.Eq(1, c.Count())
+	is.NoErr(c.Clear())
+	is.Eq(0, c.Count())
+
+	// only the cache files are deleted
+	is.False(fileExists(c.GetFilename("key")))
+	is.False(fileExists(filepath.Dir(c.GetFilename("key"))))
+	is.True(fileExists(other))
+	is.True(fileExists(filepath.Join(dir, "abcdef", "nested")))
+
+	// the cache file names are hashed, it is not a PrefixClearer
+	_, ok := any(c).(cache.PrefixClearer)
+	is.False(ok)
+	_, ok = any(c).(cache.EvictionCounter)
+	is.True(ok)
+
+	// the default dir is a sub dir of tmp dir
+	c = cache.NewFileCache("")
+	is.StrContains(c.GetFilename("key"), filepath.Join(os.TempDir(), "gookit-cache"))
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestFileCache_WithContext(t *testing.T) {
+	is := assert.New(t)
+	c := cache.NewFileCache("./testdata")
+	defer c.Del("key")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cc := c.WithContext(ctx)
+	is.NoError(cc.Set("key", "value", 0))
+	is.Eq("value", c.Get("key"))
+
+	cancel()
+	is.Nil(cc.Get("key"))
+	is.ErrIs(cc.Del("key"), context.Canceled)
+	is.ErrIs(cc.DelMulti([]string{"key"}), context.Canceled)
+	is.True(c.Has("key"))
+}
+
 func TestDefManager(t *testing.T) {
 	is := assert.New(t)
 	num := cache.UnregisterAll()
@@ -152,3 +438,69 @@ func TestDefManager(t *testing.T) {
 	num = cache.UnregisterAll()
 	is.Gte(num, 1)
 }
+
+func TestBaseDriver_log(t *testing.T) {
+	is := assert.New(t)
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	d := &cache.BaseDriver{}
+	d.SetName("test")
+	d.WithOptions(cache.WithSlog(logger))
+	is.Eq("test", d.Name())
+	is.True(d.HasLogger())
+	is.True(d.DebugEnabled())
+
+	d.SetOpErr("Get", "key", errors.New("failed"))
+	is.ErrMsg(d.LastErr("key"), "failed")
+	is.StrContains(buf.String(), `level=ERROR msg="cache operation failed" driver=test op=Get key=key error=failed`)
+
+	buf.Reset()
+	d.DebugOp("Set", "key", time.Now(), nil)
+	is.StrContains(buf.String(), `level=DEBUG msg="cache operation" driver=test op=Set key=key duration=`)
+
+	// custom levels
+	buf.Reset()
+	d.WithOptions(cache.WithLogLevels(slog.LevelWarn, slog.LevelInfo))
+	d.LogErr("sweep failed", errors.New("failed"))
+	d.Debugf("debug %s", "message")
+	is.StrContains(buf.String(), `level=WARN msg="sweep failed" driver=test error=failed`)
+	is.StrContains(buf.String(), `level=INFO msg="debug message" driver=test`)
+
+	// the debug is disabled by the handler level
+	buf.Reset()
+	d.WithOptions(cache.WithSlog(slog.New(slog.NewTextHandler(buf, nil))), cache.WithLogLevels(nil, nil))
+	is.False(d.DebugEnabled())
+	d.DebugOp("Set", "key", time.Now(), nil)
+	is.Empty(buf.String())
+
+	// the printf style logger
+	buf.Reset()
+	d = &cache.BaseDriver{}
+	d.SetName("test")
+	d.WithOptions(func(opt *cache.Option) {
+		opt.Logger = log.New(buf, "", 0)
+	})
+	d.SetLastErr(errors.New("failed"))
+	d.DebugOp("Set", "key", time.Now(), nil)
+	is.Eq("cache operation failed driver=test error=failed\n", buf.String())
+	d.WithOptions(cache.WithDebug(true))
+	d.DebugOp("Set", "key", time.Now(), nil)
+	is.StrContains(buf.String(), "cache operation driver=test op=Set key=key duration=")
+}
+
+func TestFileCache_log(t *testing.T) {
+	is := assert.New(t)
+	buf := new(bytes.Buffer)
+	c := cache.NewFileCache(t.TempDir())
+	c.WithOptions(cache.WithSlog(slog.New(slog.NewTextHandler(buf, nil))))
+
+	// not exists is not an error
+	is.Nil(c.Get("not-exist"))
+	is.Empty(buf.String())
+
+	is.NoErr(os.MkdirAll(filepath.Dir(c.GetFilename("bad")), 0755))
+	is.NoErr(os.WriteFile(c.GetFilename("bad"), []byte("invalid"), 0644))
+	is.Nil(c.Get("bad"))
+	is.StrContains(buf.String(), `msg="cache operation failed" driver=file op=Get key=bad error=`)
+}
@@ -2,9 +2,17 @@
 package gcache
 
 import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bluele/gcache"
+	"github.com/gookit/cache"
+	"github.com/gookit/gsr"
 )
 
 // Name driver name
@@ -14,6 +22,19 @@ const Name = "gcache"
 type GCache struct {
 	// cache.BaseDriver
 	db gcache.Cache
+	// lock for write, make the conditional write is atomic.
+	// it is shared with the copies by WithContext()
+	lock *sync.Mutex
+	// context for operate
+	ctx context.Context
+	// eviction counter, it is shared with the copies by WithContext()
+	counter *evictCounter
+}
+
+// the evicted func of gcache is also called on remove, so count the removed for exclude them.
+type evictCounter struct {
+	evicted atomic.Uint64
+	removed atomic.Uint64
 }
 
 // New create an instance
@@ -23,9 +44,30 @@ func New(size int) *GCache {
 
 // NewWithType create an instance with cache type
 func NewWithType(size int, tp string) *GCache {
+	counter := new(evictCounter)
 	return &GCache{
-		db: gcache.New(size).EvictType(tp).Build(),
+		db: gcache.New(size).EvictType(tp).EvictedFunc(func(_, _ any) {
+			counter.evicted.Add(1)
+		}).Build(),
+		lock:    new(sync.Mutex),
+		counter: counter,
+	}
+}
+
+// WithContext returns a copy of the driver for operate with ctx.
+// the copy shares the cache data with the origin.
+func (g *GCache) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *g
+	cp.ctx = ctx
+	return &cp
+}
+
+// returns the context error if it is done
+func (g *GCache) ctxErr() error {
+	if g.ctx != nil {
+		return g.ctx.Err()
 	}
+	return nil
 }
 
 // Close connection
@@ -35,10 +77,28 @@ func (g *GCache) Close() error {
 
 // Clear all caches
 func (g *GCache) Clear() error {
+	if err := g.ctxErr(); err != nil {
+		return err
+	}
+
 	g.db.Purge()
 	return nil
 }
 
+// ClearPrefix delete the caches which key has the prefix
+func (g *GCache) ClearPrefix(prefix string) error {
+	if err := g.ctxErr(); err != nil {
+		return err
+	}
+
+	for _, key := range g.db.Keys(false) {
+		if s, ok := key.(string); ok && strings.HasPrefix(s, prefix) {
+			g.remove(key)
+		}
+	}
+	return nil
+}
+
 // Has cache key
 func (g *GCache) Has(key string) bool {
 	return g.Get(key) != nil
@@ -46,18 +106,35 @@ func (g *GCache) Has(key string) bool {
 
 // Get cache by key
 func (g *GCache) Get(key string) any {
+	if g.ctxErr() != nil {
+		return nil
+	}
+
 	val, _ := g.db.Get(key)
 	return val
 }
 
 // Set cache by key
 func (g *GCache) Set(key string, val any, ttl time.Duration) (err error) {
-	return g.db.SetWithExpire(key, val, ttl)
+	if err = g.ctxErr(); err != nil {
+		return err
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.set(key, val, ttl)
 }
 
 // Del cache by key
 func (g *GCache) Del(key string) error {
-	g.db.Remove(key)
+	if err := g.ctxErr(); err != nil {
+		return err
+	}
+
+	g.lock.Lock()
+	g.remove(key)
+	g.lock.Unlock()
 	return nil
 }
 
@@ -66,6 +143,10 @@ func (g *GCache) GetMulti(keys []string) map[string]any {
 	data := make(map[string]any, len(keys))
 
 	for _, key := range keys {
+		if g.ctxErr() != nil {
+			break
+		}
+
 		val, err := g.db.Get(key)
 		if err == nil {
 			data[key] = val
@@ -77,21 +158,137 @@ func (g *GCache) GetMulti(keys []string) map[string]any {
 
 // SetMulti cache by keys
 func (g *GCache) SetMulti(values map[string]any, ttl time.Duration) (err error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
 	for key, val := range values {
-		err = g.db.SetWithExpire(key, val, ttl)
+		if err = g.ctxErr(); err != nil {
+			return
+		}
+		err = g.set(key, val, ttl)
 	}
 	return
 }
 
 // DelMulti cache by keys
 func (g *GCache) DelMulti(keys []string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
 	for _, key := range keys {
-		g.db.Remove(key)
+		if err := g.ctxErr(); err != nil {
+			return err
+		}
+		g.remove(key)
 	}
 	return nil
 }
 
+// Add set the key value only if the key does not exist
+func (g *GCache) Add(key string, val any, ttl time.Duration) (bool, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.db.Has(key) {
+		return false, nil
+	}
+	return true, g.set(key, val, ttl)
+}
+
+// Replace set the key value only if the key already exists
+func (g *GCache) Replace(key string, val any, ttl time.Duration) (bool, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if !g.db.Has(key) {
+		return false, nil
+	}
+	return true, g.set(key, val, ttl)
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (g *GCache) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (bool, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	val, err := g.db.Get(key)
+	if err != nil || !cache.EqualValue(val, oldVal) {
+		return false, nil
+	}
+	return true, g.set(key, newVal, ttl)
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal
+func (g *GCache) CompareAndDelete(key string, oldVal any) (bool, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	val, err := g.db.Get(key)
+	if err != nil || !cache.EqualValue(val, oldVal) {
+		return false, nil
+	}
+	return g.remove(key), nil
+}
+
+// Evictions get the number of the evicted items. eg: out of size, expired
+func (g *GCache) Evictions() uint64 {
+	evicted, removed := g.counter.evicted.Load(), g.counter.removed.Load()
+	if evicted < removed {
+		return 0
+	}
+	return evicted - removed
+}
+
 // Db get the gcache.Cache
 func (g *GCache) Db() gcache.Cache {
 	return g.db
 }
+
+// remove the key, and count it for exclude from the evictions
+func (g *GCache) remove(key any) bool {
+	ok := g.db.Remove(key)
+	if ok {
+		g.counter.removed.Add(1)
+	}
+	return ok
+}
+
+// set the key value. ttl <= 0 means the key will never expire.
+func (g *GCache) set(key string, val any, ttl time.Duration) error {
+	if ttl > 0 {
+		return g.db.SetWithExpire(key, val, ttl)
+	}
+
+	// gcache keeps the old expiration on update an exists item, so remove it first.
+	g.remove(key)
+	return g.db.Set(key, val)
+}
+
+/*************************************************************
+ * open by DSN url
+ *************************************************************/
+
+func init() {
+	cache.RegisterFactory(Name, openURL)
+}
+
+// open by the DSN url. eg: "gcache://?size=1000&type=lru"
+func openURL(u *url.URL) (cache.Cache, error) {
+	size, err := cache.URLInt(u, "size", 1000)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		return nil, &cache.ConfigError{Field: "size", Err: fmt.Errorf("must be greater than 0, got %d", size)}
+	}
+
+	tp := u.Query().Get("type")
+	switch tp {
+	case "":
+		tp = gcache.TYPE_LRU
+	case gcache.TYPE_SIMPLE, gcache.TYPE_LRU, gcache.TYPE_LFU, gcache.TYPE_ARC:
+	default:
+		return nil, &cache.ConfigError{Field: "type", Err: fmt.Errorf("unknown cache type %q", tp)}
+	}
+	return NewWithType(size, tp), nil
+}
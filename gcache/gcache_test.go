@@ -1,12 +1,14 @@
 package gcache_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/gookit/cache"
 	"github.com/gookit/cache/gcache"
+	"github.com/gookit/cache/cachetest"
 	"github.com/gookit/goutil/dump"
 	"github.com/gookit/goutil/strutil"
 	"github.com/gookit/goutil/testutil/assert"
@@ -119,4 +121,97 @@ func TestGCache_object(t *testing.T) {
 	b2 := c.Get(key).(user)
 	dump.P(b2)
 	is.Equal("inhere", b2.Name)
-}
\ No newline at end of file
+}
+
+func TestGCache_conditional(t *testing.T) {
+	is := assert.New(t)
+	c := gcache.New(12)
+	defer c.Clear()
+
+	var _ cache.ConditionalSetter = c
+	key := strutil.RandomCharsV2(12)
+
+	ok, err := c.Replace(key, "v0", cache.Seconds3)
+	is.NoError(err)
+	is.False(ok)
+
+	ok, err = c.Add(key, "v1", cache.Seconds3)
+	is.NoError(err)
+	is.True(ok)
+	ok, err = c.Add(key, "v2", cache.Seconds3)
+	is.NoError(err)
+	is.False(ok)
+
+	ok, err = c.CompareAndSwap(key, "v0", "v2", cache.Seconds3)
+	is.NoError(err)
+	is.False(ok)
+	ok, err = c.CompareAndSwap(key, "v1", "v2", cache.Seconds3)
+	is.NoError(err)
+	is.True(ok)
+	is.Eq("v2", c.Get(key))
+
+	// same as the other drivers, the values are equal after marshaled
+	is.NoError(c.Set(key, 1, cache.Seconds3))
+	ok, err = c.CompareAndSwap(key, float64(1), 2, cache.Seconds3)
+	is.NoError(err)
+	is.True(ok)
+	ok, err = c.CompareAndDelete(key, float64(2))
+	is.NoError(err)
+	is.True(ok)
+	is.False(c.Has(key))
+}
+
+func TestGCache_WithContext(t *testing.T) {
+	is := assert.New(t)
+	c := gcache.New(12)
+	defer c.Clear()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cc := c.WithContext(ctx)
+	is.NoError(cc.Set("key", "value", cache.Seconds3))
+	is.Eq("value", c.Get("key"))
+
+	cancel()
+	is.Nil(cc.Get("key"))
+	is.ErrIs(cc.Del("key"), context.Canceled)
+	is.Eq("value", c.Get("key"))
+}
+
+func TestGCache_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		return gcache.New(100)
+	})
+}
+
+func TestGCache_open(t *testing.T) {
+	is := assert.New(t)
+	c, err := cache.Open("gcache://?size=10&type=lfu")
+	is.NoErr(err)
+	is.NoErr(c.Set("key", "value", 0))
+	is.Eq("value", c.Get("key"))
+
+	_, err = cache.Open("gcache://?size=0")
+	is.ErrMsg(err, "cache config: size: must be greater than 0, got 0")
+	_, err = cache.Open("gcache://?type=fifo")
+	is.ErrMsg(err, `cache config: type: unknown cache type "fifo"`)
+}
+
+func TestGCache_evictions(t *testing.T) {
+	is := assert.New(t)
+	c := gcache.New(2)
+
+	is.NoErr(c.Set("k1", "v1", 0))
+	is.NoErr(c.Set("k2", "v2", 0))
+	is.NoErr(c.Set("k3", "v3", 0))
+	is.Eq(uint64(1), c.Evictions())
+
+	// the explicit deletes are not evictions
+	is.NoErr(c.Del("k3"))
+	is.NoErr(c.Set("k2", "new", 0))
+	is.Eq(uint64(1), c.Evictions())
+
+	is.NoErr(c.Set("k4", "v4", 20*time.Millisecond))
+	time.Sleep(30 * time.Millisecond)
+	is.Nil(c.Get("k4"))
+	is.Eq(uint64(2), c.Evictions())
+}
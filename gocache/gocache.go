@@ -11,8 +11,14 @@
 package gocache
 
 import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/gookit/cache"
+	"github.com/gookit/gsr"
 	goc "github.com/patrickmn/go-cache"
 )
 
@@ -22,6 +28,11 @@ const Name = "gocache"
 // GoCache struct
 type GoCache struct {
 	db *goc.Cache
+	// lock for write, make the conditional write is atomic.
+	// it is shared with the copies by WithContext()
+	lock *sync.Mutex
+	// context for operate
+	ctx context.Context
 	// will handle expire on has,get
 	expireManually bool
 }
@@ -34,7 +45,8 @@ func New() *GoCache {
 // NewSimple create new simple instance
 func NewSimple() *GoCache {
 	return &GoCache{
-		db: goc.New(goc.NoExpiration, goc.NoExpiration),
+		db:   goc.New(goc.NoExpiration, goc.NoExpiration),
+		lock: new(sync.Mutex),
 		// handle expire on has,get
 		expireManually: true,
 	}
@@ -43,10 +55,27 @@ func NewSimple() *GoCache {
 // NewGoCache create instance with settings
 func NewGoCache(defaultExpiration, cleanupInterval time.Duration) *GoCache {
 	return &GoCache{
-		db: goc.New(defaultExpiration, cleanupInterval),
+		db:   goc.New(defaultExpiration, cleanupInterval),
+		lock: new(sync.Mutex),
 	}
 }
 
+// WithContext returns a copy of the driver for operate with ctx.
+// the copy shares the cache data with the origin.
+func (g *GoCache) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *g
+	cp.ctx = ctx
+	return &cp
+}
+
+// returns the context error if it is done
+func (g *GoCache) ctxErr() error {
+	if g.ctx != nil {
+		return g.ctx.Err()
+	}
+	return nil
+}
+
 // Close connection
 func (g *GoCache) Close() error {
 	return nil
@@ -54,10 +83,28 @@ func (g *GoCache) Close() error {
 
 // Clear all caches
 func (g *GoCache) Clear() error {
+	if err := g.ctxErr(); err != nil {
+		return err
+	}
+
 	g.db.Flush()
 	return nil
 }
 
+// ClearPrefix delete the caches which key has the prefix
+func (g *GoCache) ClearPrefix(prefix string) error {
+	if err := g.ctxErr(); err != nil {
+		return err
+	}
+
+	for key := range g.db.Items() {
+		if strings.HasPrefix(key, prefix) {
+			g.db.Delete(key)
+		}
+	}
+	return nil
+}
+
 // Has cache key
 func (g *GoCache) Has(key string) bool {
 	return g.Get(key) != nil
@@ -65,6 +112,10 @@ func (g *GoCache) Has(key string) bool {
 
 // Get cache by key
 func (g *GoCache) Get(key string) any {
+	if g.ctxErr() != nil {
+		return nil
+	}
+
 	if g.expireManually {
 		g.db.DeleteExpired()
 	}
@@ -75,13 +126,25 @@ func (g *GoCache) Get(key string) any {
 
 // Set cache by key
 func (g *GoCache) Set(key string, val any, ttl time.Duration) error {
+	if err := g.ctxErr(); err != nil {
+		return err
+	}
+
+	g.lock.Lock()
 	g.db.Set(key, val, ttl)
+	g.lock.Unlock()
 	return nil
 }
 
 // Del cache by key
-func (g GoCache) Del(key string) error {
+func (g *GoCache) Del(key string) error {
+	if err := g.ctxErr(); err != nil {
+		return err
+	}
+
+	g.lock.Lock()
 	g.db.Delete(key)
+	g.lock.Unlock()
 	return nil
 }
 
@@ -90,6 +153,10 @@ func (g *GoCache) GetMulti(keys []string) map[string]any {
 	data := make(map[string]any, len(keys))
 
 	for _, key := range keys {
+		if g.ctxErr() != nil {
+			break
+		}
+
 		val, ok := g.db.Get(key)
 		if ok {
 			data[key] = val
@@ -100,8 +167,14 @@ func (g *GoCache) GetMulti(keys []string) map[string]any {
 }
 
 // SetMulti cache by keys
-func (g GoCache) SetMulti(values map[string]any, ttl time.Duration) error {
+func (g *GoCache) SetMulti(values map[string]any, ttl time.Duration) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
 	for key, val := range values {
+		if err := g.ctxErr(); err != nil {
+			return err
+		}
 		g.db.Set(key, val, ttl)
 	}
 	return nil
@@ -109,13 +182,180 @@ func (g GoCache) SetMulti(values map[string]any, ttl time.Duration) error {
 
 // DelMulti db by keys
 func (g *GoCache) DelMulti(keys []string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
 	for _, key := range keys {
+		if err := g.ctxErr(); err != nil {
+			return err
+		}
 		g.db.Delete(key)
 	}
 	return nil
 }
 
+// Incr increment the key value by 1
+func (g *GoCache) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return g.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1
+func (g *GoCache) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return g.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta. the value type must be int64.
+// the ttl is only used when the key is created.
+func (g *GoCache) IncrBy(key string, delta int64, ttl ...time.Duration) (int64, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	num, err := g.db.IncrementInt64(key, delta)
+	if err == nil {
+		return num, nil
+	}
+
+	// not exists, try create it.
+	if g.db.Add(key, delta, cache.CreateTTL(ttl)) == nil {
+		return delta, nil
+	}
+	return g.db.IncrementInt64(key, delta)
+}
+
+// IncrByFloat increment the key value by float delta. the value type must be float64.
+// the ttl is only used when the key is created.
+func (g *GoCache) IncrByFloat(key string, delta float64, ttl ...time.Duration) (float64, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	num, err := g.db.IncrementFloat64(key, delta)
+	if err == nil {
+		return num, nil
+	}
+
+	// not exists, try create it.
+	if g.db.Add(key, delta, cache.CreateTTL(ttl)) == nil {
+		return delta, nil
+	}
+	return g.db.IncrementFloat64(key, delta)
+}
+
+// Add set the key value only if the key does not exist
+func (g *GoCache) Add(key string, val any, ttl time.Duration) (bool, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.db.Add(key, val, ttl) == nil, nil
+}
+
+// Replace set the key value only if the key already exists
+func (g *GoCache) Replace(key string, val any, ttl time.Duration) (bool, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.db.Replace(key, val, ttl) == nil, nil
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (g *GoCache) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (bool, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	val, ok := g.db.Get(key)
+	if !ok || !cache.EqualValue(val, oldVal) {
+		return false, nil
+	}
+
+	g.db.Set(key, newVal, ttl)
+	return true, nil
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal
+func (g *GoCache) CompareAndDelete(key string, oldVal any) (bool, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	val, ok := g.db.Get(key)
+	if !ok || !cache.EqualValue(val, oldVal) {
+		return false, nil
+	}
+
+	g.db.Delete(key)
+	return true, nil
+}
+
+// TTL get the remaining time to live of the key
+func (g *GoCache) TTL(key string) (time.Duration, error) {
+	_, exp, ok := g.db.GetWithExpiration(key)
+	if !ok {
+		return 0, cache.ErrNotFound
+	}
+
+	if exp.IsZero() {
+		return cache.Forever, nil
+	}
+	return time.Until(exp), nil
+}
+
+// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration.
+func (g *GoCache) Expire(key string, ttl time.Duration) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	val, ok := g.db.Get(key)
+	if !ok {
+		return cache.ErrNotFound
+	}
+
+	if ttl <= 0 {
+		ttl = goc.NoExpiration
+	}
+
+	g.db.Set(key, val, ttl)
+	return nil
+}
+
+// Persist remove the key expiration
+func (g *GoCache) Persist(key string) error {
+	return g.Expire(key, cache.Forever)
+}
+
+// Touch refresh the key expiration to ttl from now
+func (g *GoCache) Touch(key string, ttl time.Duration) error {
+	if err := g.Expire(key, ttl); err != cache.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
 // Db get the goc.Cache
 func (g *GoCache) Db() *goc.Cache {
 	return g.db
 }
+
+/*************************************************************
+ * open by DSN url
+ *************************************************************/
+
+func init() {
+	cache.RegisterFactory(Name, openURL)
+}
+
+// open by the DSN url. eg: "gocache://", "gocache://?expiration=1h&cleanup=10m"
+func openURL(u *url.URL) (cache.Cache, error) {
+	q := u.Query()
+	if !q.Has("expiration") && !q.Has("cleanup") {
+		return NewSimple(), nil
+	}
+
+	exp, err := cache.URLDuration(u, "expiration", goc.NoExpiration)
+	if err != nil {
+		return nil, err
+	}
+
+	cleanup, err := cache.URLDuration(u, "cleanup", goc.NoExpiration)
+	if err != nil {
+		return nil, err
+	}
+	return NewGoCache(exp, cleanup), nil
+}
@@ -1,12 +1,14 @@
 package gocache_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/gookit/cache"
 	"github.com/gookit/cache/gocache"
+	"github.com/gookit/cache/cachetest"
 	"github.com/gookit/goutil/dump"
 	"github.com/gookit/goutil/strutil"
 	"github.com/gookit/goutil/testutil/assert"
@@ -120,4 +122,113 @@ func TestGoCache_object(t *testing.T) {
 	b2 := c.Get(key).(user)
 	dump.P(b2)
 	is.Equal("inhere", b2.Name)
-}
\ No newline at end of file
+}
+
+func TestGoCache_counter(t *testing.T) {
+	is := assert.New(t)
+	c := gocache.NewSimple()
+	defer c.Clear()
+
+	var _ cache.Counter = c
+	key := strutil.RandomCharsV2(12)
+
+	num, err := c.Incr(key)
+	is.NoError(err)
+	is.Eq(int64(1), num)
+
+	num, err = c.IncrBy(key, 9)
+	is.NoError(err)
+	is.Eq(int64(10), num)
+
+	num, err = c.Decr(key)
+	is.NoError(err)
+	is.Eq(int64(9), num)
+
+	f, err := c.IncrByFloat("f"+key, 1.5)
+	is.NoError(err)
+	is.Eq(1.5, f)
+}
+
+func TestGoCache_conditional(t *testing.T) {
+	is := assert.New(t)
+	c := gocache.NewSimple()
+	defer c.Clear()
+
+	var _ cache.ConditionalSetter = c
+	key := strutil.RandomCharsV2(12)
+
+	ok, err := c.Replace(key, "v0", cache.Seconds3)
+	is.NoError(err)
+	is.False(ok)
+
+	ok, err = c.Add(key, "v1", cache.Seconds3)
+	is.NoError(err)
+	is.True(ok)
+	ok, err = c.Add(key, "v2", cache.Seconds3)
+	is.NoError(err)
+	is.False(ok)
+
+	ok, err = c.CompareAndSwap(key, "v0", "v2", cache.Seconds3)
+	is.NoError(err)
+	is.False(ok)
+	ok, err = c.CompareAndSwap(key, "v1", "v2", cache.Seconds3)
+	is.NoError(err)
+	is.True(ok)
+	is.Eq("v2", c.Get(key))
+
+	// same as the other drivers, the values are equal after marshaled
+	is.NoError(c.Set(key, 1, cache.Seconds3))
+	ok, err = c.CompareAndSwap(key, float64(1), 2, cache.Seconds3)
+	is.NoError(err)
+	is.True(ok)
+	ok, err = c.CompareAndDelete(key, float64(2))
+	is.NoError(err)
+	is.True(ok)
+	is.False(c.Has(key))
+}
+
+func TestGoCache_ttl(t *testing.T) {
+	is := assert.New(t)
+	c := gocache.NewSimple()
+	defer c.Clear()
+
+	var _ cache.TTLer = c
+	key := strutil.RandomCharsV2(12)
+
+	_, err := c.TTL(key)
+	is.ErrIs(err, cache.ErrNotFound)
+	is.ErrIs(c.Expire(key, cache.Seconds3), cache.ErrNotFound)
+
+	is.NoError(c.Set(key, "value", cache.OneMinutes))
+	ttl, err := c.TTL(key)
+	is.NoError(err)
+	is.Gt(ttl, cache.Seconds30)
+
+	is.NoError(c.Persist(key))
+	ttl, err = c.TTL(key)
+	is.NoError(err)
+	is.Eq(time.Duration(cache.Forever), ttl)
+	is.Eq("value", c.Get(key))
+}
+
+func TestGoCache_WithContext(t *testing.T) {
+	is := assert.New(t)
+	c := gocache.NewSimple()
+	defer c.Clear()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cc := c.WithContext(ctx)
+	is.NoError(cc.Set("key", "value", cache.Seconds3))
+	is.Eq("value", c.Get("key"))
+
+	cancel()
+	is.Nil(cc.Get("key"))
+	is.ErrIs(cc.Del("key"), context.Canceled)
+	is.Eq("value", c.Get("key"))
+}
+
+func TestGoCache_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		return gocache.NewSimple()
+	})
+}
@@ -5,9 +5,14 @@ package goredis
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gookit/cache"
+	"github.com/gookit/cache/internal/rscript"
 	"github.com/gookit/gsr"
 	"github.com/redis/go-redis/v9"
 )
@@ -15,7 +20,9 @@ import (
 // Name driver name
 const Name = "goredis"
 
-// CtxForExec default ctx for exec command
+// CtxForExec default ctx for exec command.
+//
+// Deprecated: please use WithContext() for set the ctx of each operation.
 var CtxForExec = context.Background()
 
 // GoRedis struct
@@ -23,7 +30,6 @@ type GoRedis struct {
 	cache.BaseDriver
 	// client
 	rdb *redis.Client
-	ctx context.Context
 	// config
 	url   string
 	pwd   string
@@ -39,9 +45,10 @@ func Connect(url, pwd string, dbNum int) *GoRedis {
 func New(url, pwd string, dbNum int) *GoRedis {
 	rc := &GoRedis{
 		url: url, pwd: pwd, dbNum: dbNum,
-		ctx: CtxForExec,
 	}
 
+	rc.SetName(Name)
+	rc.SetContext(CtxForExec)
 	return rc
 }
 
@@ -62,7 +69,8 @@ func (c *GoRedis) Connect() *GoRedis {
 		Password: c.pwd,   // no password set
 		DB:       c.dbNum, // use default DB
 	})
-	c.Logf("connect to server %s db is %d", c.url, c.dbNum)
+	c.rdb.AddHook(debugHook{c: c})
+	c.Log(slog.LevelInfo, "connect to redis server", slog.String("url", c.url), slog.Int("db", c.dbNum))
 
 	return c
 }
@@ -71,10 +79,10 @@ func (c *GoRedis) Connect() *GoRedis {
  * methods implements of the gsr.SimpleCacher
  *************************************************************/
 
-// WithContext for operate
+// WithContext returns a copy of the driver for operate with ctx
 func (c *GoRedis) WithContext(ctx context.Context) gsr.ContextCacher {
 	cp := *c
-	cp.ctx = ctx
+	cp.SetContext(ctx)
 	return &cp
 }
 
@@ -83,16 +91,49 @@ func (c *GoRedis) Close() error {
 	return c.rdb.Close()
 }
 
-// Clear all caches
+// Clear all caches. if the prefix is set, will only delete the keys with the prefix.
 func (c *GoRedis) Clear() error {
-	return c.rdb.FlushDB(c.ctx).Err()
+	return c.clear(c.Key(""))
+}
+
+// ClearPrefix delete the caches which key has the prefix, the driver prefix will be added.
+func (c *GoRedis) ClearPrefix(prefix string) error {
+	return c.clear(c.Key(prefix))
+}
+
+// delete the keys by the real key prefix, will flush the db if prefix is empty.
+func (c *GoRedis) clear(prefix string) error {
+	ctx := c.Context()
+	if prefix == "" {
+		return c.rdb.FlushDB(ctx).Err()
+	}
+
+	iter := c.rdb.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	keys := make([]string, 0, 100)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == cap(keys) {
+			if err := c.rdb.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	if len(keys) > 0 {
+		return c.rdb.Del(ctx, keys...).Err()
+	}
+	return nil
 }
 
 // Has cache key
 func (c *GoRedis) Has(key string) bool {
-	n, err := c.rdb.Exists(c.ctx, c.Key(key)).Result()
+	n, err := c.rdb.Exists(c.Context(), c.Key(key)).Result()
 	if err != nil {
-		c.SetLastErr(err)
+		c.SetOpErr("Has", key, err)
 		return false
 	}
 
@@ -101,14 +142,21 @@ func (c *GoRedis) Has(key string) bool {
 
 // Get cache by key
 func (c *GoRedis) Get(key string) any {
-	bts, err := c.rdb.Get(c.ctx, c.Key(key)).Bytes()
+	bts, err := c.rdb.Get(c.Context(), c.Key(key)).Bytes()
+	if err != nil {
+		// not exists is not an error
+		if err != redis.Nil {
+			c.SetOpErr("Get", key, err)
+		}
+		return nil
+	}
 
-	return c.Unmarshal(bts, err)
+	return c.Unmarshal(bts, nil)
 }
 
 // GetAs get cache and unmarshal to ptr
 func (c *GoRedis) GetAs(key string, ptr any) error {
-	bts, err := c.rdb.Get(c.ctx, c.Key(key)).Bytes()
+	bts, err := c.rdb.Get(c.Context(), c.Key(key)).Bytes()
 	if err != nil {
 		return err
 	}
@@ -123,22 +171,49 @@ func (c *GoRedis) Set(key string, val any, ttl time.Duration) (err error) {
 		return err
 	}
 
-	return c.rdb.SetNX(c.ctx, c.Key(key), val, ttl).Err()
+	return c.rdb.Set(c.Context(), c.Key(key), val, ttl).Err()
 }
 
 // Del caches by key
 func (c *GoRedis) Del(key string) error {
-	return c.rdb.Del(c.ctx, c.Key(key)).Err()
+	return c.rdb.Del(c.Context(), c.Key(key)).Err()
 }
 
 // GetMulti cache by keys
 func (c *GoRedis) GetMulti(keys []string) map[string]any {
-	panic("implement me")
+	list, err := c.rdb.MGet(c.Context(), c.BuildKeys(keys)...).Result()
+	if err != nil {
+		c.SetOpErr("GetMulti", "", err)
+		return nil
+	}
+
+	values := make(map[string]any, len(keys))
+	for i, val := range list {
+		str, ok := val.(string)
+		if !ok { // not exists
+			continue
+		}
+
+		if val = c.Unmarshal([]byte(str), nil); val != nil {
+			values[keys[i]] = val
+		}
+	}
+	return values
 }
 
 // SetMulti cache by keys
 func (c *GoRedis) SetMulti(values map[string]any, ttl time.Duration) (err error) {
-	panic("implement me")
+	ctx := c.Context()
+	_, err = c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, val := range values {
+			if val, err = c.Marshal(val); err != nil {
+				return err
+			}
+			pipe.Set(ctx, c.Key(key), val, ttl)
+		}
+		return nil
+	})
+	return err
 }
 
 // DelMulti cache by keys
@@ -148,5 +223,300 @@ func (c *GoRedis) DelMulti(keys []string) error {
 		cks = append(cks, c.Key(key))
 	}
 
-	return c.rdb.Del(c.ctx, cks...).Err()
+	return c.rdb.Del(c.Context(), cks...).Err()
+}
+
+/*************************************************************
+ * methods implements of the cache.Counter
+ *************************************************************/
+
+// Incr increment the key value by 1
+func (c *GoRedis) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1
+func (c *GoRedis) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta.
+// the ttl is only used when the key is created.
+func (c *GoRedis) IncrBy(key string, delta int64, ttl ...time.Duration) (int64, error) {
+	key = c.Key(key)
+	t := cache.CreateTTL(ttl)
+	if t <= 0 {
+		return c.rdb.IncrBy(c.Context(), key, delta).Result()
+	}
+
+	var cmd *redis.IntCmd
+	_, err := c.rdb.TxPipelined(c.Context(), func(pipe redis.Pipeliner) error {
+		pipe.SetNX(c.Context(), key, 0, t)
+		cmd = pipe.IncrBy(c.Context(), key, delta)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cmd.Val(), nil
+}
+
+// IncrByFloat increment the key value by float delta.
+// the ttl is only used when the key is created.
+func (c *GoRedis) IncrByFloat(key string, delta float64, ttl ...time.Duration) (float64, error) {
+	key = c.Key(key)
+	t := cache.CreateTTL(ttl)
+	if t <= 0 {
+		return c.rdb.IncrByFloat(c.Context(), key, delta).Result()
+	}
+
+	var cmd *redis.FloatCmd
+	_, err := c.rdb.TxPipelined(c.Context(), func(pipe redis.Pipeliner) error {
+		pipe.SetNX(c.Context(), key, 0, t)
+		cmd = pipe.IncrByFloat(c.Context(), key, delta)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cmd.Val(), nil
+}
+
+/*************************************************************
+ * methods implements of the cache.ConditionalSetter
+ *************************************************************/
+
+// compare and swap script, see rscript.CompareAndSwap
+var casScript = redis.NewScript(rscript.CompareAndSwap)
+
+// compare and delete script, see rscript.CompareAndDelete
+var cadScript = redis.NewScript(rscript.CompareAndDelete)
+
+// Add set the key value only if the key does not exist
+func (c *GoRedis) Add(key string, val any, ttl time.Duration) (bool, error) {
+	val, err := c.Marshal(val)
+	if err != nil {
+		return false, err
+	}
+	return c.rdb.SetNX(c.Context(), c.Key(key), val, ttl).Result()
+}
+
+// Replace set the key value only if the key already exists
+func (c *GoRedis) Replace(key string, val any, ttl time.Duration) (bool, error) {
+	val, err := c.Marshal(val)
+	if err != nil {
+		return false, err
+	}
+	return c.rdb.SetXX(c.Context(), c.Key(key), val, ttl).Result()
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (c *GoRedis) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (bool, error) {
+	oldVal, err := c.Marshal(oldVal)
+	if err != nil {
+		return false, err
+	}
+	if newVal, err = c.Marshal(newVal); err != nil {
+		return false, err
+	}
+
+	n, err := casScript.Run(c.Context(), c.rdb, []string{c.Key(key)}, oldVal, newVal, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal
+func (c *GoRedis) CompareAndDelete(key string, oldVal any) (bool, error) {
+	oldVal, err := c.Marshal(oldVal)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := cadScript.Run(c.Context(), c.rdb, []string{c.Key(key)}, oldVal).Int()
+	return n == 1, err
+}
+
+/*************************************************************
+ * methods implements of the cache.TTLer
+ *************************************************************/
+
+// TTL get the remaining time to live of the key
+func (c *GoRedis) TTL(key string) (time.Duration, error) {
+	ttl, err := c.rdb.PTTL(c.Context(), c.Key(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	switch ttl {
+	case -2: // key not exists
+		return 0, cache.ErrNotFound
+	case -1: // key has no expiration
+		return cache.Forever, nil
+	}
+	return ttl, nil
+}
+
+// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration.
+func (c *GoRedis) Expire(key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return c.Persist(key)
+	}
+
+	ok, err := c.rdb.PExpire(c.Context(), c.Key(key), ttl).Result()
+	if err == nil && !ok {
+		return cache.ErrNotFound
+	}
+	return err
+}
+
+// Persist remove the key expiration
+func (c *GoRedis) Persist(key string) error {
+	ok, err := c.rdb.Persist(c.Context(), c.Key(key)).Result()
+	if err != nil || ok {
+		return err
+	}
+
+	// returns false if key not exists or has no expiration
+	if !c.Has(key) {
+		return cache.ErrNotFound
+	}
+	return nil
+}
+
+// Touch refresh the key expiration to ttl from now
+func (c *GoRedis) Touch(key string, ttl time.Duration) error {
+	if err := c.Expire(key, ttl); err != cache.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+/*************************************************************
+ * methods for pub/sub
+ *************************************************************/
+
+// Publish the message to the channel
+func (c *GoRedis) Publish(ctx context.Context, channel string, msg []byte) error {
+	return c.rdb.Publish(ctx, channel, msg).Err()
+}
+
+// Subscribe the channel and call the handler for each message. it blocks until the ctx is done.
+//
+// The broken connection will be reconnected and re-subscribed automatically,
+// the onSubscribed(can be nil) will be called after each (re)subscribed.
+func (c *GoRedis) Subscribe(ctx context.Context, channel string, handler func(msg []byte), onSubscribed func()) error {
+	ps := c.rdb.Subscribe(ctx, channel)
+	defer ps.Close()
+
+	ch := ps.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case v, ok := <-ch:
+			if !ok {
+				return nil
+			}
+
+			switch m := v.(type) {
+			case *redis.Subscription:
+				if m.Kind == "subscribe" && onSubscribed != nil {
+					onSubscribed()
+				}
+			case *redis.Message:
+				handler([]byte(m.Payload))
+			}
+		}
+	}
+}
+
+// debugHook log the elapsed time of the commands if the debug is enabled
+type debugHook struct {
+	c *GoRedis
+}
+
+// DialHook do nothing
+func (h debugHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook log the command
+func (h debugHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !h.c.DebugEnabled() {
+			return next(ctx, cmd)
+		}
+
+		st := time.Now()
+		err := next(ctx, cmd)
+
+		var key string
+		if args := cmd.Args(); len(args) > 1 {
+			key = fmt.Sprint(args[1])
+		}
+		h.c.DebugOp(cmd.Name(), key, st, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook log the pipeline
+func (h debugHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !h.c.DebugEnabled() {
+			return next(ctx, cmds)
+		}
+
+		st := time.Now()
+		err := next(ctx, cmds)
+		h.c.DebugOp("pipeline", "", st, err)
+		return err
+	}
+}
+
+/*************************************************************
+ * open by DSN url
+ *************************************************************/
+
+func init() {
+	cache.RegisterFactory(Name, openURL)
+}
+
+// open by the DSN url. eg: "goredis://:pwd@127.0.0.1:6379/2?prefix=app:&pool=20"
+func openURL(u *url.URL) (cache.Cache, error) {
+	addr := u.Host
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+
+	var pwd string
+	if u.User != nil {
+		pwd, _ = u.User.Password()
+	}
+
+	var dbNum int
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		var err error
+		if dbNum, err = strconv.Atoi(db); err != nil {
+			return nil, &cache.ConfigError{Field: "db", Err: fmt.Errorf("invalid db number %q", db)}
+		}
+	}
+
+	pool, err := cache.URLInt(u, "pool", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	optFns, err := cache.URLOptions(u)
+	if err != nil {
+		return nil, err
+	}
+
+	c := New(addr, pwd, dbNum)
+	c.WithOptions(optFns...)
+	c.rdb = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: pwd,
+		DB:       dbNum,
+		PoolSize: pool,
+	})
+	return c, nil
 }
@@ -3,6 +3,8 @@ package cache
 import (
 	"bytes"
 	"encoding/gob"
+	"reflect"
+	"time"
 )
 
 // BindStruct get cache value and map to a struct
@@ -29,3 +31,27 @@ func GobEncode(val any) (bs []byte, err error) {
 
 	return buf.Bytes(), nil
 }
+
+// CreateTTL get the ttl for create new key from optional ttl args.
+func CreateTTL(ttl []time.Duration) time.Duration {
+	if len(ttl) > 0 && ttl[0] > 0 {
+		return ttl[0]
+	}
+	return Forever
+}
+
+// EqualValue check two cache values is equals.
+// will compare the marshaled data if they are not deep equal. eg: int(1) and float64(1)
+func EqualValue(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	if Marshal == nil {
+		return false
+	}
+
+	bs1, err1 := Marshal(a)
+	bs2, err2 := Marshal(b)
+	return err1 == nil && err2 == nil && bytes.Equal(bs1, bs2)
+}
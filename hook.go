@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Result of a cache operation
+type Result uint8
+
+// results of the operation
+const (
+	// ResultOK the operation is succeeded, and it has no hit info. eg: Set, Del
+	ResultOK Result = iota
+	// ResultHit the key is found on read
+	ResultHit
+	// ResultMiss the key is not found on read
+	ResultMiss
+	// ResultError the operation is failed
+	ResultError
+)
+
+// String get result name
+func (r Result) String() string {
+	switch r {
+	case ResultOK:
+		return "ok"
+	case ResultHit:
+		return "hit"
+	case ResultMiss:
+		return "miss"
+	case ResultError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Operation the info of a cache operation for the hooks
+type Operation struct {
+	// Name of the operation. eg: "Get", "SetMulti", "Incr"
+	Name string
+	// Driver name
+	Driver string
+	// Keys of the operation, it is empty for Clear.
+	Keys []string
+	// Start time of the operation
+	Start time.Time
+
+	// the fields below are set after the operation
+
+	// Result of the operation. the multi read is hit only if all keys are found.
+	Result Result
+	// Hits the number of the keys found on read
+	Hits int
+	// Err of the operation
+	Err error
+	// Duration of the operation
+	Duration time.Duration
+}
+
+// Hook interface definition. for observe the cache operations. eg: tracing, logging
+type Hook interface {
+	// BeforeOp called before the operation, the returned ctx will be passed to the driver
+	// if it implements ContextCacher. eg: the ctx with a span
+	BeforeOp(ctx context.Context, op *Operation) context.Context
+	// AfterOp called after the operation with the ctx returned by BeforeOp.
+	// the hooks are called in reverse order of BeforeOp.
+	AfterOp(ctx context.Context, op *Operation)
+}
+
+// HookFunc the hook only called after the operation
+type HookFunc func(ctx context.Context, op *Operation)
+
+// BeforeOp returns the ctx as is
+func (fn HookFunc) BeforeOp(ctx context.Context, _ *Operation) context.Context {
+	return ctx
+}
+
+// AfterOp call the func
+func (fn HookFunc) AfterOp(ctx context.Context, op *Operation) {
+	fn(ctx, op)
+}
+
+// WithHooks wraps the driver with hooks by Wrap(), the driverName will be set to Operation.Driver
+//
+// The returned cache implements the optional interfaces only if the driver implements them, see Wrap().
+//
+// Usage:
+//
+//	c := cache.WithHooks(rds, "redis", cache.TracingHook(tracer))
+func WithHooks(c Cache, driverName string, hooks ...Hook) Cache {
+	return Wrap(c, HooksInterceptor(driverName, hooks...))
+}
+
+// HooksInterceptor create an interceptor for call the hooks around each operation.
+func HooksInterceptor(driverName string, hooks ...Hook) Interceptor {
+	return func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+		op := &Operation{Name: call.Op, Driver: driverName, Keys: call.Keys, Start: time.Now()}
+		ctxs := make([]context.Context, len(hooks))
+		for i, hook := range hooks {
+			ctx = hook.BeforeOp(ctx, op)
+			ctxs[i] = ctx
+		}
+
+		err := next(ctx)
+		op.Duration = time.Since(op.Start)
+		if err != nil {
+			op.Err = err
+			if errors.Is(err, ErrNotFound) {
+				op.Result = ResultMiss
+			} else {
+				op.Result = ResultError
+			}
+		} else if call.IsRead() {
+			op.hit(call.Hits())
+		}
+
+		for i := len(hooks) - 1; i >= 0; i-- {
+			hooks[i].AfterOp(ctxs[i], op)
+		}
+		return err
+	}
+}
+
+// set the read result of the op
+func (op *Operation) hit(found int) {
+	op.Hits = found
+	if found > 0 && found == len(op.Keys) {
+		op.Result = ResultHit
+	} else {
+		op.Result = ResultMiss
+	}
+}
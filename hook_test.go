@@ -0,0 +1,233 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/cache/lock"
+	"github.com/gookit/goutil/testutil/assert"
+	"github.com/gookit/gsr"
+)
+
+type spanNameKey struct{}
+
+// a tracer record the spans
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+type testSpan struct {
+	name  string
+	attrs map[string]any
+	err   error
+	ended bool
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, cache.Span) {
+	span := &testSpan{name: name, attrs: map[string]any{}}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return context.WithValue(ctx, spanNameKey{}, name), span
+}
+
+func (s *testSpan) SetAttribute(key string, val any) { s.attrs[key] = val }
+func (s *testSpan) RecordError(err error)            { s.err = err }
+func (s *testSpan) End()                             { s.ended = true }
+
+func ExampleTracingHook() {
+	tracer := &testTracer{}
+	m := cache.NewManager()
+	m.Register(cache.DvrMemory, cache.NewMemoryCache())
+	m.UseHook(cache.TracingHook(tracer))
+
+	_ = m.Set("key", "value", 0)
+	_ = m.Get("key")
+
+	for _, span := range tracer.spans {
+		fmt.Println(span.name, span.attrs[cache.AttrDriver], span.attrs[cache.AttrResult])
+	}
+
+	// Output:
+	// cache.Set memory ok
+	// cache.Get memory hit
+}
+
+func TestHookedCache_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		return cache.WithHooks(cache.NewMemoryCache(), cache.DvrMemory, cache.HookFunc(func(context.Context, *cache.Operation) {}))
+	}, cachetest.WithTTLPrecision(time.Second))
+}
+
+// a driver record the ctx of operations
+type ctxDriver struct {
+	cache.Cache
+	ctx context.Context
+	got *[]any
+}
+
+func (d *ctxDriver) WithContext(ctx context.Context) gsr.ContextCacher {
+	return &ctxDriver{Cache: d.Cache, ctx: ctx, got: d.got}
+}
+
+func (d *ctxDriver) Get(key string) any {
+	if d.ctx != nil {
+		*d.got = append(*d.got, d.ctx.Value(spanNameKey{}))
+	}
+	return d.Cache.Get(key)
+}
+
+func TestHookedCache(t *testing.T) {
+	is := assert.New(t)
+	var calls []string
+	var ops []cache.Operation
+	hook := func(name string) cache.Hook {
+		return &orderHook{name: name, calls: &calls}
+	}
+
+	mc := cache.NewMemoryCache()
+	c := cache.WithHooks(mc, "mem", hook("h1"), hook("h2"), cache.HookFunc(func(_ context.Context, op *cache.Operation) {
+		ops = append(ops, *op)
+	}))
+	is.Eq(mc, cache.Unwrap(c))
+
+	is.NoErr(c.Set("key", "value", 0))
+	is.Eq([]string{"h1.before", "h2.before", "h2.after", "h1.after"}, calls)
+
+	is.Eq("value", c.Get("key"))
+	is.Nil(c.Get("not-exist"))
+	is.NoErr(c.SetMulti(map[string]any{"k1": 1, "k2": 2}, 0))
+	c.GetMulti([]string{"k1", "k2", "k3"})
+	is.NoErr(c.DelMulti([]string{"k1"}))
+
+	is.Len(ops, 6)
+	is.Eq("Set", ops[0].Name)
+	is.Eq("mem", ops[0].Driver)
+	is.Eq([]string{"key"}, ops[0].Keys)
+	is.Eq(cache.ResultOK, ops[0].Result)
+	is.Eq(cache.ResultHit, ops[1].Result)
+	is.Eq(cache.ResultMiss, ops[2].Result)
+	is.Eq("miss", ops[2].Result.String())
+	is.Eq(cache.ResultMiss, ops[4].Result)
+	is.Eq(2, ops[4].Hits)
+	is.False(ops[4].Start.IsZero())
+
+	// the optional interfaces
+	n, err := c.(cache.Counter).Incr("counter")
+	is.NoErr(err)
+	is.Eq(int64(1), n)
+	ok, err := c.(cache.ConditionalSetter).Add("key", "new", 0)
+	is.NoErr(err)
+	is.False(ok)
+	ok, err = c.(cache.CompareDeleter).CompareAndDelete("key", "value")
+	is.NoErr(err)
+	is.True(ok)
+	_, err = c.(cache.TTLer).TTL("key")
+	is.ErrIs(err, cache.ErrNotFound)
+	is.Eq(cache.ResultMiss, ops[len(ops)-1].Result)
+	is.NoErr(c.(cache.TTLer).Expire("k2", time.Minute))
+	_, err = c.(cache.TTLer).TTL("k2")
+	is.NoErr(err)
+	is.Eq(cache.ResultHit, ops[len(ops)-1].Result)
+	is.NoErr(c.(cache.PrefixClearer).ClearPrefix("coun"))
+	is.False(mc.Has("counter"))
+	is.True(c.Has("k2"))
+	is.Eq(cache.ResultHit, ops[len(ops)-1].Result)
+
+	// only the interfaces of the driver are implemented
+	nc := cache.WithHooks(&ctxDriver{Cache: mc}, "ctx")
+	_, ok = nc.(cache.Counter)
+	is.False(ok)
+	_, ok = nc.(cache.TTLer)
+	is.False(ok)
+	_, ok = nc.(cache.PrefixClearer)
+	is.False(ok)
+	is.NoErr(nc.Clear())
+	is.NoErr(nc.Close())
+}
+
+// a hook record the call order
+type orderHook struct {
+	name  string
+	calls *[]string
+}
+
+func (h *orderHook) BeforeOp(ctx context.Context, _ *cache.Operation) context.Context {
+	*h.calls = append(*h.calls, h.name+".before")
+	return ctx
+}
+
+func (h *orderHook) AfterOp(_ context.Context, _ *cache.Operation) {
+	*h.calls = append(*h.calls, h.name+".after")
+}
+
+func TestTracingHook(t *testing.T) {
+	is := assert.New(t)
+	tracer := &testTracer{}
+	var got []any
+	d := &ctxDriver{Cache: cache.NewMemoryCache(), got: &got}
+	c := cache.WithHooks(d, "ctx", cache.TracingHook(tracer))
+
+	// the ctx annotated by hooks is passed to the driver
+	is.Nil(c.Get("key"))
+	is.Eq([]any{"cache.Get"}, got)
+
+	errFail := errors.New("failed")
+	fc := cache.WithHooks(&closeDriver{Cache: cache.NewMemoryCache(), err: errFail}, "fail", cache.TracingHook(tracer))
+	is.ErrIs(fc.Clear(), errFail)
+	cc := cache.WithContext(c, context.Background())
+	cc.GetMulti([]string{"k1", "k2"})
+
+	is.Len(tracer.spans, 3)
+	span := tracer.spans[0]
+	is.True(span.ended)
+	is.Eq("key", span.attrs[cache.AttrKey])
+	is.Eq("miss", span.attrs[cache.AttrResult])
+	is.Nil(span.err)
+
+	span = tracer.spans[1]
+	is.Eq("cache.Clear", span.name)
+	is.Eq("error", span.attrs[cache.AttrResult])
+	is.ErrIs(span.err, errFail)
+
+	span = tracer.spans[2]
+	is.Eq(2, span.attrs[cache.AttrKeysCount])
+	is.Eq(0, span.attrs[cache.AttrHits])
+}
+
+func TestManager_UseHook(t *testing.T) {
+	is := assert.New(t)
+	m := cache.NewManager()
+	mc := cache.NewMemoryCache()
+	m.Register(cache.DvrMemory, mc)
+
+	var names []string
+	m.UseHook(cache.HookFunc(func(_ context.Context, op *cache.Operation) {
+		names = append(names, op.Driver+"."+op.Name+":"+strings.Join(op.Keys, ","))
+	}))
+
+	is.NoErr(m.Set("key", "value", 0))
+	ns := m.Namespace("ns")
+	_, err := ns.Incr("num")
+	is.NoErr(err)
+	is.True(m.Driver(cache.DvrMemory).Has("key"))
+	is.Eq([]string{"memory.Set:key", "memory.IncrBy:ns:num", "memory.Has:key"}, names)
+
+	// the registered driver is not changed
+	c, err := m.Lookup(cache.DvrMemory)
+	is.NoErr(err)
+	is.Eq(mc, cache.Unwrap(c))
+	_, err = lock.New(c)
+	is.NoErr(err)
+	_, err = lock.New(cache.WithHooks(&ctxDriver{Cache: mc}, "ctx"))
+	is.ErrIs(err, lock.ErrNotSupported)
+	is.NoErr(m.Close())
+}
@@ -0,0 +1,60 @@
+// Package expiry provide the expire time header codec, for the drivers without native TTL.
+//
+// The encoded data layout:
+//
+//	| 8 bytes big endian expire unix nano, 0 is never expired | value bytes |
+package expiry
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// HeaderLen the expire header length
+const HeaderLen = 8
+
+// ErrInvalidData the data is too short to contain the expire header
+var ErrInvalidData = errors.New("cache: invalid data, missing expire header")
+
+// At get the expire unix nano by ttl. returns 0 if ttl <= 0
+func At(ttl time.Duration) int64 {
+	if ttl > 0 {
+		return time.Now().Add(ttl).UnixNano()
+	}
+	return 0
+}
+
+// Expired check the expire unix nano is expired
+func Expired(exp int64) bool {
+	return exp > 0 && exp <= time.Now().UnixNano()
+}
+
+// Remaining get the remaining ttl of the expire unix nano. returns 0 if it is never expired.
+func Remaining(exp int64) time.Duration {
+	if exp <= 0 {
+		return 0
+	}
+
+	// at least 1ms, the key is still exists
+	if ttl := time.Until(time.Unix(0, exp)); ttl > time.Millisecond {
+		return ttl
+	}
+	return time.Millisecond
+}
+
+// Encode the value with the expire header
+func Encode(val []byte, exp int64) []byte {
+	bs := make([]byte, HeaderLen+len(val))
+	binary.BigEndian.PutUint64(bs, uint64(exp))
+	copy(bs[HeaderLen:], val)
+	return bs
+}
+
+// Decode the data to value and expire unix nano. the returned value shares the data memory.
+func Decode(data []byte) (val []byte, exp int64, err error) {
+	if len(data) < HeaderLen {
+		return nil, 0, ErrInvalidData
+	}
+	return data[HeaderLen:], int64(binary.BigEndian.Uint64(data)), nil
+}
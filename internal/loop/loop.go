@@ -0,0 +1,68 @@
+// Package loop provide a runner for run a background task periodically.
+package loop
+
+import (
+	"sync"
+	"time"
+)
+
+// Runner run a func periodically in a goroutine. the zero value is ready to use.
+type Runner struct {
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Start run the fn every interval. if it is running, will be restarted with the new settings.
+// interval <= 0 will only stop the running task.
+func (r *Runner) Start(interval time.Duration, fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopLocked()
+	if interval <= 0 {
+		return
+	}
+
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go run(interval, fn, r.stop, r.done)
+}
+
+// Stop the running task, and wait it exited.
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopLocked()
+}
+
+// Running check the task is running
+func (r *Runner) Running() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stop != nil
+}
+
+func (r *Runner) stopLocked() {
+	if r.stop != nil {
+		close(r.stop)
+		<-r.done
+		r.stop, r.done = nil, nil
+	}
+}
+
+func run(interval time.Duration, fn func(), stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
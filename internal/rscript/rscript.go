@@ -0,0 +1,31 @@
+// Package rscript provide the lua scripts shared by the redis drivers.
+package rscript
+
+// CompareAndSwap script. set the key value to new value only if the current value is equals to old value.
+//
+// KEYS[1]: key, ARGV[1]: old value, ARGV[2]: new value, ARGV[3]: ttl milliseconds
+//
+// returns 1 on swapped, otherwise returns 0.
+const CompareAndSwap = `
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+else
+	redis.call('SET', KEYS[1], ARGV[2])
+end
+return 1
+`
+
+// CompareAndDelete script. delete the key only if the current value is equals to old value.
+//
+// KEYS[1]: key, ARGV[1]: old value
+//
+// returns 1 on deleted, otherwise returns 0.
+const CompareAndDelete = `
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+return redis.call('DEL', KEYS[1])
+`
@@ -0,0 +1,378 @@
+// Package invalidate provide a cross-instance invalidation bus for the local caches.
+//
+// With an in-process cache(eg: cache.MemoryCache) in front of the remote cache, a write on one
+// instance leaves the stale local entries on other instances. The Bus publishes the written keys
+// through a Transport, other instances subscribe them and evict from their local caches.
+//
+// The goredis.GoRedis and redis.Redigo drivers can be used as Transport by redis pub/sub.
+//
+// Usage:
+//
+//	rds := goredis.Connect("127.0.0.1:6379", "", 0)
+//	l1 := cache.NewMemoryCache()
+//
+//	bus := invalidate.New(l1, rds)
+//	if err := bus.Start(ctx); err != nil {
+//		panic(err)
+//	}
+//	defer bus.Close()
+//
+//	// the writes on c will publish invalidations to other instances
+//	c := bus.Wrap(tiered.New(l1, rds))
+package invalidate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gookit/cache"
+)
+
+// default options
+const (
+	// DefaultChannel default channel name for publish the invalidations
+	DefaultChannel = "gookit:cache:invalidate"
+	// DefaultBatchSize default max keys number of a message
+	DefaultBatchSize = 100
+	// DefaultBatchWindow default max wait time for collect the keys into a message
+	DefaultBatchWindow = 10 * time.Millisecond
+)
+
+// errors for the bus
+var (
+	ErrStarted = errors.New("invalidate: the bus is already started")
+	ErrStopped = errors.New("invalidate: the subscription is stopped")
+)
+
+// Transport publish and subscribe the invalidation messages. eg: redis pub/sub
+type Transport interface {
+	// Publish the message to the channel
+	Publish(ctx context.Context, channel string, msg []byte) error
+	// Subscribe the channel and call the handler for each message, it blocks until the ctx is done.
+	// the onSubscribed should be called after each (re)subscribed.
+	Subscribe(ctx context.Context, channel string, handler func(msg []byte), onSubscribed func()) error
+}
+
+// Options for the Bus
+type Options struct {
+	// Channel name for publish the invalidations. default is DefaultChannel
+	Channel string
+	// BatchSize the max keys number of a message. default is DefaultBatchSize
+	BatchSize int
+	// BatchWindow the max wait time for collect the keys into a message. default is DefaultBatchWindow
+	BatchWindow time.Duration
+	// OnError handle the errors on publish and evict. default will ignore them.
+	OnError func(err error)
+}
+
+// WithChannel set the channel name
+func WithChannel(channel string) func(opt *Options) {
+	return func(opt *Options) {
+		opt.Channel = channel
+	}
+}
+
+// WithBatch set the max keys number and the max wait time of a message
+func WithBatch(size int, window time.Duration) func(opt *Options) {
+	return func(opt *Options) {
+		opt.BatchSize = size
+		opt.BatchWindow = window
+	}
+}
+
+// WithErrorHandler set the error handler
+func WithErrorHandler(fn func(err error)) func(opt *Options) {
+	return func(opt *Options) {
+		opt.OnError = fn
+	}
+}
+
+// the invalidation message
+type message struct {
+	// Source the bus id of the publisher
+	Source string   `json:"src"`
+	Keys   []string `json:"keys,omitempty"`
+	// All will clear the whole local cache
+	All bool `json:"all,omitempty"`
+}
+
+// Bus the invalidation bus
+type Bus struct {
+	local     cache.Cache
+	transport Transport
+	opt       Options
+	// unique id of the bus, for suppress the self messages
+	id string
+
+	mu      sync.Mutex
+	pending []string
+	timer   *time.Timer
+	// for stop the subscription
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New create an invalidation bus. the local is the cache will be evicted by the messages from other instances.
+func New(local cache.Cache, transport Transport, optFns ...func(opt *Options)) *Bus {
+	opt := Options{
+		Channel:     DefaultChannel,
+		BatchSize:   DefaultBatchSize,
+		BatchWindow: DefaultBatchWindow,
+	}
+	for _, fn := range optFns {
+		fn(&opt)
+	}
+
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = DefaultBatchSize
+	}
+	if opt.BatchWindow <= 0 {
+		opt.BatchWindow = DefaultBatchWindow
+	}
+
+	return &Bus{
+		local:     local,
+		transport: transport,
+		opt:       opt,
+		id:        newID(),
+	}
+}
+
+// ID get the unique id of the bus
+func (b *Bus) ID() string {
+	return b.id
+}
+
+// Start subscribe the channel in background. it will wait until the first subscription is completed.
+//
+// On the subscription is re-established(eg: after reconnect), the whole local cache will be cleared,
+// because the messages published during the disconnection are lost.
+func (b *Bus) Start(ctx context.Context) error {
+	b.mu.Lock()
+	if b.cancel != nil {
+		b.mu.Unlock()
+		return ErrStarted
+	}
+
+	subCtx, cancel := context.WithCancel(context.Background())
+	b.cancel, b.done = cancel, make(chan struct{})
+	b.mu.Unlock()
+
+	var once sync.Once
+	var subErr error
+	ready := make(chan struct{})
+
+	go func() {
+		defer close(b.done)
+		subErr = b.transport.Subscribe(subCtx, b.opt.Channel, b.handle, func() {
+			first := false
+			once.Do(func() {
+				first = true
+				close(ready)
+			})
+
+			if !first {
+				b.onError(b.local.Clear())
+			}
+		})
+	}()
+
+	select {
+	case <-ready:
+		return nil
+	case <-b.done:
+		if subErr != nil {
+			return subErr
+		}
+		return ErrStopped
+	case <-ctx.Done():
+		b.stop()
+		return ctx.Err()
+	}
+}
+
+// Invalidate publish the keys to other instances, the keys will be collected into batches.
+//
+// NOTICE: the local cache of current instance will not be changed.
+func (b *Bus) Invalidate(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, keys...)
+	if len(b.pending) < b.opt.BatchSize {
+		if b.timer == nil {
+			b.timer = time.AfterFunc(b.opt.BatchWindow, func() {
+				b.onError(b.Flush())
+			})
+		}
+		b.mu.Unlock()
+		return
+	}
+	b.mu.Unlock()
+
+	b.onError(b.Flush())
+}
+
+// InvalidateAll publish a message to clear the whole local caches of other instances.
+func (b *Bus) InvalidateAll() error {
+	return b.publish(&message{Source: b.id, All: true})
+}
+
+// Flush publish the pending keys immediately
+func (b *Bus) Flush() error {
+	b.mu.Lock()
+	keys := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	keys = unique(keys)
+	var errs []error
+	for start := 0; start < len(keys); start += b.opt.BatchSize {
+		end := min(start+b.opt.BatchSize, len(keys))
+		if err := b.publish(&message{Source: b.id, Keys: keys[start:end]}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close flush the pending keys and stop the subscription
+func (b *Bus) Close() error {
+	err := b.Flush()
+	b.stop()
+	return err
+}
+
+// Wrap the cache, the writes on the returned cache will publish the invalidations.
+//
+//   - Set, Del, SetMulti, DelMulti will publish the keys by Invalidate()
+//   - Clear will publish a message to clear the whole local caches by InvalidateAll()
+func (b *Bus) Wrap(c cache.Cache) cache.Cache {
+	return &wrapper{Cache: c, bus: b}
+}
+
+// stop the subscription and wait it exited
+func (b *Bus) stop() {
+	b.mu.Lock()
+	cancel, done := b.cancel, b.done
+	b.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// handle the message from the transport
+func (b *Bus) handle(data []byte) {
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		b.onError(err)
+		return
+	}
+
+	// suppress the self messages
+	if msg.Source == b.id {
+		return
+	}
+
+	if msg.All {
+		b.onError(b.local.Clear())
+	} else if len(msg.Keys) > 0 {
+		b.onError(b.local.DelMulti(msg.Keys))
+	}
+}
+
+func (b *Bus) publish(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.transport.Publish(context.Background(), b.opt.Channel, data)
+}
+
+func (b *Bus) onError(err error) {
+	if err != nil && b.opt.OnError != nil {
+		b.opt.OnError(err)
+	}
+}
+
+// wrapper the cache for publish the invalidations on writes
+type wrapper struct {
+	cache.Cache
+	bus *Bus
+}
+
+// Set value by key, and publish the key invalidation
+func (w *wrapper) Set(key string, val any, ttl time.Duration) error {
+	err := w.Cache.Set(key, val, ttl)
+	w.bus.Invalidate(key)
+	return err
+}
+
+// Del value by key, and publish the key invalidation
+func (w *wrapper) Del(key string) error {
+	err := w.Cache.Del(key)
+	w.bus.Invalidate(key)
+	return err
+}
+
+// SetMulti values, and publish the keys invalidation
+func (w *wrapper) SetMulti(values map[string]any, ttl time.Duration) error {
+	err := w.Cache.SetMulti(values, ttl)
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	w.bus.Invalidate(keys...)
+	return err
+}
+
+// DelMulti values by keys, and publish the keys invalidation
+func (w *wrapper) DelMulti(keys []string) error {
+	err := w.Cache.DelMulti(keys)
+	w.bus.Invalidate(keys...)
+	return err
+}
+
+// Clear all caches, and publish a message to clear the whole local caches
+func (w *wrapper) Clear() error {
+	if err := w.Cache.Clear(); err != nil {
+		return err
+	}
+	return w.bus.InvalidateAll()
+}
+
+func unique(keys []string) []string {
+	seen := make(map[string]struct{}, len(keys))
+	list := keys[:0]
+	for _, key := range keys {
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			list = append(list, key)
+		}
+	}
+	return list
+}
+
+func newID() string {
+	bts := make([]byte, 8)
+	_, _ = rand.Read(bts)
+	return hex.EncodeToString(bts)
+}
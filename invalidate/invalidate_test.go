@@ -0,0 +1,158 @@
+package invalidate_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest/fakeserver"
+	"github.com/gookit/cache/goredis"
+	"github.com/gookit/cache/invalidate"
+	"github.com/gookit/cache/redis"
+	"github.com/gookit/cache/tiered"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+var srv *fakeserver.Redis
+
+func TestMain(m *testing.M) {
+	var err error
+	if srv, err = fakeserver.NewRedis(); err != nil {
+		panic(err)
+	}
+
+	redis.SubscribeRetryInterval = 10 * time.Millisecond
+	code := m.Run()
+	_ = srv.Close()
+	os.Exit(code)
+}
+
+// the remote cache and transport
+type remote interface {
+	cache.Cache
+	invalidate.Transport
+}
+
+func TestBus_goredis(t *testing.T) {
+	testBus(t, func() remote {
+		c := goredis.Connect(srv.Addr(), "", 0)
+		c.WithOptions(cache.WithEncode(true))
+		return c
+	})
+}
+
+func TestBus_redigo(t *testing.T) {
+	testBus(t, func() remote {
+		c := redis.Connect(srv.Addr(), "", 0)
+		c.WithOptions(cache.WithEncode(true))
+		return c
+	})
+}
+
+// an application instance
+type instance struct {
+	l1  cache.Cache
+	bus *invalidate.Bus
+	c   cache.Cache
+}
+
+func newInstance(t *testing.T, connect func() remote) *instance {
+	rds := connect()
+	l1 := cache.NewMemoryCache()
+	bus := invalidate.New(l1, rds, invalidate.WithErrorHandler(func(err error) {
+		t.Errorf("bus error: %v", err)
+	}))
+	assert.NoErr(t, bus.Start(context.Background()))
+	assert.ErrIs(t, bus.Start(context.Background()), invalidate.ErrStarted)
+
+	t.Cleanup(func() {
+		assert.NoErr(t, bus.Close())
+		assert.NoErr(t, rds.Close())
+	})
+	return &instance{l1: l1, bus: bus, c: bus.Wrap(tiered.New(l1, rds))}
+}
+
+func testBus(t *testing.T, connect func() remote) {
+	srv.FlushAll()
+	a, b := newInstance(t, connect), newInstance(t, connect)
+	is := assert.New(t)
+	is.NotEq(a.bus.ID(), b.bus.ID())
+
+	// b cached the value in l1
+	is.NoErr(a.c.Set("key", "v1", 0))
+	is.Eq("v1", b.c.Get("key"))
+	is.True(b.l1.Has("key"))
+
+	// write on a, the l1 of b will be evicted
+	is.NoErr(a.c.Set("key", "v2", 0))
+	eventually(t, func() bool { return !b.l1.Has("key") })
+	is.Eq("v2", b.c.Get("key"))
+	// the self message is suppressed
+	is.True(a.l1.Has("key"))
+
+	// multi keys in batch
+	is.NoErr(b.c.SetMulti(map[string]any{"k1": 1, "k2": 2}, 0))
+	is.Len(a.c.GetMulti([]string{"k1", "k2"}), 2)
+	is.NoErr(b.c.DelMulti([]string{"k1", "k2"}))
+	eventually(t, func() bool { return !a.l1.Has("k1") && !a.l1.Has("k2") })
+
+	// clear all
+	is.True(a.c.Get("key") != nil && b.c.Get("key") != nil, "should cached in both l1")
+	is.NoErr(a.c.Clear())
+	eventually(t, func() bool { return !b.l1.Has("key") })
+
+	// the l1 will be flushed on re-subscribed
+	is.NoErr(b.l1.Set("local", "value", 0))
+	srv.CloseClients()
+	eventually(t, func() bool { return !b.l1.Has("local") })
+}
+
+func TestBus_flush(t *testing.T) {
+	is := assert.New(t)
+	rds := goredis.Connect(srv.Addr(), "", 0)
+	defer rds.Close()
+
+	received := make(chan []byte, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_ = rds.Subscribe(ctx, "test-channel", func(msg []byte) { received <- msg }, nil)
+	}()
+	defer cancel()
+
+	bus := invalidate.New(cache.NewMemoryCache(), rds, invalidate.WithChannel("test-channel"), invalidate.WithBatch(2, time.Hour))
+	time.Sleep(50 * time.Millisecond) // wait subscribed
+
+	// publish on reach the batch size
+	bus.Invalidate("a", "b", "b")
+	is.Eq(`{"src":"`+bus.ID()+`","keys":["a","b"]}`, string(waitMsg(t, received)))
+
+	// publish on flush
+	bus.Invalidate("c")
+	is.NoErr(bus.Flush())
+	is.Eq(`{"src":"`+bus.ID()+`","keys":["c"]}`, string(waitMsg(t, received)))
+	is.NoErr(bus.Close())
+}
+
+func waitMsg(t *testing.T, ch chan []byte) []byte {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("wait message timeout")
+		return nil
+	}
+}
+
+func eventually(t *testing.T, fn func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -1,53 +1,607 @@
 // Package leveldb use the https://github.com/syndtr/goleveldb as cache driver
+//
+// LevelDB has no native TTL, so each value is stored with an expire time header.
+// the expired keys are deleted lazily on read, and by a background sweep goroutine.
 package leveldb
 
 import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
 	"time"
 
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/internal/expiry"
+	"github.com/gookit/cache/internal/loop"
+	"github.com/gookit/goutil/mathutil"
+	"github.com/gookit/gsr"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // Name driver name
 const Name = "leveldb"
 
+// DefaultSweepInterval the default interval for delete the expired keys
+const DefaultSweepInterval = time.Minute
+
+// the key exists but expired
+var errExpired = errors.New("leveldb: key expired")
+
 // LevelDB definition
 type LevelDB struct {
-	// cache.BaseDriver
+	cache.BaseDriver
 	db *leveldb.DB
+	// lock for the read-modify-write operations
+	lock *sync.Mutex
+	// expired keys sweep runner
+	sweeper *loop.Runner
+	// readonly mode
+	readonly bool
+}
+
+// New open a LevelDB instance by the db dir path.
+//
+// Usage:
+//
+//	c, err := leveldb.New("path/to/dir", cache.WithPrefix("app:"))
+func New(path string, optFns ...func(option *cache.Option)) (*LevelDB, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newLevelDB(db, false, optFns), nil
+}
+
+// NewReadOnly open a LevelDB instance by the db dir path in read-only mode.
+// all write operations will return the leveldb.ErrReadOnly
+func NewReadOnly(path string, optFns ...func(option *cache.Option)) (*LevelDB, error) {
+	db, err := leveldb.OpenFile(path, &opt.Options{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	return newLevelDB(db, true, optFns), nil
+}
+
+// NewMemory open a LevelDB instance on the memory storage, data will not persist to disk.
+// it is useful for tests.
+func NewMemory(optFns ...func(option *cache.Option)) (*LevelDB, error) {
+	return NewWithStorage(storage.NewMemStorage(), nil, optFns...)
+}
+
+// NewWithStorage open a LevelDB instance by custom storage and options
+func NewWithStorage(stor storage.Storage, o *opt.Options, optFns ...func(option *cache.Option)) (*LevelDB, error) {
+	db, err := leveldb.Open(stor, o)
+	if err != nil {
+		return nil, err
+	}
+	return newLevelDB(db, o.GetReadOnly(), optFns), nil
+}
+
+func newLevelDB(db *leveldb.DB, readonly bool, optFns []func(option *cache.Option)) *LevelDB {
+	c := &LevelDB{
+		db:       db,
+		lock:     new(sync.Mutex),
+		sweeper:  new(loop.Runner),
+		readonly: readonly,
+	}
+	c.SetName(Name)
+	c.WithOptions(optFns...)
+
+	if !readonly {
+		c.StartSweep(DefaultSweepInterval)
+	}
+	return c
+}
+
+// Db get the leveldb db
+func (c *LevelDB) Db() *leveldb.DB {
+	return c.db
+}
+
+// StartSweep start a goroutine for delete the expired keys periodically, it will be stopped by Close().
+// if the sweep goroutine is running, it will be restarted with new interval.
+func (c *LevelDB) StartSweep(interval time.Duration) {
+	c.sweeper.Start(interval, func() {
+		if err := c.Sweep(); err != nil {
+			c.LogErr("sweep expired keys failed", err)
+		}
+	})
+}
+
+// StopSweep stop the sweep goroutine, and wait it exited.
+func (c *LevelDB) StopSweep() {
+	c.sweeper.Stop()
+}
+
+// Sweep delete all expired keys with the prefix
+func (c *LevelDB) Sweep() error {
+	if c.readonly {
+		return leveldb.ErrReadOnly
+	}
+
+	var keys [][]byte
+	it := c.db.NewIterator(c.keyRange(""), nil)
+	for it.Next() {
+		if _, exp, err := expiry.Decode(it.Value()); err == nil && expiry.Expired(exp) {
+			keys = append(keys, append([]byte(nil), it.Key()...))
+		}
+	}
+
+	it.Release()
+	if err := it.Error(); err != nil || len(keys) == 0 {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// re-check it, the key maybe updated after iterate
+	batch := new(leveldb.Batch)
+	for _, key := range keys {
+		if _, _, err := c.load(key); err == errExpired {
+			batch.Delete(key)
+		}
+	}
+	return c.db.Write(batch, nil)
+}
+
+/*************************************************************
+ * methods implements of the gsr.SimpleCacher
+ *************************************************************/
+
+// WithContext returns a copy of the driver for operate with ctx.
+// the ctx is checked before each operation, and between the items of multi operations.
+func (c *LevelDB) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *c
+	cp.SetContext(ctx)
+	return &cp
 }
 
+// Has cache key
 func (c *LevelDB) Has(key string) bool {
-	panic("implement me")
+	if c.ContextErr() != nil {
+		return false
+	}
+
+	rk := []byte(c.Key(key))
+	_, _, err := c.load(rk)
+	if err == errExpired {
+		c.delExpired(rk)
+	}
+	return err == nil
 }
 
+// Get value by key
 func (c *LevelDB) Get(key string) any {
-	panic("implement me")
+	if err := c.ContextErr(); err != nil {
+		c.SetOpErr("Get", key, err)
+		return nil
+	}
+
+	rk := []byte(c.Key(key))
+	val, err := c.get(rk)
+	if err != nil {
+		if err == errExpired {
+			c.delExpired(rk)
+		} else if err != leveldb.ErrNotFound {
+			c.SetOpErr("Get", key, err)
+		}
+		return nil
+	}
+	return val
 }
 
+// Set value by key
 func (c *LevelDB) Set(key string, val any, ttl time.Duration) (err error) {
-	panic("implement me")
+	if err = c.ContextErr(); err != nil {
+		return err
+	}
+
+	bts, err := c.MustMarshal(val)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.db.Put([]byte(c.Key(key)), expiry.Encode(bts, expiry.At(ttl)), nil)
 }
 
+// Del value by key
 func (c *LevelDB) Del(key string) error {
-	panic("implement me")
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.db.Delete([]byte(c.Key(key)), nil)
 }
 
+// GetMulti values by multi key. the not exists keys will be ignored.
 func (c *LevelDB) GetMulti(keys []string) map[string]any {
-	panic("implement me")
+	snap, err := c.db.GetSnapshot()
+	if err != nil {
+		c.SetOpErr("GetMulti", "", err)
+		return nil
+	}
+	defer snap.Release()
+
+	results := make(map[string]any, len(keys))
+	for _, key := range keys {
+		if err := c.ContextErr(); err != nil {
+			c.SetOpErr("GetMulti", "", err)
+			return nil
+		}
+
+		bs, err := snap.Get([]byte(c.Key(key)), nil)
+		if err != nil {
+			if err == leveldb.ErrNotFound {
+				continue
+			}
+			c.SetOpErr("GetMulti", key, err)
+			return nil
+		}
+
+		bts, exp, err := expiry.Decode(bs)
+		if err != nil || expiry.Expired(exp) {
+			continue
+		}
+
+		var val any
+		if err = c.UnmarshalTo(bts, &val); err != nil {
+			c.SetOpErr("GetMulti", key, err)
+			continue
+		}
+		results[key] = val
+	}
+	return results
 }
 
+// SetMulti values by multi key. will write by the leveldb.Batch
 func (c *LevelDB) SetMulti(values map[string]any, ttl time.Duration) (err error) {
-	panic("implement me")
+	exp := expiry.At(ttl)
+	batch := new(leveldb.Batch)
+
+	for key, val := range values {
+		if err = c.ContextErr(); err != nil {
+			return err
+		}
+
+		bts, err := c.MustMarshal(val)
+		if err != nil {
+			return err
+		}
+		batch.Put([]byte(c.Key(key)), expiry.Encode(bts, exp))
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.db.Write(batch, nil)
 }
 
+// DelMulti values by multi key. will delete by the leveldb.Batch
 func (c *LevelDB) DelMulti(keys []string) error {
-	panic("implement me")
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	for _, key := range keys {
+		batch.Delete([]byte(c.Key(key)))
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.db.Write(batch, nil)
 }
 
+// Clear all cache data. if the prefix option is set, only delete the keys with prefix.
 func (c *LevelDB) Clear() error {
-	panic("implement me")
+	return c.ClearPrefix("")
 }
 
+// ClearPrefix delete the caches which key has the prefix, the driver prefix will be added.
+func (c *LevelDB) ClearPrefix(prefix string) error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	it := c.db.NewIterator(c.keyRange(prefix), nil)
+	defer it.Release()
+
+	batch := new(leveldb.Batch)
+	for it.Next() {
+		if err := c.ContextErr(); err != nil {
+			return err
+		}
+		batch.Delete(append([]byte(nil), it.Key()...))
+	}
+
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return c.db.Write(batch, nil)
+}
+
+// Close the sweep goroutine and the db
 func (c *LevelDB) Close() error {
+	c.StopSweep()
 	return c.db.Close()
 }
+
+/*************************************************************
+ * methods implements of the cache.Counter
+ *************************************************************/
+
+// Incr increment the key value by 1
+func (c *LevelDB) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1
+func (c *LevelDB) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta.
+// the ttl is only used when the key is created.
+func (c *LevelDB) IncrBy(key string, delta int64, ttl ...time.Duration) (num int64, err error) {
+	err = c.update(key, func(val any, exp int64, found bool) error {
+		if found {
+			if num, err = mathutil.ToInt64(val); err != nil {
+				return cache.ErrNotNumber
+			}
+		} else {
+			exp = expiry.At(cache.CreateTTL(ttl))
+		}
+
+		num += delta
+		return c.save(key, num, exp)
+	})
+	return
+}
+
+// IncrByFloat increment the key value by float delta.
+// the ttl is only used when the key is created.
+func (c *LevelDB) IncrByFloat(key string, delta float64, ttl ...time.Duration) (num float64, err error) {
+	err = c.update(key, func(val any, exp int64, found bool) error {
+		if found {
+			if num, err = mathutil.ToFloat(val); err != nil {
+				return cache.ErrNotNumber
+			}
+		} else {
+			exp = expiry.At(cache.CreateTTL(ttl))
+		}
+
+		num += delta
+		return c.save(key, num, exp)
+	})
+	return
+}
+
+/*************************************************************
+ * methods implements of the cache.ConditionalSetter
+ *************************************************************/
+
+// Add set the key value only if the key does not exist
+func (c *LevelDB) Add(key string, val any, ttl time.Duration) (ok bool, err error) {
+	err = c.update(key, func(_ any, _ int64, found bool) error {
+		if found {
+			return nil
+		}
+
+		ok = true
+		return c.save(key, val, expiry.At(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// Replace set the key value only if the key already exists
+func (c *LevelDB) Replace(key string, val any, ttl time.Duration) (ok bool, err error) {
+	err = c.update(key, func(_ any, _ int64, found bool) error {
+		if !found {
+			return nil
+		}
+
+		ok = true
+		return c.save(key, val, expiry.At(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (c *LevelDB) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (ok bool, err error) {
+	err = c.update(key, func(val any, _ int64, found bool) error {
+		if !found || !c.EqualValue(val, oldVal) {
+			return nil
+		}
+
+		ok = true
+		return c.save(key, newVal, expiry.At(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal
+func (c *LevelDB) CompareAndDelete(key string, oldVal any) (ok bool, err error) {
+	err = c.update(key, func(val any, _ int64, found bool) error {
+		if !found || !c.EqualValue(val, oldVal) {
+			return nil
+		}
+
+		ok = true
+		return c.db.Delete([]byte(c.Key(key)), nil)
+	})
+	return ok && err == nil, err
+}
+
+/*************************************************************
+ * methods implements of the cache.TTLer
+ *************************************************************/
+
+// TTL get the remaining time to live of the key
+func (c *LevelDB) TTL(key string) (time.Duration, error) {
+	if err := c.ContextErr(); err != nil {
+		return 0, err
+	}
+
+	_, exp, err := c.load([]byte(c.Key(key)))
+	if err != nil {
+		if isNotFound(err) {
+			return 0, cache.ErrNotFound
+		}
+		return 0, err
+	}
+	return expiry.Remaining(exp), nil
+}
+
+// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration.
+func (c *LevelDB) Expire(key string, ttl time.Duration) error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	rk := []byte(c.Key(key))
+	bts, _, err := c.load(rk)
+	if err != nil {
+		if isNotFound(err) {
+			return cache.ErrNotFound
+		}
+		return err
+	}
+	return c.db.Put(rk, expiry.Encode(bts, expiry.At(ttl)), nil)
+}
+
+// Persist remove the key expiration
+func (c *LevelDB) Persist(key string) error {
+	return c.Expire(key, cache.Forever)
+}
+
+// Touch refresh the key expiration to ttl from now
+func (c *LevelDB) Touch(key string, ttl time.Duration) error {
+	if err := c.Expire(key, ttl); err != cache.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+/*************************************************************
+ * helper methods
+ *************************************************************/
+
+// the iterate range of the keys with prefix
+func (c *LevelDB) keyRange(prefix string) *util.Range {
+	return util.BytesPrefix([]byte(c.Key(prefix)))
+}
+
+// load the raw value and expire time of the real key.
+// returns leveldb.ErrNotFound if the key not exists, errExpired if the key is expired.
+func (c *LevelDB) load(key []byte) ([]byte, int64, error) {
+	bs, err := c.db.Get(key, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	bts, exp, err := expiry.Decode(bs)
+	if err != nil {
+		return nil, 0, err
+	}
+	if expiry.Expired(exp) {
+		return nil, 0, errExpired
+	}
+	return bts, exp, nil
+}
+
+// delete the expired key on read. re-check it under the lock, the key maybe updated.
+func (c *LevelDB) delExpired(key []byte) {
+	if c.readonly {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, _, err := c.load(key); err == errExpired {
+		c.SetOpErr("DelExpired", string(key), c.db.Delete(key, nil))
+	}
+}
+
+// get and unmarshal the value of the real key
+func (c *LevelDB) get(key []byte) (val any, err error) {
+	bts, _, err := c.load(key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.UnmarshalTo(bts, &val)
+	return
+}
+
+// update run the read-modify-write fn for the key under the lock.
+func (c *LevelDB) update(key string, fn func(val any, exp int64, found bool) error) error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	bts, exp, err := c.load([]byte(c.Key(key)))
+	if err != nil {
+		if isNotFound(err) {
+			return fn(nil, 0, false)
+		}
+		return err
+	}
+
+	var val any
+	if err = c.UnmarshalTo(bts, &val); err != nil {
+		return err
+	}
+	return fn(val, exp, true)
+}
+
+func isNotFound(err error) bool {
+	return err == leveldb.ErrNotFound || err == errExpired
+}
+
+// save the value to key with the expire unix nano, 0 is never expired
+func (c *LevelDB) save(key string, val any, exp int64) error {
+	bts, err := c.MustMarshal(val)
+	if err != nil {
+		return err
+	}
+	return c.db.Put([]byte(c.Key(key)), expiry.Encode(bts, exp), nil)
+}
+
+/*************************************************************
+ * open by DSN url
+ *************************************************************/
+
+func init() {
+	cache.RegisterFactory(Name, openURL)
+}
+
+// open by the DSN url. eg: "leveldb:///path/to/dir?prefix=app:", "leveldb://" for the memory storage
+func openURL(u *url.URL) (cache.Cache, error) {
+	optFns, err := cache.URLOptions(u)
+	if err != nil {
+		return nil, err
+	}
+
+	if path := cache.URLPath(u); path != "" {
+		return New(path, optFns...)
+	}
+	return NewMemory(optFns...)
+}
@@ -0,0 +1,146 @@
+package leveldb_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/leveldb"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/goutil/testutil/assert"
+	ldb "github.com/syndtr/goleveldb/leveldb"
+)
+
+func Example() {
+	c, err := leveldb.NewMemory()
+	if err != nil {
+		panic(err)
+	}
+	defer c.Close()
+
+	key := "name"
+
+	// set
+	c.Set(key, "cache value", cache.Seconds2)
+	fmt.Println(c.Has(key))
+
+	// get
+	val := c.Get(key)
+	fmt.Println(val)
+
+	time.Sleep(2 * time.Second)
+
+	// get expired
+	val2 := c.Get(key)
+	fmt.Println(val2)
+
+	// Output:
+	// true
+	// cache value
+	// <nil>
+}
+
+func TestLevelDB_readonly(t *testing.T) {
+	is := assert.New(t)
+	dir := t.TempDir()
+
+	c, err := leveldb.New(dir)
+	is.NoErr(err)
+	is.NoErr(c.Set("key", "value", 0))
+	is.NoErr(c.Close())
+
+	c, err = leveldb.NewReadOnly(dir)
+	is.NoErr(err)
+	defer c.Close()
+
+	is.Eq("value", c.Get("key"))
+	is.ErrIs(c.Set("key", "value1", 0), ldb.ErrReadOnly)
+	is.ErrIs(c.Sweep(), ldb.ErrReadOnly)
+}
+
+func TestLevelDB_multi(t *testing.T) {
+	is := assert.New(t)
+	c, err := leveldb.NewMemory(cache.WithPrefix("app:"))
+	is.NoErr(err)
+	defer c.Close()
+
+	is.NoErr(c.Db().Put([]byte("other"), []byte("value"), nil))
+
+	is.NoErr(c.SetMulti(map[string]any{"k1": "v1", "k2": "v2"}, cache.Seconds3))
+	is.Eq(map[string]any{"k1": "v1", "k2": "v2"}, c.GetMulti([]string{"k1", "k2", "k3"}))
+
+	is.NoErr(c.DelMulti([]string{"k1"}))
+	is.False(c.Has("k1"))
+	is.True(c.Has("k2"))
+
+	// only clear the keys with prefix
+	is.NoErr(c.Clear())
+	is.False(c.Has("k2"))
+	has, err := c.Db().Has([]byte("other"), nil)
+	is.NoErr(err)
+	is.True(has)
+}
+
+func TestLevelDB_sweep(t *testing.T) {
+	is := assert.New(t)
+	c, err := leveldb.NewMemory()
+	is.NoErr(err)
+	defer c.Close()
+
+	is.NoErr(c.Set("k1", "v1", 10*time.Millisecond))
+	is.NoErr(c.Set("k2", "v2", 0))
+	c.StartSweep(20 * time.Millisecond)
+	time.Sleep(60 * time.Millisecond)
+
+	has, err := c.Db().Has([]byte("k1"), nil)
+	is.NoErr(err)
+	is.False(has)
+	is.Eq("v2", c.Get("k2"))
+}
+
+func TestLevelDB_counter_ttl(t *testing.T) {
+	is := assert.New(t)
+	c, err := leveldb.NewMemory()
+	is.NoErr(err)
+	defer c.Close()
+
+	num, err := c.Incr("num", cache.Seconds3)
+	is.NoErr(err)
+	is.Eq(int64(1), num)
+	num, err = c.IncrBy("num", 5)
+	is.NoErr(err)
+	is.Eq(int64(6), num)
+
+	ttl, err := c.TTL("num")
+	is.NoErr(err)
+	is.True(ttl > 0 && ttl <= cache.Seconds3)
+
+	is.NoErr(c.Persist("num"))
+	ttl, err = c.TTL("num")
+	is.NoErr(err)
+	is.Eq(time.Duration(cache.Forever), ttl)
+
+	_, err = c.TTL("not-exist")
+	is.ErrIs(err, cache.ErrNotFound)
+
+	ok, err := c.Add("num", 1, 0)
+	is.NoErr(err)
+	is.False(ok)
+	ok, err = c.CompareAndSwap("num", 6, 7, 0)
+	is.NoErr(err)
+	is.True(ok)
+	ok, err = c.CompareAndDelete("num", 7)
+	is.NoErr(err)
+	is.True(ok)
+	is.False(c.Has("num"))
+}
+
+func TestLevelDB_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		c, err := leveldb.NewMemory()
+		assert.NoErr(t, err)
+		t.Cleanup(func() { c.Close() })
+		return c
+	})
+}
@@ -0,0 +1,217 @@
+// Package lock provide a lease based distributed lock on top of the cache drivers.
+//
+// The cache driver must implement the cache.ConditionalSetter and cache.CompareDeleter,
+// eg: redis.Redigo, goredis.GoRedis, memcached.MemCached, cache.MemoryCache
+//
+// Usage:
+//
+//	locker, err := lock.New(goredis.Connect("127.0.0.1:6379", "", 0))
+//
+//	lk, err := locker.Acquire(ctx, "job:sync", cache.Seconds30)
+//	if err != nil {
+//		return err
+//	}
+//	defer lk.Release()
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mrand "math/rand/v2"
+	"time"
+
+	"github.com/gookit/cache"
+)
+
+// errors for lock
+var (
+	// ErrNotObtained the lock is held by other owner
+	ErrNotObtained = errors.New("lock: not obtained")
+	// ErrNotHeld the lock is not held by current owner. eg: expired or released
+	ErrNotHeld = errors.New("lock: not held")
+	// ErrNotSupported the cache driver cannot be used for lock
+	ErrNotSupported = errors.New("lock: cache driver must support atomic set-if-absent and compare-and-delete")
+
+	errInvalidTTL = errors.New("lock: ttl must be greater than 0")
+)
+
+// Store the cache driver requirements for lock
+type Store interface {
+	cache.ConditionalSetter
+	cache.CompareDeleter
+}
+
+// Options for the Locker
+type Options struct {
+	// Prefix for lock keys. default is "lock:"
+	Prefix string
+	// RetryMin the min wait time between acquire retries. default is 10ms
+	RetryMin time.Duration
+	// RetryMax the max wait time between acquire retries. default is 500ms
+	RetryMax time.Duration
+	// TokenFn build the owner token for each lock. default is random hex string
+	TokenFn func() (string, error)
+}
+
+// WithPrefix set the lock key prefix
+func WithPrefix(prefix string) func(opt *Options) {
+	return func(opt *Options) {
+		opt.Prefix = prefix
+	}
+}
+
+// WithRetry set the min and max wait time between acquire retries
+func WithRetry(minWait, maxWait time.Duration) func(opt *Options) {
+	return func(opt *Options) {
+		opt.RetryMin = minWait
+		opt.RetryMax = maxWait
+	}
+}
+
+// Locker create locks on a cache driver
+type Locker struct {
+	store Store
+	opt   Options
+}
+
+// New create a Locker by cache driver. will return ErrNotSupported if the driver cannot be used.
+func New(c cache.Cache, optFns ...func(opt *Options)) (*Locker, error) {
+	store, ok := c.(Store)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	l := &Locker{
+		store: store,
+		opt: Options{
+			Prefix:   "lock:",
+			RetryMin: 10 * time.Millisecond,
+			RetryMax: 500 * time.Millisecond,
+			TokenFn:  randomToken,
+		},
+	}
+
+	for _, fn := range optFns {
+		fn(&l.opt)
+	}
+
+	if l.opt.RetryMin <= 0 {
+		l.opt.RetryMin = time.Millisecond
+	}
+	if l.opt.RetryMax < l.opt.RetryMin {
+		l.opt.RetryMax = l.opt.RetryMin
+	}
+	return l, nil
+}
+
+// TryAcquire try to obtain the lock once. returns ErrNotObtained if it is held by other owner.
+func (l *Locker) TryAcquire(key string, ttl time.Duration) (*Lock, error) {
+	if ttl <= 0 {
+		return nil, errInvalidTTL
+	}
+
+	token, err := l.opt.TokenFn()
+	if err != nil {
+		return nil, err
+	}
+
+	lk := &Lock{locker: l, key: l.opt.Prefix + key, token: token}
+	ok, err := l.store.Add(lk.key, token, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotObtained
+	}
+	return lk, nil
+}
+
+// Acquire obtain the lock, will retry with exponential backoff until obtained or the ctx is done.
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	var timer *time.Timer
+	wait := l.opt.RetryMin
+
+	for {
+		lk, err := l.TryAcquire(key, ttl)
+		if err != ErrNotObtained {
+			return lk, err
+		}
+
+		// wait with jitter: [wait/2, wait)
+		d := wait/2 + time.Duration(mrand.Int64N(int64(wait/2)+1))
+		if timer == nil {
+			timer = time.NewTimer(d)
+			defer timer.Stop()
+		} else {
+			timer.Reset(d)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", ErrNotObtained, ctx.Err())
+		case <-timer.C:
+		}
+
+		if wait *= 2; wait > l.opt.RetryMax {
+			wait = l.opt.RetryMax
+		}
+	}
+}
+
+// Lock an obtained lock
+type Lock struct {
+	locker *Locker
+	// real cache key of the lock
+	key string
+	// owner token
+	token string
+}
+
+// Key get the lock cache key
+func (lk *Lock) Key() string {
+	return lk.key
+}
+
+// Token get the owner token
+func (lk *Lock) Token() string {
+	return lk.token
+}
+
+// Release the lock. only delete the key if it is still held by the lock, otherwise returns ErrNotHeld.
+func (lk *Lock) Release() error {
+	ok, err := lk.locker.store.CompareAndDelete(lk.key, lk.token)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Extend reset the lock ttl. returns ErrNotHeld if it is not held by the lock.
+func (lk *Lock) Extend(ttl time.Duration) error {
+	if ttl <= 0 {
+		return errInvalidTTL
+	}
+
+	ok, err := lk.locker.store.CompareAndSwap(lk.key, lk.token, lk.token, ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+func randomToken() (string, error) {
+	bs := make([]byte, 16)
+	if _, err := rand.Read(bs); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bs), nil
+}
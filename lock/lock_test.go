@@ -0,0 +1,154 @@
+package lock_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/gocache"
+	"github.com/gookit/cache/lock"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func Example() {
+	locker, err := lock.New(cache.NewMemoryCache())
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cache.Seconds3)
+	defer cancel()
+
+	lk, err := locker.Acquire(ctx, "job:sync", cache.Seconds10)
+	if err != nil {
+		panic(err)
+	}
+
+	// do something ...
+	fmt.Println(lk.Key())
+	fmt.Println(lk.Release())
+
+	// Output:
+	// lock:job:sync
+	// <nil>
+}
+
+func TestNew_notSupported(t *testing.T) {
+	_, err := lock.New(notSupported{cache.NewMemoryCache()})
+	assert.ErrIs(t, err, lock.ErrNotSupported)
+}
+
+type notSupported struct {
+	cache.Cache
+}
+
+func TestLocker_TryAcquire(t *testing.T) {
+	is := assert.New(t)
+	locker, err := lock.New(cache.NewMemoryCache(), lock.WithPrefix("lk_"))
+	is.NoError(err)
+
+	lk, err := locker.TryAcquire("key", cache.Seconds10)
+	is.NoError(err)
+	is.Eq("lk_key", lk.Key())
+	is.NotEmpty(lk.Token())
+
+	_, err = locker.TryAcquire("key", cache.Seconds10)
+	is.ErrIs(err, lock.ErrNotObtained)
+
+	is.NoError(lk.Extend(cache.Seconds20))
+	is.NoError(lk.Release())
+	is.ErrIs(lk.Release(), lock.ErrNotHeld)
+	is.ErrIs(lk.Extend(cache.Seconds20), lock.ErrNotHeld)
+
+	lk2, err := locker.TryAcquire("key", cache.Seconds10)
+	is.NoError(err)
+	is.NoError(lk2.Release())
+
+	_, err = locker.TryAcquire("key", 0)
+	is.Err(err)
+}
+
+func TestLock_expired(t *testing.T) {
+	is := assert.New(t)
+	locker, err := lock.New(gocache.NewSimple())
+	is.NoError(err)
+
+	lk, err := locker.TryAcquire("key", 500*time.Millisecond)
+	is.NoError(err)
+
+	time.Sleep(600 * time.Millisecond)
+
+	// other owner obtain the expired lock
+	lk2, err := locker.TryAcquire("key", cache.Seconds10)
+	is.NoError(err)
+
+	// cannot release the lock held by other owner
+	is.ErrIs(lk.Release(), lock.ErrNotHeld)
+	is.NoError(lk2.Release())
+}
+
+func TestLocker_Acquire(t *testing.T) {
+	is := assert.New(t)
+	locker, err := lock.New(cache.NewMemoryCache(), lock.WithRetry(time.Millisecond, 5*time.Millisecond))
+	is.NoError(err)
+
+	lk, err := locker.TryAcquire("key", cache.Seconds10)
+	is.NoError(err)
+
+	// timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_, err = locker.Acquire(ctx, "key", cache.Seconds10)
+	is.ErrIs(err, lock.ErrNotObtained)
+	is.ErrIs(err, context.DeadlineExceeded)
+
+	// obtained after released
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = lk.Release()
+	}()
+
+	lk2, err := locker.Acquire(context.Background(), "key", cache.Seconds10)
+	is.NoError(err)
+	is.NoError(lk2.Release())
+}
+
+func TestLocker_concurrent(t *testing.T) {
+	locker, err := lock.New(cache.NewMemoryCache(), lock.WithRetry(time.Millisecond, 2*time.Millisecond))
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var counter, running int
+	var mu sync.Mutex
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lk, err := locker.Acquire(context.Background(), "counter", cache.Seconds10)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			mu.Lock()
+			running++
+			assert.Eq(t, 1, running)
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+			counter++
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			assert.NoError(t, lk.Release())
+		}()
+	}
+
+	wg.Wait()
+	assert.Eq(t, 10, counter)
+}
@@ -1,8 +1,18 @@
 package cache
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
 
-// default supported cache driver name
+// default supported cache driver name.
+// the drivers can be created on the first lookup by the factory with the same name, if the driver package is imported.
 const (
 	DvrFile      = "file"
 	DvrRedis     = "redis"
@@ -16,13 +26,20 @@ const (
  * Cache Manager
  *************************************************************/
 
-// Manager definition
+// Manager definition. it is safe for concurrent use.
 type Manager struct {
+	mu sync.RWMutex
 	// Debug bool
 	// default driver name
 	defName string
 	// drivers map
 	drivers map[string]Cache
+	// DSN of the drivers will be created on the first lookup
+	dsns map[string]string
+	// lock for create the drivers on lookup
+	createMu sync.Mutex
+	// hooks for wrap the drivers on lookup
+	hooks []Hook
 }
 
 // NewManager create a cache manager instance
@@ -30,20 +47,34 @@ func NewManager() *Manager {
 	return &Manager{
 		// defName: driverName,
 		drivers: make(map[string]Cache, 8),
+		dsns:    make(map[string]string),
 	}
 }
 
-// Register new cache driver
+// Register new cache driver. the first registered driver will be used as default.
+//
+// If the name has been registered by another driver, the old driver will be replaced and closed.
 func (m *Manager) Register(name string, driver Cache) *Manager {
-	// always use latest as default driver.
-	m.defName = name
+	m.mu.Lock()
+	old, ok := m.drivers[name]
+	if m.defName == "" {
+		m.defName = name
+	}
 	// save driver instance
 	m.drivers[name] = driver
+	m.mu.Unlock()
+
+	if ok && old != driver {
+		_ = old.Close()
+	}
 	return m
 }
 
 // Unregister an cache driver
 func (m *Manager) Unregister(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if _, ok := m.drivers[name]; !ok {
 		return 0
 	}
@@ -65,75 +96,269 @@ func (m *Manager) SetDefName(driverName string) {
 	m.DefaultUse(driverName)
 }
 
-// DefaultUse set default driver name
-func (m *Manager) DefaultUse(driverName string) {
-	if _, ok := m.drivers[driverName]; !ok {
-		panic("cache driver: " + driverName + " is not registered")
+// Configure set the DSN url of a driver, it will be created on the first lookup.
+//
+// Usage:
+//
+//	m.Configure("sessions", "redis://127.0.0.1:6379/2?prefix=sess:")
+//	// will be created on first use
+//	m.Driver("sessions").Set("key", "value", 0)
+func (m *Manager) Configure(name, dsn string) *Manager {
+	m.mu.Lock()
+	m.dsns[name] = dsn
+	m.mu.Unlock()
+	return m
+}
+
+// Lookup get a driver by name. if the driver is not registered, it will be created and registered by:
+//
+//   - the DSN url set by Configure()
+//   - the factory with the same name, by the default config. eg: cache.DvrMemory, cache.DvrFile
+//
+// If the hooks are added by UseHook(), the driver will be wrapped by WithHooks().
+func (m *Manager) Lookup(driverName string) (Cache, error) {
+	c, err := m.lookup(driverName)
+	if err != nil {
+		return nil, err
 	}
+	return m.withHooks(driverName, c), nil
+}
 
-	m.defName = driverName
+// LookupRaw get or create the driver same as Lookup(), but it is not wrapped by the hooks.
+// eg: for the drivers dispatch the operations to the other drivers of the Manager, avoid run the hooks twice.
+func (m *Manager) LookupRaw(driverName string) (Cache, error) {
+	return m.lookup(driverName)
+}
+
+// UseHook add hooks for observe the operations of all drivers. eg: tracing
+//
+// The drivers returned by the Manager will be wrapped by WithHooks(), the registered drivers are not changed.
+// NOTICE: it is not named Use(), the Use() is for select the default driver.
+func (m *Manager) UseHook(hooks ...Hook) *Manager {
+	m.mu.Lock()
+	// copy on write, the hooks may be used by the wrapped drivers
+	m.hooks = append(m.hooks[:len(m.hooks):len(m.hooks)], hooks...)
+	m.mu.Unlock()
+	return m
+}
+
+func (m *Manager) withHooks(driverName string, c Cache) Cache {
+	m.mu.RLock()
+	hooks := m.hooks
+	m.mu.RUnlock()
+
+	if len(hooks) == 0 {
+		return c
+	}
+	return WithHooks(c, driverName, hooks...)
+}
+
+// get or create the driver, without hooks
+func (m *Manager) lookup(driverName string) (Cache, error) {
+	m.mu.RLock()
+	c, ok := m.drivers[driverName]
+	m.mu.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	// double check for create only once
+	m.createMu.Lock()
+	defer m.createMu.Unlock()
+
+	m.mu.RLock()
+	c, ok = m.drivers[driverName]
+	dsn, configured := m.dsns[driverName]
+	m.mu.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	var err error
+	if configured {
+		c, err = Open(dsn)
+	} else if fn, ok := factory(driverName); ok {
+		c, err = fn(&url.URL{Scheme: strings.ToLower(driverName)})
+	} else {
+		return nil, errors.New("cache driver: " + driverName + " is not registered")
+	}
+
+	if err != nil {
+		return nil, driverErr(driverName, err)
+	}
+
+	m.Register(driverName, c)
+	return c, nil
+}
+
+// DefaultUse set default driver name
+func (m *Manager) DefaultUse(driverName string) {
+	m.Use(driverName)
 }
 
 // Default returns the default driver instance
 func (m *Manager) Default() Cache {
-	if c, ok := m.drivers[m.defName]; ok {
-		return c
+	m.mu.RLock()
+	c, ok := m.drivers[m.defName]
+	name := m.defName
+	m.mu.RUnlock()
+	if ok {
+		return m.withHooks(name, c)
 	}
 
-	panic("cache driver: " + m.defName + " is not registered")
+	if name == "" {
+		panic("cache driver: the default driver is not set")
+	}
+	return m.mustLookup(name)
 }
 
 // Use driver object by name and set it as default driver.
 func (m *Manager) Use(driverName string) Cache {
-	m.DefaultUse(driverName)
-	return m.Driver(driverName)
+	c := m.mustLookup(driverName)
+
+	m.mu.Lock()
+	m.defName = driverName
+	m.mu.Unlock()
+	return c
 }
 
 // Cache get driver by name. alias of Driver()
 func (m *Manager) Cache(driverName string) Cache {
-	return m.drivers[driverName]
+	return m.Driver(driverName)
 }
 
-// Driver get a driver instance by name
+// Driver get a driver instance by name, returns nil if not found or failed to create. see Lookup()
 func (m *Manager) Driver(driverName string) Cache {
-	return m.drivers[driverName]
+	c, _ := m.Lookup(driverName)
+	return c
+}
+
+func (m *Manager) mustLookup(driverName string) Cache {
+	c, err := m.Lookup(driverName)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// check the driver is registered or configured
+func (m *Manager) has(driverName string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.drivers[driverName]
+	if !ok {
+		_, ok = m.dsns[driverName]
+	}
+	return ok
 }
 
 // DefName get default driver name
 func (m *Manager) DefName() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.defName
 }
 
-// Close all drivers
-func (m *Manager) Close() (err error) {
-	for _, cache := range m.drivers {
-		err = cache.Close()
+// Close all drivers in parallel, returns all errors joined.
+func (m *Manager) Close() error {
+	return m.Shutdown(context.Background())
+}
+
+// Shutdown close all drivers in parallel, and wait them done or the ctx is done.
+//
+// Usage:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	err := manager.Shutdown(ctx)
+func (m *Manager) Shutdown(ctx context.Context) error {
+	names, drivers := m.snapshot()
+
+	// the errors are collected by index, so they are joined in name order.
+	var mu sync.Mutex
+	errs := make([]error, len(drivers))
+	var wg sync.WaitGroup
+	for i, driver := range drivers {
+		wg.Add(1)
+		go func(i int, driver Cache) {
+			defer wg.Done()
+			if err := driver.Close(); err != nil {
+				mu.Lock()
+				errs[i] = driverErr(names[i], err)
+				mu.Unlock()
+			}
+		}(i, driver)
 	}
-	return err
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var ctxErr error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		ctxErr = ctx.Err()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return errors.Join(append(errs, ctxErr)...)
 }
 
-// ClearAll all drivers caches
-func (m *Manager) ClearAll() (err error) {
-	for _, cache := range m.drivers {
-		err = cache.Clear()
+// ClearAll all drivers caches, returns all errors joined.
+func (m *Manager) ClearAll() error {
+	names, drivers := m.snapshot()
+
+	var errs []error
+	for i, driver := range drivers {
+		if err := driver.Clear(); err != nil {
+			errs = append(errs, driverErr(names[i], err))
+		}
 	}
-	return err
+	return errors.Join(errs...)
 }
 
 // UnregisterAll cache drivers
 func (m *Manager) UnregisterAll(fn ...func(cache Cache)) int {
-	num := len(m.drivers)
-
+	m.mu.Lock()
+	drivers := m.drivers
 	// unregister
 	m.defName = ""
-	for name, driver := range m.drivers {
-		if len(fn) > 0 {
+	m.drivers = make(map[string]Cache, 8)
+	m.mu.Unlock()
+
+	if len(fn) > 0 {
+		for _, driver := range drivers {
 			fn[0](driver)
 		}
+	}
+	return len(drivers)
+}
+
+// get the sorted names and drivers
+func (m *Manager) snapshot() ([]string, []Cache) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
-		delete(m.drivers, name)
+	names := make([]string, 0, len(m.drivers))
+	for name := range m.drivers {
+		names = append(names, name)
 	}
-	return num
+	sort.Strings(names)
+
+	drivers := make([]Cache, len(names))
+	for i, name := range names {
+		drivers[i] = m.drivers[name]
+	}
+	return names, drivers
+}
+
+func driverErr(name string, err error) error {
+	return fmt.Errorf("cache driver %s: %w", name, err)
 }
 
 /*************************************************************
@@ -0,0 +1,188 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+// a driver record the close calls, and returns the error on close and clear
+type closeDriver struct {
+	cache.Cache
+	closed atomic.Int32
+	err    error
+	delay  time.Duration
+}
+
+func newCloseDriver(err error) *closeDriver {
+	return &closeDriver{Cache: cache.NewMemoryCache(), err: err}
+}
+
+func (d *closeDriver) Close() error {
+	time.Sleep(d.delay)
+	d.closed.Add(1)
+	return d.err
+}
+
+func (d *closeDriver) Clear() error {
+	return d.err
+}
+
+func TestManager_Register(t *testing.T) {
+	is := assert.New(t)
+	m := cache.NewManager()
+
+	// the first registered is default
+	d1, d2 := newCloseDriver(nil), newCloseDriver(nil)
+	m.Register("d1", d1).Register("d2", d2)
+	is.Eq("d1", m.DefName())
+	is.Eq(d2, m.Driver("d2"))
+
+	// replace will close the old driver
+	d3 := newCloseDriver(nil)
+	m.Register("d2", d3)
+	is.Eq(int32(1), d2.closed.Load())
+	is.Eq(d3, m.Cache("d2"))
+	m.Register("d2", d3)
+	is.Eq(int32(0), d3.closed.Load())
+
+	is.Eq(d3, m.Use("d2"))
+	is.Eq("d2", m.DefName())
+	is.Panics(func() {
+		m.DefaultUse("not-exist")
+	})
+
+	is.Eq(1, m.Unregister("d2"))
+	is.Eq("", m.DefName())
+	is.Panics(func() {
+		m.Default()
+	})
+
+	var closed []cache.Cache
+	is.Eq(1, m.UnregisterAll(func(c cache.Cache) {
+		closed = append(closed, c)
+	}))
+	is.Eq([]cache.Cache{d1}, closed)
+	is.Nil(m.Driver("d1"))
+}
+
+func TestManager_concurrent(t *testing.T) {
+	m := cache.NewManager()
+	m.Register("default", cache.NewMemoryCache())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := "d" + strconv.Itoa(i%3)
+			for j := 0; j < 100; j++ {
+				m.Register(name, cache.NewMemoryCache())
+				_ = m.Driver(name)
+				_ = m.Default().Set("key", j, 0)
+				_ = m.DefName()
+				m.Unregister(name)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Eq(t, "default", m.DefName())
+}
+
+func TestManager_Close(t *testing.T) {
+	is := assert.New(t)
+	errFail := errors.New("failed")
+
+	m := cache.NewManager()
+	d1, d2, d3 := newCloseDriver(errFail), newCloseDriver(nil), newCloseDriver(errFail)
+	m.Register("d1", d1).Register("d2", d2).Register("d3", d3)
+
+	// all errors are joined
+	err := m.ClearAll()
+	is.ErrIs(err, errFail)
+	is.ErrMsg(err, "cache driver d1: failed\ncache driver d3: failed")
+
+	err = m.Close()
+	is.ErrMsg(err, "cache driver d1: failed\ncache driver d3: failed")
+	for _, d := range []*closeDriver{d1, d2, d3} {
+		is.Eq(int32(1), d.closed.Load())
+	}
+
+	// close in parallel with timeout
+	m = cache.NewManager()
+	slow := newCloseDriver(nil)
+	slow.delay = time.Second
+	m.Register("slow", slow).Register("fast", newCloseDriver(nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	is.ErrIs(m.Shutdown(ctx), context.DeadlineExceeded)
+	is.Lt(time.Since(start), 500*time.Millisecond)
+}
+
+func TestManager_Lookup(t *testing.T) {
+	is := assert.New(t)
+	m := cache.NewManager()
+
+	// create by the factory with same name
+	c, err := m.Lookup(cache.DvrMemory)
+	is.NoErr(err)
+	is.Eq(c, m.Driver(cache.DvrMemory))
+	is.Eq(cache.DvrMemory, m.DefName())
+
+	// create by the configured DSN
+	m.Configure("local", "memory://")
+	is.NotNil(m.Use("local").(*cache.MemoryCache))
+	is.Eq("local", m.DefName())
+	is.NoErr(m.Set("key", "value", 0))
+
+	// third-party driver
+	var created atomic.Int32
+	cache.RegisterFactory("test-Lazy", func(u *url.URL) (cache.Cache, error) {
+		created.Add(1)
+		if u.Query().Get("fail") != "" {
+			return nil, errors.New("failed")
+		}
+		return cache.NewMemoryCache(), nil
+	})
+	is.True(cache.HasFactory("test-lazy"))
+	is.Contains(cache.Factories(), "test-lazy")
+
+	var wg sync.WaitGroup
+	drivers := make([]cache.Cache, 8)
+	for i := range drivers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			drivers[i] = m.Driver("test-lazy")
+		}(i)
+	}
+	wg.Wait()
+	is.Eq(int32(1), created.Load())
+	for _, c := range drivers {
+		is.Eq(drivers[0], c)
+	}
+	is.NotNil(drivers[0])
+
+	// errors
+	m.Configure("bad", "test-lazy://?fail=1")
+	_, err = m.Lookup("bad")
+	is.ErrMsg(err, "cache driver bad: failed")
+	is.Nil(m.Driver("bad"))
+
+	_, err = m.Lookup("not-exists")
+	is.ErrMsg(err, "cache driver: not-exists is not registered")
+	is.Panics(func() {
+		m.Use("not-exists")
+	})
+}
@@ -2,10 +2,16 @@
 package memcached
 
 import (
+	"bytes"
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/gookit/cache"
+	"github.com/gookit/gsr"
 )
 
 // Name driver name
@@ -20,18 +26,17 @@ type MemCached struct {
 
 // New a MemCached instance
 func New(servers ...string) *MemCached {
-	return &MemCached{
+	c := &MemCached{
 		servers: servers,
 	}
+
+	c.SetName(Name)
+	return c
 }
 
 // Connect new a MemCached instance and connect to memcached servers.
 func Connect(servers ...string) *MemCached {
-	c := &MemCached{
-		servers: servers,
-	}
-
-	return c.Connect()
+	return New(servers...).Connect()
 }
 
 // Connect to servers
@@ -40,21 +45,45 @@ func (c *MemCached) Connect() *MemCached {
 	return c
 }
 
+// WithContext returns a copy of the driver for operate with ctx.
+//
+// NOTICE: the memcache client does not support context, so it only checks the ctx before
+// each operation, and between the items of multi operations.
+func (c *MemCached) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *c
+	cp.SetContext(ctx)
+	return &cp
+}
+
 // Has cache key
 func (c *MemCached) Has(key string) bool {
+	if c.ContextErr() != nil {
+		return false
+	}
+
 	_, err := c.client.Get(c.Key(key))
 	return err == nil
 }
 
 // Get value by key
 func (c *MemCached) Get(key string) (val any) {
+	if err := c.ContextErr(); err != nil {
+		c.SetOpErr("Get", key, err)
+		return
+	}
+
 	item, err := c.client.Get(c.Key(key))
 	if err != nil {
+		// not exists is not an error
+		if err != memcache.ErrCacheMiss {
+			c.SetOpErr("Get", key, err)
+		}
 		return
 	}
 
 	err = c.UnmarshalTo(item.Value, &val)
 	if err != nil {
+		c.SetOpErr("Get", key, err)
 		return nil
 	}
 	return
@@ -62,40 +91,51 @@ func (c *MemCached) Get(key string) (val any) {
 
 // Set value by key
 func (c *MemCached) Set(key string, val any, ttl time.Duration) (err error) {
-	bts, err := c.MustMarshal(val)
+	if err = c.ContextErr(); err != nil {
+		return err
+	}
+
+	item, err := c.newItem(c.Key(key), val, ttl)
 	if err != nil {
 		return err
 	}
 
-	return c.client.Set(&memcache.Item{
-		Key:   c.Key(key),
-		Value: bts,
-		// expire time. 0 is never expired
-		Expiration: int32(ttl / time.Second),
-	})
+	return c.client.Set(item)
 }
 
 // Del value by key
 func (c *MemCached) Del(key string) error {
-	return c.client.Delete(c.Key(key))
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+
+	return ignoreMiss(c.client.Delete(c.Key(key)))
 }
 
 // GetMulti values by multi key
 func (c *MemCached) GetMulti(keys []string) map[string]any {
-	keys = c.BuildKeys(keys)
+	if err := c.ContextErr(); err != nil {
+		c.SetOpErr("GetMulti", "", err)
+		return nil
+	}
 
-	items, err := c.client.GetMulti(keys)
+	items, err := c.client.GetMulti(c.BuildKeys(keys))
 	if err != nil {
+		c.SetOpErr("GetMulti", "", err)
 		return nil
 	}
 
-	values := make(map[string]any, len(keys))
-	for key, item := range items {
+	values := make(map[string]any, len(items))
+	for _, key := range keys {
+		item, ok := items[c.Key(key)]
+		if !ok {
+			continue
+		}
+
 		var val any
 		if err := c.UnmarshalTo(item.Value, &val); err != nil {
 			continue
 		}
-
 		values[key] = val
 	}
 
@@ -105,7 +145,10 @@ func (c *MemCached) GetMulti(keys []string) map[string]any {
 // SetMulti values by multi key
 func (c *MemCached) SetMulti(values map[string]any, ttl time.Duration) (err error) {
 	for key, val := range values {
-		if err = c.Set(c.Key(key), val, ttl); err != nil {
+		if err = c.ContextErr(); err != nil {
+			return
+		}
+		if err = c.Set(key, val, ttl); err != nil {
 			return
 		}
 	}
@@ -116,7 +159,10 @@ func (c *MemCached) SetMulti(values map[string]any, ttl time.Duration) (err erro
 // DelMulti values by multi key
 func (c *MemCached) DelMulti(keys []string) error {
 	for _, key := range c.BuildKeys(keys) {
-		if err := c.client.Delete(key); err != nil {
+		if err := c.ContextErr(); err != nil {
+			return err
+		}
+		if err := ignoreMiss(c.client.Delete(key)); err != nil {
 			return err
 		}
 	}
@@ -124,8 +170,220 @@ func (c *MemCached) DelMulti(keys []string) error {
 	return nil
 }
 
+// Incr increment the key value by 1
+func (c *MemCached) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1.
+// NOTICE: memcached counter cannot be decremented below 0.
+func (c *MemCached) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta.
+// the ttl is only used when the key is created.
+//
+// NOTICE: memcached counter is unsigned, decrement below 0 will be set to 0.
+func (c *MemCached) IncrBy(key string, delta int64, ttl ...time.Duration) (int64, error) {
+	key = c.Key(key)
+	num, err := c.incrBy(key, delta)
+	if err != memcache.ErrCacheMiss {
+		return num, err
+	}
+
+	// key not exists, create it with the ttl
+	initVal := delta
+	if initVal < 0 {
+		initVal = 0
+	}
+
+	err = c.client.Add(&memcache.Item{
+		Key:        key,
+		Value:      []byte(strconv.FormatInt(initVal, 10)),
+		Expiration: expiration(cache.CreateTTL(ttl)),
+	})
+	if err == memcache.ErrNotStored {
+		// has been created by other client
+		return c.incrBy(key, delta)
+	}
+	return initVal, err
+}
+
+func (c *MemCached) incrBy(key string, delta int64) (int64, error) {
+	var num uint64
+	var err error
+	if delta < 0 {
+		num, err = c.client.Decrement(key, uint64(-delta))
+	} else {
+		num, err = c.client.Increment(key, uint64(delta))
+	}
+	return int64(num), err
+}
+
+// IncrByFloat is not supported by memcached, will always return cache.ErrNotSupported
+func (c *MemCached) IncrByFloat(string, float64, ...time.Duration) (float64, error) {
+	return 0, cache.ErrNotSupported
+}
+
+// Add set the key value only if the key does not exist
+func (c *MemCached) Add(key string, val any, ttl time.Duration) (bool, error) {
+	item, err := c.newItem(c.Key(key), val, ttl)
+	if err != nil {
+		return false, err
+	}
+	return isStored(c.client.Add(item))
+}
+
+// Replace set the key value only if the key already exists
+func (c *MemCached) Replace(key string, val any, ttl time.Duration) (bool, error) {
+	item, err := c.newItem(c.Key(key), val, ttl)
+	if err != nil {
+		return false, err
+	}
+	return isStored(c.client.Replace(item))
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (c *MemCached) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (bool, error) {
+	oldBts, err := c.MustMarshal(oldVal)
+	if err != nil {
+		return false, err
+	}
+
+	item, err := c.client.Get(c.Key(key))
+	if err != nil {
+		if err == memcache.ErrCacheMiss {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if !bytes.Equal(item.Value, oldBts) {
+		return false, nil
+	}
+
+	if item.Value, err = c.MustMarshal(newVal); err != nil {
+		return false, err
+	}
+
+	item.Expiration = expiration(ttl)
+	return isStored(c.client.CompareAndSwap(item))
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal.
+// memcached has no conditional delete, so it swaps the item to an immediately expired one.
+func (c *MemCached) CompareAndDelete(key string, oldVal any) (bool, error) {
+	oldBts, err := c.MustMarshal(oldVal)
+	if err != nil {
+		return false, err
+	}
+
+	item, err := c.client.Get(c.Key(key))
+	if err != nil {
+		if err == memcache.ErrCacheMiss {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if !bytes.Equal(item.Value, oldBts) {
+		return false, nil
+	}
+
+	// negative expiration means the item is immediately expired
+	item.Expiration = -1
+	return isStored(c.client.CompareAndSwap(item))
+}
+
+// TTL is not supported by memcached, will always return cache.ErrNotSupported
+func (c *MemCached) TTL(string) (time.Duration, error) {
+	return 0, cache.ErrNotSupported
+}
+
+// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration.
+func (c *MemCached) Expire(key string, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = cache.Forever
+	}
+
+	err := c.client.Touch(c.Key(key), expiration(ttl))
+	if err == memcache.ErrCacheMiss {
+		return cache.ErrNotFound
+	}
+	return err
+}
+
+// Persist remove the key expiration
+func (c *MemCached) Persist(key string) error {
+	return c.Expire(key, cache.Forever)
+}
+
+// Touch refresh the key expiration to ttl from now
+func (c *MemCached) Touch(key string, ttl time.Duration) error {
+	if err := c.Expire(key, ttl); err != cache.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// new cache item for write
+func (c *MemCached) newItem(key string, val any, ttl time.Duration) (*memcache.Item, error) {
+	bts, err := c.MustMarshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memcache.Item{
+		Key:   key,
+		Value: bts,
+		// expire time. 0 is never expired
+		Expiration: expiration(ttl),
+	}, nil
+}
+
+// max relative expiration seconds, the larger value will be treated as a unix timestamp by memcached.
+const maxRelativeExpire = 30 * 24 * 3600
+
+// convert the ttl to memcached expiration seconds. 0 is never expired.
+// the ttl less than 1 second will be rounded up to 1 second.
+func expiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+
+	secs := int64((ttl + time.Second - 1) / time.Second)
+	if secs > maxRelativeExpire {
+		return int32(time.Now().Unix() + secs)
+	}
+	return int32(secs)
+}
+
+// ignore the cache miss error on delete
+func ignoreMiss(err error) error {
+	if err == memcache.ErrCacheMiss {
+		return nil
+	}
+	return err
+}
+
+// isStored check the conditional write result.
+func isStored(err error) (bool, error) {
+	switch err {
+	case nil:
+		return true, nil
+	case memcache.ErrNotStored, memcache.ErrCASConflict, memcache.ErrCacheMiss:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
 // Clear all caches
 func (c *MemCached) Clear() error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
 	return c.client.DeleteAll()
 }
 
@@ -139,3 +397,28 @@ func (c *MemCached) Close() error {
 func (c *MemCached) Client() *memcache.Client {
 	return c.client
 }
+
+/*************************************************************
+ * open by DSN url
+ *************************************************************/
+
+func init() {
+	cache.RegisterFactory(Name, openURL)
+}
+
+// open by the DSN url. eg: "memcached://10.0.0.1:11211,10.0.0.2:11211?prefix=app:"
+func openURL(u *url.URL) (cache.Cache, error) {
+	optFns, err := cache.URLOptions(u)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := strings.Split(u.Host, ",")
+	if u.Host == "" {
+		servers = []string{"127.0.0.1:11211"}
+	}
+
+	c := New(servers...)
+	c.WithOptions(optFns...)
+	return c.Connect(), nil
+}
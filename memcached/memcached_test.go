@@ -2,8 +2,15 @@ package memcached_test
 
 import (
 	"fmt"
+	"os"
+	"testing"
+	"time"
 
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/cache/cachetest/fakeserver"
 	"github.com/gookit/cache/memcached"
+	"github.com/gookit/goutil/testutil/assert"
 )
 
 func Example() {
@@ -26,3 +33,42 @@ func Example() {
 	// get: "cache value"
 	fmt.Print(val)
 }
+
+// the fake memcached server address for tests
+var srvAddr string
+
+func TestMain(m *testing.M) {
+	srv, err := fakeserver.NewMemcached()
+	if err != nil {
+		panic(err)
+	}
+
+	srvAddr = srv.Addr()
+	code := m.Run()
+	_ = srv.Close()
+	os.Exit(code)
+}
+
+func TestMemCached_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		c := memcached.Connect(srvAddr)
+		t.Cleanup(func() {
+			_ = c.Clear()
+			_ = c.Close()
+		})
+		return c
+	}, cachetest.WithTTLPrecision(time.Second), cachetest.WithClearAll())
+}
+
+func TestMemCached_open(t *testing.T) {
+	is := assert.New(t)
+	c, err := cache.Open("memcached://" + srvAddr + "?prefix=open:")
+	is.NoErr(err)
+	defer c.Close()
+
+	is.True(cache.HasFactory(cache.DvrMemCached))
+	is.Eq("open:key", c.(*memcached.MemCached).Key("key"))
+	is.NoErr(c.Set("key", "value", 0))
+	is.Eq("value", c.Get("key"))
+	is.NoErr(c.Del("key"))
+}
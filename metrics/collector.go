@@ -0,0 +1,222 @@
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gookit/cache"
+)
+
+// DefaultNamespace the default metric name prefix of the Collector
+const DefaultNamespace = "gookit_cache"
+
+// Collector collect the stats of the caches, and export them as Prometheus text format or expvar.
+//
+// The exported metrics, with label cache="name":
+//
+//	gookit_cache_hits_total, gookit_cache_misses_total, gookit_cache_sets_total,
+//	gookit_cache_deletes_total, gookit_cache_evictions_total, gookit_cache_errors_total
+//	gookit_cache_operation_duration_seconds  histogram with label op="Get"
+type Collector struct {
+	mu    sync.RWMutex
+	stats map[string]cache.Stats
+	// Namespace the metric name prefix. default is DefaultNamespace
+	Namespace string
+}
+
+// NewCollector create a Collector
+func NewCollector() *Collector {
+	return &Collector{
+		stats:     make(map[string]cache.Stats),
+		Namespace: DefaultNamespace,
+	}
+}
+
+// Add the stats with cache name, will replace the exists one.
+func (c *Collector) Add(name string, s cache.Stats) *Collector {
+	c.mu.Lock()
+	c.stats[name] = s
+	c.mu.Unlock()
+	return c
+}
+
+// Remove the stats by cache name
+func (c *Collector) Remove(name string) {
+	c.mu.Lock()
+	delete(c.stats, name)
+	c.mu.Unlock()
+}
+
+// Names get the sorted cache names
+func (c *Collector) Names() []string {
+	names, _ := c.snapshot()
+	return names
+}
+
+// get the sorted names and the stats
+func (c *Collector) snapshot() ([]string, []cache.Stats) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.stats))
+	for name := range c.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	stats := make([]cache.Stats, len(names))
+	for i, name := range names {
+		stats[i] = c.stats[name]
+	}
+	return names, stats
+}
+
+// the counter metrics
+var counters = []struct {
+	name, help string
+	value      func(s cache.Stats) uint64
+}{
+	{"hits_total", "The number of the keys found on read.", cache.Stats.Hits},
+	{"misses_total", "The number of the keys not found on read.", cache.Stats.Misses},
+	{"sets_total", "The number of the keys written.", cache.Stats.Sets},
+	{"deletes_total", "The number of the keys deleted.", cache.Stats.Deletes},
+	{"evictions_total", "The number of the items evicted by the driver.", cache.Stats.Evictions},
+	{"errors_total", "The number of the failed operations.", cache.Stats.Errors},
+}
+
+// WriteTo write the metrics in Prometheus text exposition format to w.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	names, stats := c.snapshot()
+
+	for _, m := range counters {
+		metric := c.Namespace + "_" + m.name
+		fmt.Fprintf(cw, "# HELP %s %s\n# TYPE %s counter\n", metric, m.help, metric)
+		for i, name := range names {
+			fmt.Fprintf(cw, "%s{cache=\"%s\"} %d\n", metric, escape(name), m.value(stats[i]))
+		}
+	}
+
+	metric := c.Namespace + "_operation_duration_seconds"
+	fmt.Fprintf(cw, "# HELP %s The latency of the cache operations.\n# TYPE %s histogram\n", metric, metric)
+	for i, name := range names {
+		hists := stats[i].Latencies()
+		ops := make([]string, 0, len(hists))
+		for op := range hists {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+
+		for _, op := range ops {
+			h := hists[op]
+			labels := fmt.Sprintf("cache=\"%s\",op=\"%s\"", escape(name), escape(op))
+
+			var cumulative uint64
+			for j, bound := range h.Bounds {
+				cumulative += h.Counts[j]
+				le := strconv.FormatFloat(bound.Seconds(), 'g', -1, 64)
+				fmt.Fprintf(cw, "%s_bucket{%s,le=\"%s\"} %d\n", metric, labels, le, cumulative)
+			}
+			fmt.Fprintf(cw, "%s_bucket{%s,le=\"+Inf\"} %d\n", metric, labels, h.Count)
+			fmt.Fprintf(cw, "%s_sum{%s} %s\n", metric, labels, strconv.FormatFloat(h.Sum.Seconds(), 'g', -1, 64))
+			fmt.Fprintf(cw, "%s_count{%s} %d\n", metric, labels, h.Count)
+		}
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP export the metrics in Prometheus text format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = c.WriteTo(w)
+}
+
+// StatsData the stats data of a cache for export
+type StatsData struct {
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	HitRatio  float64 `json:"hit_ratio"`
+	Sets      uint64  `json:"sets"`
+	Deletes   uint64  `json:"deletes"`
+	Evictions uint64  `json:"evictions"`
+	Errors    uint64  `json:"errors"`
+	// Latencies the latency summary of the operations
+	Latencies map[string]LatencyData `json:"latencies"`
+}
+
+// LatencyData the latency summary of an operation
+type LatencyData struct {
+	Count  uint64 `json:"count"`
+	SumNs  int64  `json:"sum_ns"`
+	MeanNs int64  `json:"mean_ns"`
+}
+
+// Data get the stats data of all caches, the key is cache name.
+func (c *Collector) Data() map[string]StatsData {
+	data := make(map[string]StatsData)
+	names, stats := c.snapshot()
+	for i, s := range stats {
+		sd := StatsData{
+			Hits:      s.Hits(),
+			Misses:    s.Misses(),
+			HitRatio:  cache.HitRatio(s),
+			Sets:      s.Sets(),
+			Deletes:   s.Deletes(),
+			Evictions: s.Evictions(),
+			Errors:    s.Errors(),
+			Latencies: make(map[string]LatencyData),
+		}
+
+		for op, h := range s.Latencies() {
+			sd.Latencies[op] = LatencyData{Count: h.Count, SumNs: int64(h.Sum), MeanNs: int64(h.Mean())}
+		}
+		data[names[i]] = sd
+	}
+	return data
+}
+
+// Expvar get an expvar.Var of the stats data, it is computed on each call.
+//
+// Usage:
+//
+//	expvar.Publish("cache", col.Expvar())
+func (c *Collector) Expvar() expvar.Var {
+	return expvar.Func(func() any {
+		return c.Data()
+	})
+}
+
+// count the written bytes and keep the first error
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string {
+	return labelEscaper.Replace(s)
+}
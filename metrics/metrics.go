@@ -0,0 +1,244 @@
+// Package metrics provide a recorder for record the statistics of any cache by cache.Wrap(),
+// and the collector for export them as Prometheus text format or expvar.
+//
+// Usage:
+//
+//	c, stats := metrics.New(goredis.Connect("127.0.0.1:6379", "", 0))
+//	cache.Register(goredis.Name, c)
+//
+//	col := metrics.NewCollector().Add("redis", stats)
+//	http.Handle("/metrics", col)
+//	// or export by expvar
+//	expvar.Publish("cache", col.Expvar())
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gookit/cache"
+)
+
+// DefaultBuckets the default latency histogram buckets
+var DefaultBuckets = []time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// Options for the Recorder
+type Options struct {
+	// Buckets the upper bounds of the latency histogram buckets. default is DefaultBuckets
+	Buckets []time.Duration
+}
+
+// WithBuckets set the latency histogram buckets, the bounds must be in ascending order.
+func WithBuckets(bounds ...time.Duration) func(opt *Options) {
+	return func(opt *Options) {
+		opt.Buckets = bounds
+	}
+}
+
+// Recorder record the statistics of the operations by the interceptor, it implements the cache.Stats
+//
+// The Evictions are reported by the cache wrapped by Wrap() if it implements the cache.EvictionCounter
+type Recorder struct {
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	sets    atomic.Uint64
+	deletes atomic.Uint64
+	errors  atomic.Uint64
+
+	bounds []time.Duration
+	mu     sync.RWMutex
+	hists  map[string]*histogram
+	// the evictions source, set by Wrap()
+	evictions cache.EvictionCounter
+}
+
+// NewRecorder create a Recorder
+func NewRecorder(optFns ...func(opt *Options)) *Recorder {
+	opt := Options{Buckets: DefaultBuckets}
+	for _, fn := range optFns {
+		fn(&opt)
+	}
+
+	return &Recorder{
+		bounds: opt.Buckets,
+		hists:  make(map[string]*histogram),
+	}
+}
+
+// New wraps the backend with a new Recorder, the returned cache keeps the optional interfaces of the backend.
+//
+// Usage:
+//
+//	c, stats := metrics.New(rds)
+//	fmt.Println(stats.Hits(), cache.HitRatio(stats))
+func New(backend cache.Cache, optFns ...func(opt *Options)) (cache.Cache, *Recorder) {
+	r := NewRecorder(optFns...)
+	return r.Wrap(backend), r
+}
+
+// Wrap the cache by cache.Wrap() with the interceptor of recorder.
+// the evictions will be reported by c if it implements the cache.EvictionCounter
+func (r *Recorder) Wrap(c cache.Cache) cache.Cache {
+	if ec, ok := c.(cache.EvictionCounter); ok {
+		r.mu.Lock()
+		r.evictions = ec
+		r.mu.Unlock()
+	}
+	return cache.Wrap(c, r.Interceptor())
+}
+
+// Interceptor create the interceptor for record the operations, use with cache.Wrap()
+//
+//   - the keys not found or with nil value on Get, Has, GetMulti are counted as miss.
+//   - the keys written by Set, SetMulti and the succeeded Add, Replace, CompareAndSwap are counted as sets.
+//   - the keys deleted by Del, DelMulti and the succeeded CompareAndDelete are counted as deletes.
+//   - the failed operations are counted as errors, the ErrNotFound is not an error.
+func (r *Recorder) Interceptor() cache.Interceptor {
+	return func(ctx context.Context, call *cache.Call, next func(ctx context.Context) error) error {
+		defer r.observe(call.Op, time.Now())
+
+		err := next(ctx)
+		if err != nil {
+			if !errors.Is(err, cache.ErrNotFound) {
+				r.errors.Add(1)
+			}
+			return err
+		}
+
+		switch call.Op {
+		case "Get", "Has", "GetMulti":
+			found := call.Hits()
+			r.hits.Add(uint64(found))
+			r.misses.Add(uint64(len(call.Keys) - found))
+		case "Set", "SetMulti":
+			r.sets.Add(uint64(len(call.Keys)))
+		case "Add", "Replace", "CompareAndSwap":
+			if ok, _ := call.Result.(bool); ok {
+				r.sets.Add(1)
+			}
+		case "Del", "DelMulti":
+			r.deletes.Add(uint64(len(call.Keys)))
+		case "CompareAndDelete":
+			if ok, _ := call.Result.(bool); ok {
+				r.deletes.Add(1)
+			}
+		}
+		return nil
+	}
+}
+
+/*************************************************************
+ * methods implements of the cache.Stats
+ *************************************************************/
+
+// Hits get the number of the keys found on read
+func (r *Recorder) Hits() uint64 { return r.hits.Load() }
+
+// Misses get the number of the keys not found on read
+func (r *Recorder) Misses() uint64 { return r.misses.Load() }
+
+// Sets get the number of the keys written
+func (r *Recorder) Sets() uint64 { return r.sets.Load() }
+
+// Deletes get the number of the keys deleted
+func (r *Recorder) Deletes() uint64 { return r.deletes.Load() }
+
+// Errors get the number of the failed operations
+func (r *Recorder) Errors() uint64 { return r.errors.Load() }
+
+// Evictions get the number of the evicted items of the wrapped cache. returns 0 if it is not cache.EvictionCounter
+func (r *Recorder) Evictions() uint64 {
+	r.mu.RLock()
+	ec := r.evictions
+	r.mu.RUnlock()
+
+	if ec != nil {
+		return ec.Evictions()
+	}
+	return 0
+}
+
+// Latencies get the latency histograms of the operations
+func (r *Recorder) Latencies() map[string]cache.Histogram {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make(map[string]cache.Histogram, len(r.hists))
+	for op, h := range r.hists {
+		list[op] = h.snapshot(r.bounds)
+	}
+	return list
+}
+
+/*************************************************************
+ * helper methods
+ *************************************************************/
+
+// observe the latency of the operation
+func (r *Recorder) observe(op string, start time.Time) {
+	latency := time.Since(start)
+
+	r.mu.RLock()
+	h, ok := r.hists[op]
+	r.mu.RUnlock()
+
+	if !ok {
+		r.mu.Lock()
+		if h, ok = r.hists[op]; !ok {
+			h = newHistogram(len(r.bounds))
+			r.hists[op] = h
+		}
+		r.mu.Unlock()
+	}
+	h.observe(r.bounds, latency)
+}
+
+// histogram with atomic counters
+type histogram struct {
+	counts []atomic.Uint64
+	sum    atomic.Int64
+}
+
+func newHistogram(n int) *histogram {
+	return &histogram{counts: make([]atomic.Uint64, n+1)}
+}
+
+func (h *histogram) observe(bounds []time.Duration, d time.Duration) {
+	i := 0
+	for i < len(bounds) && d > bounds[i] {
+		i++
+	}
+
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+func (h *histogram) snapshot(bounds []time.Duration) cache.Histogram {
+	// the count is summed from the buckets, keep them consistent on concurrent observe.
+	var count uint64
+	counts := make([]uint64, len(h.counts))
+	for i := range h.counts {
+		counts[i] = h.counts[i].Load()
+		count += counts[i]
+	}
+
+	return cache.Histogram{
+		Bounds: bounds,
+		Counts: counts,
+		Count:  count,
+		Sum:    time.Duration(h.sum.Load()),
+	}
+}
@@ -0,0 +1,163 @@
+package metrics_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/cache/gcache"
+	"github.com/gookit/cache/lock"
+	"github.com/gookit/cache/metrics"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func Example() {
+	c, stats := metrics.New(cache.NewMemoryCache())
+	_ = c.Set("key", "value", 0)
+	_ = c.Get("key")
+	_ = c.Get("not-exist")
+
+	fmt.Println(stats.Hits(), stats.Misses(), stats.Sets(), cache.HitRatio(stats))
+
+	// Output:
+	// 1 1 1 0.5
+}
+
+func TestRecorder_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		c, _ := metrics.New(cache.NewMemoryCache())
+		return c
+	}, cachetest.WithTTLPrecision(time.Second))
+}
+
+// a driver returns the error on write
+type failDriver struct {
+	cache.Cache
+	err error
+}
+
+func (d *failDriver) Set(string, any, time.Duration) error {
+	return d.err
+}
+
+func TestRecorder(t *testing.T) {
+	is := assert.New(t)
+	mc := cache.NewMemoryCache()
+	c, st := metrics.New(mc, metrics.WithBuckets(time.Millisecond, time.Second))
+	var _ cache.Stats = st
+	is.Eq(mc, cache.Unwrap(c))
+
+	is.NoErr(c.Set("k1", "v1", 0))
+	is.NoErr(c.SetMulti(map[string]any{"k2": "v2", "k3": "v3"}, 0))
+	is.Eq(uint64(3), st.Sets())
+
+	is.Eq("v1", c.Get("k1"))
+	vals := c.GetMulti([]string{"k2", "k3", "k4"})
+	is.Eq("v2", vals["k2"])
+	is.Eq(uint64(3), st.Hits())
+	is.Eq(uint64(1), st.Misses())
+	is.True(c.Has("k1"))
+	is.False(c.Has("k4"))
+	is.Eq(uint64(4), st.Hits())
+	is.Eq(uint64(2), st.Misses())
+
+	is.NoErr(c.Del("k1"))
+	is.NoErr(c.DelMulti([]string{"k2", "k3"}))
+	is.Eq(uint64(3), st.Deletes())
+	is.NoErr(c.Clear())
+	is.Eq(uint64(0), st.Errors())
+
+	lats := st.Latencies()
+	is.Len(lats, 8)
+	h := lats["Get"]
+	is.Eq([]time.Duration{time.Millisecond, time.Second}, h.Bounds)
+	is.Len(h.Counts, 3)
+	is.Eq(uint64(1), h.Count)
+	is.Eq(uint64(1), lats["GetMulti"].Count)
+	is.Eq(h.Sum, h.Mean())
+
+	// the optional interfaces of backend
+	ok, err := c.(cache.ConditionalSetter).Add("k5", "v5", 0)
+	is.NoErr(err)
+	is.True(ok)
+	is.Eq(uint64(4), st.Sets())
+	ok, err = c.(cache.CompareDeleter).CompareAndDelete("k5", "v5")
+	is.NoErr(err)
+	is.True(ok)
+	is.Eq(uint64(4), st.Deletes())
+	_, err = c.(cache.TTLer).TTL("k5")
+	is.ErrIs(err, cache.ErrNotFound)
+	is.Eq(uint64(0), st.Errors())
+	_, err = lock.New(c)
+	is.NoErr(err)
+
+	// evictions of backend
+	gc, gst := metrics.New(gcache.New(10))
+	is.NoErr(gc.Set("exp", "v", 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	is.Nil(gc.Get("exp"))
+	is.Eq(uint64(1), gst.Evictions())
+
+	// errors, the stats is shared with the copies
+	errFail := errors.New("failed")
+	fc, fst := metrics.New(&failDriver{Cache: cache.NewMemoryCache(), err: errFail})
+	_, ok = fc.(cache.Counter)
+	is.False(ok)
+	cc := cache.WithContext(fc, context.Background())
+	is.ErrIs(cc.Set("key", "value", 0), errFail)
+	is.Eq(uint64(1), fst.Errors())
+	is.Eq(uint64(0), fst.Sets())
+	is.Eq(uint64(0), fst.Evictions())
+	is.NoErr(fc.Close())
+
+	// the recorder for multi caches
+	r := metrics.NewRecorder()
+	c1 := cache.Wrap(cache.NewMemoryCache(), r.Interceptor())
+	c2 := r.Wrap(cache.NewMemoryCache())
+	is.NoErr(c1.Set("key", "v", 0))
+	is.NoErr(c2.Set("key", "v", 0))
+	is.Eq(uint64(2), r.Sets())
+}
+
+func TestCollector(t *testing.T) {
+	is := assert.New(t)
+	c, st := metrics.New(cache.NewMemoryCache(), metrics.WithBuckets(time.Hour))
+	_ = c.Set("key", "value", 0)
+	_ = c.Get("key")
+
+	col := metrics.NewCollector().Add("mem", st).Add(`b"ad`, metrics.NewRecorder())
+	is.Eq([]string{`b"ad`, "mem"}, col.Names())
+	col.Remove(`b"ad`)
+
+	w := httptest.NewRecorder()
+	col.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	is.StrContains(w.Header().Get("Content-Type"), "text/plain")
+	out := w.Body.String()
+	is.StrContains(out, "# TYPE gookit_cache_hits_total counter\ngookit_cache_hits_total{cache=\"mem\"} 1\n")
+	is.StrContains(out, "gookit_cache_sets_total{cache=\"mem\"} 1\n")
+	is.StrContains(out, "gookit_cache_evictions_total{cache=\"mem\"} 0\n")
+	is.StrContains(out, "# TYPE gookit_cache_operation_duration_seconds histogram\n")
+	is.StrContains(out, "gookit_cache_operation_duration_seconds_bucket{cache=\"mem\",op=\"Get\",le=\"3600\"} 1\n")
+	is.StrContains(out, "gookit_cache_operation_duration_seconds_bucket{cache=\"mem\",op=\"Get\",le=\"+Inf\"} 1\n")
+	is.StrContains(out, "gookit_cache_operation_duration_seconds_count{cache=\"mem\",op=\"Set\"} 1\n")
+	is.False(strings.Contains(out, "b\\\"ad"))
+
+	var sb strings.Builder
+	n, err := col.WriteTo(&sb)
+	is.NoErr(err)
+	is.Eq(int64(sb.Len()), n)
+
+	// expvar
+	data := map[string]metrics.StatsData{}
+	is.NoErr(json.Unmarshal([]byte(col.Expvar().String()), &data))
+	is.Eq(uint64(1), data["mem"].Hits)
+	is.Eq(float64(1), data["mem"].HitRatio)
+	is.Eq(uint64(1), data["mem"].Latencies["Get"].Count)
+}
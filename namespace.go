@@ -0,0 +1,303 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/gookit/gsr"
+)
+
+// NamespaceSep the separator between the namespace name and the key
+const NamespaceSep = ":"
+
+// NoExpire the ttl for write the key without expiration in the namespace which has DefaultTTL.
+// it is converted to Forever before passed to the driver.
+const NoExpire time.Duration = -1
+
+// NamespaceOption for the namespace
+type NamespaceOption struct {
+	// Driver name of the namespace. default is the default driver of the Manager.
+	Driver string
+	// DefaultTTL will be used on write with ttl 0. default is 0, the Forever.
+	//
+	// NOTICE: if set, use the ttl NoExpire to write the keys as Forever.
+	DefaultTTL time.Duration
+}
+
+// WithDefaultTTL set the default ttl of the namespace
+func WithDefaultTTL(ttl time.Duration) func(opt *NamespaceOption) {
+	return func(opt *NamespaceOption) {
+		opt.DefaultTTL = ttl
+	}
+}
+
+// WithDriver set the driver name of the namespace
+func WithDriver(name string) func(opt *NamespaceOption) {
+	return func(opt *NamespaceOption) {
+		opt.Driver = name
+	}
+}
+
+// NamespaceCache is a view of the driver with isolated key space.
+//
+// The keys are prefixed by "name:", on top of the prefix option of the driver.
+// The driver is looked up from the Manager on each operation, so the replaced driver will be used.
+//
+// The optional interfaces(Counter, ConditionalSetter, CompareDeleter, TTLer) are delegated to the driver,
+// and returns ErrNotSupported if the driver does not implement them.
+type NamespaceCache struct {
+	m   *Manager
+	opt NamespaceOption
+	// namespace name and the key prefix
+	name   string
+	prefix string
+	// context for operate
+	ctx context.Context
+}
+
+// Namespace create a view of the driver with isolated key space.
+//
+// Usage:
+//
+//	sessions := m.Namespace("sessions", cache.WithDefaultTTL(30*time.Minute))
+//	// the key is "sessions:token", the ttl is 30 minutes
+//	sessions.Set("token", "value", 0)
+func (m *Manager) Namespace(name string, optFns ...func(opt *NamespaceOption)) *NamespaceCache {
+	ns := &NamespaceCache{m: m, name: name, prefix: name + NamespaceSep}
+	for _, fn := range optFns {
+		fn(&ns.opt)
+	}
+	return ns
+}
+
+// Namespace create a view of the driver in the default manager with isolated key space.
+func Namespace(name string, optFns ...func(opt *NamespaceOption)) *NamespaceCache {
+	return std.Namespace(name, optFns...)
+}
+
+// Name get the namespace name
+func (ns *NamespaceCache) Name() string {
+	return ns.name
+}
+
+// Driver get the driver of the namespace
+func (ns *NamespaceCache) Driver() Cache {
+	var c Cache
+	if ns.opt.Driver != "" {
+		c = ns.m.mustLookup(ns.opt.Driver)
+	} else {
+		c = ns.m.Default()
+	}
+
+	if ns.ctx != nil {
+		return WithContext(c, ns.ctx)
+	}
+	return c
+}
+
+// WithContext returns a copy of the namespace for operate with ctx.
+func (ns *NamespaceCache) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *ns
+	cp.ctx = ctx
+	return &cp
+}
+
+// Key get the key with namespace prefix
+func (ns *NamespaceCache) Key(key string) string {
+	return ns.prefix + key
+}
+
+func (ns *NamespaceCache) keys(keys []string) []string {
+	list := make([]string, len(keys))
+	for i, key := range keys {
+		list[i] = ns.prefix + key
+	}
+	return list
+}
+
+func (ns *NamespaceCache) ttl(ttl time.Duration) time.Duration {
+	switch ttl {
+	case 0:
+		return ns.opt.DefaultTTL
+	case NoExpire:
+		return Forever
+	}
+	return ttl
+}
+
+/*************************************************************
+ * methods implements of the gsr.SimpleCacher
+ *************************************************************/
+
+// Has cache key
+func (ns *NamespaceCache) Has(key string) bool {
+	return ns.Driver().Has(ns.Key(key))
+}
+
+// Get value by key
+func (ns *NamespaceCache) Get(key string) any {
+	return ns.Driver().Get(ns.Key(key))
+}
+
+// Set value by key, the ttl 0 will use the default ttl of namespace
+func (ns *NamespaceCache) Set(key string, val any, ttl time.Duration) error {
+	return ns.Driver().Set(ns.Key(key), val, ns.ttl(ttl))
+}
+
+// Del value by key
+func (ns *NamespaceCache) Del(key string) error {
+	return ns.Driver().Del(ns.Key(key))
+}
+
+// GetMulti values by keys
+func (ns *NamespaceCache) GetMulti(keys []string) map[string]any {
+	values := ns.Driver().GetMulti(ns.keys(keys))
+	if values == nil {
+		return nil
+	}
+
+	list := make(map[string]any, len(values))
+	for key, val := range values {
+		list[key[len(ns.prefix):]] = val
+	}
+	return list
+}
+
+// SetMulti values, the ttl 0 will use the default ttl of namespace
+func (ns *NamespaceCache) SetMulti(values map[string]any, ttl time.Duration) error {
+	list := make(map[string]any, len(values))
+	for key, val := range values {
+		list[ns.prefix+key] = val
+	}
+	return ns.Driver().SetMulti(list, ns.ttl(ttl))
+}
+
+// DelMulti values by keys
+func (ns *NamespaceCache) DelMulti(keys []string) error {
+	return ns.Driver().DelMulti(ns.keys(keys))
+}
+
+// Clear all caches in the namespace. returns ErrNotSupported if the driver does not implement PrefixClearer.
+func (ns *NamespaceCache) Clear() error {
+	if pc, ok := ns.Driver().(PrefixClearer); ok {
+		return pc.ClearPrefix(ns.prefix)
+	}
+	return ErrNotSupported
+}
+
+// ClearPrefix delete the caches which key has the prefix in the namespace.
+func (ns *NamespaceCache) ClearPrefix(prefix string) error {
+	if pc, ok := ns.Driver().(PrefixClearer); ok {
+		return pc.ClearPrefix(ns.prefix + prefix)
+	}
+	return ErrNotSupported
+}
+
+// Close do nothing, the driver is shared with others and managed by the Manager.
+func (ns *NamespaceCache) Close() error {
+	return nil
+}
+
+/*************************************************************
+ * methods implements of the optional interfaces
+ *************************************************************/
+
+// Incr increment the key value by 1
+func (ns *NamespaceCache) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return ns.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1
+func (ns *NamespaceCache) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return ns.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta
+func (ns *NamespaceCache) IncrBy(key string, delta int64, ttl ...time.Duration) (int64, error) {
+	if ct, ok := ns.Driver().(Counter); ok {
+		return ct.IncrBy(ns.Key(key), delta, ns.counterTTL(ttl)...)
+	}
+	return 0, ErrNotSupported
+}
+
+// IncrByFloat increment the key value by float delta
+func (ns *NamespaceCache) IncrByFloat(key string, delta float64, ttl ...time.Duration) (float64, error) {
+	if ct, ok := ns.Driver().(Counter); ok {
+		return ct.IncrByFloat(ns.Key(key), delta, ns.counterTTL(ttl)...)
+	}
+	return 0, ErrNotSupported
+}
+
+func (ns *NamespaceCache) counterTTL(ttl []time.Duration) []time.Duration {
+	if len(ttl) == 0 {
+		if ns.opt.DefaultTTL != 0 {
+			return []time.Duration{ns.opt.DefaultTTL}
+		}
+		return ttl
+	}
+	return []time.Duration{ns.ttl(ttl[0])}
+}
+
+// Add set the key value only if the key does not exist
+func (ns *NamespaceCache) Add(key string, val any, ttl time.Duration) (bool, error) {
+	if cs, ok := ns.Driver().(ConditionalSetter); ok {
+		return cs.Add(ns.Key(key), val, ns.ttl(ttl))
+	}
+	return false, ErrNotSupported
+}
+
+// Replace set the key value only if the key already exists
+func (ns *NamespaceCache) Replace(key string, val any, ttl time.Duration) (bool, error) {
+	if cs, ok := ns.Driver().(ConditionalSetter); ok {
+		return cs.Replace(ns.Key(key), val, ns.ttl(ttl))
+	}
+	return false, ErrNotSupported
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (ns *NamespaceCache) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (bool, error) {
+	if cs, ok := ns.Driver().(ConditionalSetter); ok {
+		return cs.CompareAndSwap(ns.Key(key), oldVal, newVal, ns.ttl(ttl))
+	}
+	return false, ErrNotSupported
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal.
+func (ns *NamespaceCache) CompareAndDelete(key string, oldVal any) (bool, error) {
+	if cd, ok := ns.Driver().(CompareDeleter); ok {
+		return cd.CompareAndDelete(ns.Key(key), oldVal)
+	}
+	return false, ErrNotSupported
+}
+
+// TTL get the remaining time to live of the key.
+func (ns *NamespaceCache) TTL(key string) (time.Duration, error) {
+	if tl, ok := ns.Driver().(TTLer); ok {
+		return tl.TTL(ns.Key(key))
+	}
+	return 0, ErrNotSupported
+}
+
+// Expire set a new ttl for the key.
+func (ns *NamespaceCache) Expire(key string, ttl time.Duration) error {
+	if tl, ok := ns.Driver().(TTLer); ok {
+		return tl.Expire(ns.Key(key), ttl)
+	}
+	return ErrNotSupported
+}
+
+// Persist remove the key expiration.
+func (ns *NamespaceCache) Persist(key string) error {
+	if tl, ok := ns.Driver().(TTLer); ok {
+		return tl.Persist(ns.Key(key))
+	}
+	return ErrNotSupported
+}
+
+// Touch refresh the key expiration to ttl from now.
+func (ns *NamespaceCache) Touch(key string, ttl time.Duration) error {
+	if tl, ok := ns.Driver().(TTLer); ok {
+		return tl.Touch(ns.Key(key), ttl)
+	}
+	return ErrNotSupported
+}
@@ -0,0 +1,113 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func ExampleManager_Namespace() {
+	m := cache.NewManager()
+	m.Register(cache.DvrMemory, cache.NewMemoryCache())
+
+	sessions := m.Namespace("sessions", cache.WithDefaultTTL(30*time.Minute))
+	_ = sessions.Set("token", "value", 0)
+
+	fmt.Println(sessions.Get("token"), m.Default().Get("sessions:token"))
+
+	// Output:
+	// value value
+}
+
+func TestNamespace_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		m := cache.NewManager()
+		m.Register(cache.DvrMemory, cache.NewMemoryCache())
+		return m.Namespace("suite")
+	}, cachetest.WithTTLPrecision(time.Second))
+}
+
+func TestNamespace(t *testing.T) {
+	is := assert.New(t)
+	m := cache.NewManager()
+	mc := cache.NewMemoryCache()
+	m.Register(cache.DvrMemory, mc)
+
+	users := m.Namespace("users", cache.WithDefaultTTL(cache.OneMinutes))
+	orders := m.Namespace("orders")
+	is.Eq("users", users.Name())
+	is.Eq("users:key", users.Key("key"))
+
+	// the default ttl
+	is.NoErr(users.Set("k1", "v1", 0))
+	ttl, err := users.TTL("k1")
+	is.NoErr(err)
+	is.Gt(ttl, cache.Seconds30)
+	is.NoErr(users.SetMulti(map[string]any{"k2": "v2"}, cache.Seconds10))
+	ttl, err = mc.TTL("users:k2")
+	is.NoErr(err)
+	is.Lte(ttl, cache.Seconds10)
+	_, err = users.Incr("counter")
+	is.NoErr(err)
+	ttl, err = mc.TTL("users:counter")
+	is.NoErr(err)
+	is.Gt(ttl, cache.Seconds30)
+
+	// write without expiration
+	is.NoErr(users.Set("forever", "v", cache.NoExpire))
+	ttl, err = mc.TTL("users:forever")
+	is.NoErr(err)
+	is.Eq(time.Duration(cache.Forever), ttl)
+	_, err = users.Incr("forever-counter", cache.NoExpire)
+	is.NoErr(err)
+	ttl, err = mc.TTL("users:forever-counter")
+	is.NoErr(err)
+	is.Eq(time.Duration(cache.Forever), ttl)
+
+	// the key spaces are isolated
+	is.NoErr(orders.Set("k1", "order", 0))
+	is.Eq("v1", users.Get("k1"))
+	is.Eq("order", orders.Get("k1"))
+	vals := users.GetMulti([]string{"k1", "k2", "k3"})
+	is.Eq("v1", vals["k1"])
+	is.Eq("v2", vals["k2"])
+	is.Nil(vals["k3"])
+	ttl, err = orders.TTL("k1")
+	is.NoErr(err)
+	is.Eq(time.Duration(cache.Forever), ttl)
+
+	// clear the namespace only
+	is.NoErr(users.Clear())
+	is.False(users.Has("k1"))
+	is.Eq("order", orders.Get("k1"))
+
+	// the driver is looked up on each operation
+	m.Register(cache.DvrMemory, cache.NewMemoryCache())
+	is.False(orders.Has("k1"))
+	is.NoErr(orders.Close())
+}
+
+func TestNamespace_driverPrefix(t *testing.T) {
+	is := assert.New(t)
+	m := cache.NewManager()
+	fc := cache.NewFileCache(t.TempDir(), "app:")
+	m.Register(cache.DvrMemory, cache.NewMemoryCache()).Register(cache.DvrFile, fc)
+
+	ns := cache.NewManager().Namespace("ns")
+	is.Panics(func() {
+		ns.Get("key")
+	})
+
+	ns = m.Namespace("sessions", cache.WithDriver(cache.DvrFile))
+	is.NoErr(ns.Set("token", "value", 0))
+	is.Eq("value", fc.Get("sessions:token"))
+	is.Eq("app:sessions:token", fc.Key(ns.Key("token")))
+	is.False(m.Default().Has("sessions:token"))
+
+	// the driver can not enumerate keys
+	is.ErrIs(ns.Clear(), cache.ErrNotSupported)
+}
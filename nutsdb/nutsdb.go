@@ -1,50 +1,534 @@
-// Package nutsdb use the https://github.com/xujiajun/nutsdb as cache driver
+// Package nutsdb use the https://github.com/nutsdb/nutsdb as cache driver
 package nutsdb
 
 import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
 	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/goutil/mathutil"
+	"github.com/gookit/gsr"
+	"github.com/nutsdb/nutsdb"
 )
 
 // Name driver name
 const Name = "nutsdb"
 
-// NutsDB definition TODO
+// DefaultBucket the default bucket name for store the cache data
+const DefaultBucket = "cache"
+
+// NutsDB definition
 type NutsDB struct {
-	// cache.BaseDriver
+	cache.BaseDriver
+	db *nutsdb.DB
+	// bucket name for store the cache data
+	bucket string
+}
+
+// New open a NutsDB instance by the db dir path, will use the DefaultBucket.
+//
+// Usage:
+//
+//	c, err := nutsdb.New("path/to/dir", cache.WithPrefix("app:"))
+func New(dir string, optFns ...func(option *cache.Option)) (*NutsDB, error) {
+	return NewWithBucket(dir, DefaultBucket, optFns...)
+}
+
+// NewWithBucket open a NutsDB instance by the db dir path, and use the custom bucket.
+func NewWithBucket(dir, bucket string, optFns ...func(option *cache.Option)) (*NutsDB, error) {
+	opts := nutsdb.DefaultOptions
+	opts.Dir = dir
+	return NewWithOptions(opts, bucket, optFns...)
+}
+
+// NewWithOptions open a NutsDB instance by custom nutsdb.Options and bucket name.
+// the bucket will be created if it does not exist.
+func NewWithOptions(opts nutsdb.Options, bucket string, optFns ...func(option *cache.Option)) (*NutsDB, error) {
+	if bucket == "" {
+		bucket = DefaultBucket
+	}
+
+	db, err := nutsdb.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &NutsDB{db: db, bucket: bucket}
+	c.SetName(Name)
+	c.WithOptions(optFns...)
+
+	if err = c.createBucket(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Db get the nutsdb db
+func (c *NutsDB) Db() *nutsdb.DB {
+	return c.db
+}
+
+// Bucket get the bucket name
+func (c *NutsDB) Bucket() string {
+	return c.bucket
+}
+
+/*************************************************************
+ * methods implements of the gsr.SimpleCacher
+ *************************************************************/
+
+// WithContext returns a copy of the driver for operate with ctx.
+// the ctx is checked before each transaction, and between the items of multi operations.
+func (c *NutsDB) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *c
+	cp.SetContext(ctx)
+	return &cp
 }
 
+// Has cache key
 func (c *NutsDB) Has(key string) bool {
-	panic("implement me")
+	err := c.view(func(tx *nutsdb.Tx) error {
+		_, err := tx.Get(c.bucket, []byte(c.Key(key)))
+		return err
+	})
+	return err == nil
 }
 
+// Get value by key
 func (c *NutsDB) Get(key string) any {
-	panic("implement me")
+	var val any
+	err := c.view(func(tx *nutsdb.Tx) (err error) {
+		val, err = c.get(tx, c.Key(key))
+		return err
+	})
+
+	if err != nil {
+		if !isNotFound(err) {
+			c.SetOpErr("Get", key, err)
+		}
+		return nil
+	}
+	return val
 }
 
+// Set value by key
 func (c *NutsDB) Set(key string, val any, ttl time.Duration) (err error) {
-	panic("implement me")
+	return c.update(func(tx *nutsdb.Tx) error {
+		return c.save(tx, c.Key(key), val, toTTL(ttl))
+	})
 }
 
+// Del value by key
 func (c *NutsDB) Del(key string) error {
-	panic("implement me")
+	return c.update(func(tx *nutsdb.Tx) error {
+		return c.del(tx, c.Key(key))
+	})
 }
 
+// GetMulti values by multi key. the not exists keys will be ignored.
 func (c *NutsDB) GetMulti(keys []string) map[string]any {
-	panic("implement me")
+	results := make(map[string]any, len(keys))
+	err := c.view(func(tx *nutsdb.Tx) error {
+		for _, key := range keys {
+			if err := c.ContextErr(); err != nil {
+				return err
+			}
+
+			val, err := c.get(tx, c.Key(key))
+			if err != nil {
+				if isNotFound(err) {
+					continue
+				}
+				return err
+			}
+			results[key] = val
+		}
+		return nil
+	})
+
+	if err != nil {
+		c.SetOpErr("GetMulti", "", err)
+		return nil
+	}
+	return results
 }
 
+// SetMulti values by multi key, in one transaction.
 func (c *NutsDB) SetMulti(values map[string]any, ttl time.Duration) (err error) {
-	panic("implement me")
+	return c.update(func(tx *nutsdb.Tx) error {
+		secs := toTTL(ttl)
+		for key, val := range values {
+			if err := c.ContextErr(); err != nil {
+				return err
+			}
+			if err := c.save(tx, c.Key(key), val, secs); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
+// DelMulti values by multi key, in one transaction.
 func (c *NutsDB) DelMulti(keys []string) error {
-	panic("implement me")
+	return c.update(func(tx *nutsdb.Tx) error {
+		for _, key := range keys {
+			if err := c.ContextErr(); err != nil {
+				return err
+			}
+			if err := c.del(tx, c.Key(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
+// Clear all cache data in the bucket. if the prefix option is set, only delete the keys with prefix.
 func (c *NutsDB) Clear() error {
-	panic("implement me")
+	return c.ClearPrefix("")
+}
+
+// ClearPrefix delete the caches which key has the prefix, the driver prefix will be added.
+func (c *NutsDB) ClearPrefix(prefix string) error {
+	prefix = c.Key(prefix)
+	return c.update(func(tx *nutsdb.Tx) error {
+		keys, err := tx.GetKeys(c.bucket)
+		if err != nil {
+			if isNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		for _, key := range keys {
+			if err := c.ContextErr(); err != nil {
+				return err
+			}
+			if !strings.HasPrefix(string(key), prefix) {
+				continue
+			}
+			if err := c.del(tx, string(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
+// Close the db
 func (c *NutsDB) Close() error {
-	panic("implement me")
+	return c.db.Close()
+}
+
+/*************************************************************
+ * methods implements of the cache.Counter
+ *************************************************************/
+
+// Incr increment the key value by 1
+func (c *NutsDB) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1
+func (c *NutsDB) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta.
+// the ttl is only used when the key is created.
+func (c *NutsDB) IncrBy(key string, delta int64, ttl ...time.Duration) (num int64, err error) {
+	err = c.update(func(tx *nutsdb.Tx) error {
+		val, secs, err := c.loadForUpdate(tx, c.Key(key), ttl)
+		if err != nil {
+			return err
+		}
+
+		num = 0
+		if val != nil {
+			if num, err = mathutil.ToInt64(val); err != nil {
+				return cache.ErrNotNumber
+			}
+		}
+
+		num += delta
+		return c.save(tx, c.Key(key), num, secs)
+	})
+	return
+}
+
+// IncrByFloat increment the key value by float delta.
+// the ttl is only used when the key is created.
+func (c *NutsDB) IncrByFloat(key string, delta float64, ttl ...time.Duration) (num float64, err error) {
+	err = c.update(func(tx *nutsdb.Tx) error {
+		val, secs, err := c.loadForUpdate(tx, c.Key(key), ttl)
+		if err != nil {
+			return err
+		}
+
+		num = 0
+		if val != nil {
+			if num, err = mathutil.ToFloat(val); err != nil {
+				return cache.ErrNotNumber
+			}
+		}
+
+		num += delta
+		return c.save(tx, c.Key(key), num, secs)
+	})
+	return
+}
+
+/*************************************************************
+ * methods implements of the cache.ConditionalSetter
+ *************************************************************/
+
+// Add set the key value only if the key does not exist
+func (c *NutsDB) Add(key string, val any, ttl time.Duration) (ok bool, err error) {
+	err = c.update(func(tx *nutsdb.Tx) error {
+		ok = false
+		_, err := tx.Get(c.bucket, []byte(c.Key(key)))
+		if !isNotFound(err) {
+			return err
+		}
+
+		ok = true
+		return c.save(tx, c.Key(key), val, toTTL(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// Replace set the key value only if the key already exists
+func (c *NutsDB) Replace(key string, val any, ttl time.Duration) (ok bool, err error) {
+	err = c.update(func(tx *nutsdb.Tx) error {
+		ok = false
+		if _, err := tx.Get(c.bucket, []byte(c.Key(key))); err != nil {
+			if isNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		ok = true
+		return c.save(tx, c.Key(key), val, toTTL(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (c *NutsDB) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (ok bool, err error) {
+	err = c.update(func(tx *nutsdb.Tx) error {
+		ok = false
+		val, err := c.get(tx, c.Key(key))
+		if err != nil {
+			if isNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		if !c.EqualValue(val, oldVal) {
+			return nil
+		}
+
+		ok = true
+		return c.save(tx, c.Key(key), newVal, toTTL(ttl))
+	})
+	return ok && err == nil, err
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal
+func (c *NutsDB) CompareAndDelete(key string, oldVal any) (ok bool, err error) {
+	err = c.update(func(tx *nutsdb.Tx) error {
+		ok = false
+		val, err := c.get(tx, c.Key(key))
+		if err != nil {
+			if isNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		if !c.EqualValue(val, oldVal) {
+			return nil
+		}
+
+		ok = true
+		return c.del(tx, c.Key(key))
+	})
+	return ok && err == nil, err
+}
+
+/*************************************************************
+ * methods implements of the cache.TTLer
+ *************************************************************/
+
+// TTL get the remaining time to live of the key.
+//
+// NOTICE: nutsdb ttl precision is second, the remaining ttl less than 1 second will return 1 second.
+func (c *NutsDB) TTL(key string) (ttl time.Duration, err error) {
+	err = c.view(func(tx *nutsdb.Tx) error {
+		secs, err := c.ttl(tx, c.Key(key))
+		if err != nil {
+			return err
+		}
+
+		ttl = time.Duration(secs) * time.Second
+		return nil
+	})
+
+	if isNotFound(err) {
+		return 0, cache.ErrNotFound
+	}
+	return
+}
+
+// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration.
+func (c *NutsDB) Expire(key string, ttl time.Duration) error {
+	err := c.update(func(tx *nutsdb.Tx) error {
+		bts, err := tx.Get(c.bucket, []byte(c.Key(key)))
+		if err != nil {
+			return err
+		}
+		return tx.Put(c.bucket, []byte(c.Key(key)), bts, toTTL(ttl))
+	})
+
+	if isNotFound(err) {
+		return cache.ErrNotFound
+	}
+	return err
+}
+
+// Persist remove the key expiration
+func (c *NutsDB) Persist(key string) error {
+	return c.Expire(key, cache.Forever)
+}
+
+// Touch refresh the key expiration to ttl from now
+func (c *NutsDB) Touch(key string, ttl time.Duration) error {
+	if err := c.Expire(key, ttl); err != cache.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+/*************************************************************
+ * helper methods
+ *************************************************************/
+
+func (c *NutsDB) createBucket() error {
+	return c.db.Update(func(tx *nutsdb.Tx) error {
+		if tx.ExistBucket(nutsdb.DataStructureBTree, c.bucket) {
+			return nil
+		}
+		return tx.NewBucket(nutsdb.DataStructureBTree, c.bucket)
+	})
+}
+
+// get and unmarshal the value of the real key
+func (c *NutsDB) get(tx *nutsdb.Tx, key string) (val any, err error) {
+	bts, err := tx.Get(c.bucket, []byte(key))
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.UnmarshalTo(bts, &val)
+	return
+}
+
+// save the value to the real key with ttl seconds
+func (c *NutsDB) save(tx *nutsdb.Tx, key string, val any, secs uint32) error {
+	bts, err := c.MustMarshal(val)
+	if err != nil {
+		return err
+	}
+	return tx.Put(c.bucket, []byte(key), bts, secs)
+}
+
+// delete the real key, not exists key is not an error
+func (c *NutsDB) del(tx *nutsdb.Tx, key string) error {
+	if err := tx.Delete(c.bucket, []byte(key)); err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// get the remaining ttl seconds of the real key, nutsdb.Persistent is never expired.
+func (c *NutsDB) ttl(tx *nutsdb.Tx, key string) (uint32, error) {
+	secs, err := tx.GetTTL(c.bucket, []byte(key))
+	if err != nil {
+		return 0, err
+	}
+
+	if secs < 0 {
+		return nutsdb.Persistent, nil
+	}
+	// at least 1 second, the key is still exists
+	return uint32(max(secs, 1)), nil
+}
+
+// load the key value for update in a transaction. if the key exists, the returned
+// ttl will keep the key remaining ttl, otherwise will use the optional create ttl.
+func (c *NutsDB) loadForUpdate(tx *nutsdb.Tx, key string, ttl []time.Duration) (any, uint32, error) {
+	val, err := c.get(tx, key)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, toTTL(cache.CreateTTL(ttl)), nil
+		}
+		return nil, 0, err
+	}
+
+	secs, err := c.ttl(tx, key)
+	return val, secs, err
+}
+
+// run a read-only transaction, will check the context before run.
+func (c *NutsDB) view(fn func(tx *nutsdb.Tx) error) error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+	return c.db.View(fn)
+}
+
+// run a read-write transaction, will check the context before run.
+func (c *NutsDB) update(fn func(tx *nutsdb.Tx) error) error {
+	if err := c.ContextErr(); err != nil {
+		return err
+	}
+	return c.db.Update(fn)
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, nutsdb.ErrKeyNotFound) ||
+		errors.Is(err, nutsdb.ErrNotFoundKey) ||
+		errors.Is(err, nutsdb.ErrBucketEmpty)
+}
+
+// convert the ttl to nutsdb ttl seconds. the ttl less than 1 second will be rounded up.
+func toTTL(ttl time.Duration) uint32 {
+	if ttl <= 0 {
+		return nutsdb.Persistent
+	}
+	return uint32((ttl + time.Second - 1) / time.Second)
+}
+
+/*************************************************************
+ * open by DSN url
+ *************************************************************/
+
+func init() {
+	cache.RegisterFactory(Name, openURL)
+}
+
+// open by the DSN url. eg: "nutsdb:///path/to/dir?bucket=cache&prefix=app:"
+func openURL(u *url.URL) (cache.Cache, error) {
+	optFns, err := cache.URLOptions(u)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithBucket(cache.URLPath(u), u.Query().Get("bucket"), optFns...)
 }
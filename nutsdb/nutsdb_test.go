@@ -0,0 +1,189 @@
+package nutsdb_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/nutsdb"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func Example() {
+	dir, _ := os.MkdirTemp("", "nutsdb")
+	defer os.RemoveAll(dir)
+
+	c, err := nutsdb.New(dir)
+	if err != nil {
+		panic(err)
+	}
+	defer c.Close()
+
+	key := "name"
+
+	// set
+	c.Set(key, "cache value", cache.Seconds2)
+	fmt.Println(c.Has(key))
+
+	// get
+	val := c.Get(key)
+	fmt.Println(val)
+
+	time.Sleep(2 * time.Second)
+
+	// get expired
+	val2 := c.Get(key)
+	fmt.Println(val2)
+
+	// Output:
+	// true
+	// cache value
+	// <nil>
+}
+
+func newTestDB(t *testing.T, optFns ...func(option *cache.Option)) *nutsdb.NutsDB {
+	c, err := nutsdb.NewWithBucket(t.TempDir(), "test", optFns...)
+	assert.NoErr(t, err)
+	t.Cleanup(func() {
+		assert.NoErr(t, c.Close())
+	})
+	return c
+}
+
+func TestNutsDB_contract(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, c *nutsdb.NutsDB)
+	}{
+		{"set_get_del", func(t *testing.T, c *nutsdb.NutsDB) {
+			is := assert.New(t)
+			is.Nil(c.Get("key"))
+			is.NoErr(c.Set("key", "value", 0))
+			is.True(c.Has("key"))
+			is.Eq("value", c.Get("key"))
+
+			is.NoErr(c.Del("key"))
+			is.False(c.Has("key"))
+			is.NoErr(c.Del("not-exist"))
+		}},
+		{"expire", func(t *testing.T, c *nutsdb.NutsDB) {
+			is := assert.New(t)
+			is.NoErr(c.Set("key", "value", time.Second))
+			is.Eq("value", c.Get("key"))
+			time.Sleep(1100 * time.Millisecond)
+			is.Nil(c.Get("key"))
+		}},
+		{"multi", func(t *testing.T, c *nutsdb.NutsDB) {
+			is := assert.New(t)
+			is.NoErr(c.SetMulti(map[string]any{"k1": "v1", "k2": "v2"}, cache.Seconds3))
+			is.Eq(map[string]any{"k1": "v1", "k2": "v2"}, c.GetMulti([]string{"k1", "k2", "k3"}))
+
+			is.NoErr(c.DelMulti([]string{"k1", "k3"}))
+			is.False(c.Has("k1"))
+			is.True(c.Has("k2"))
+		}},
+		{"clear", func(t *testing.T, c *nutsdb.NutsDB) {
+			is := assert.New(t)
+			is.NoErr(c.SetMulti(map[string]any{"k1": "v1", "k2": "v2"}, 0))
+			is.NoErr(c.Clear())
+			is.False(c.Has("k1"))
+			is.False(c.Has("k2"))
+			is.NoErr(c.Clear())
+		}},
+		{"counter", func(t *testing.T, c *nutsdb.NutsDB) {
+			is := assert.New(t)
+			num, err := c.Incr("num", cache.Seconds3)
+			is.NoErr(err)
+			is.Eq(int64(1), num)
+			num, err = c.IncrBy("num", 5)
+			is.NoErr(err)
+			is.Eq(int64(6), num)
+			num, err = c.Decr("num")
+			is.NoErr(err)
+			is.Eq(int64(5), num)
+
+			f, err := c.IncrByFloat("float", 1.5)
+			is.NoErr(err)
+			is.Eq(1.5, f)
+
+			is.NoErr(c.Set("str", "abc", 0))
+			_, err = c.Incr("str")
+			is.ErrIs(err, cache.ErrNotNumber)
+		}},
+		{"conditional", func(t *testing.T, c *nutsdb.NutsDB) {
+			is := assert.New(t)
+			ok, err := c.Replace("key", "v0", 0)
+			is.NoErr(err)
+			is.False(ok)
+
+			ok, err = c.Add("key", "v1", 0)
+			is.NoErr(err)
+			is.True(ok)
+			ok, err = c.Add("key", "v2", 0)
+			is.NoErr(err)
+			is.False(ok)
+
+			ok, err = c.CompareAndSwap("key", "v2", "v3", 0)
+			is.NoErr(err)
+			is.False(ok)
+			ok, err = c.CompareAndSwap("key", "v1", "v3", 0)
+			is.NoErr(err)
+			is.True(ok)
+
+			ok, err = c.CompareAndDelete("key", "v3")
+			is.NoErr(err)
+			is.True(ok)
+			is.False(c.Has("key"))
+		}},
+		{"ttl", func(t *testing.T, c *nutsdb.NutsDB) {
+			is := assert.New(t)
+			_, err := c.TTL("key")
+			is.ErrIs(err, cache.ErrNotFound)
+			is.ErrIs(c.Expire("key", cache.Seconds3), cache.ErrNotFound)
+			is.NoErr(c.Touch("key", cache.Seconds3))
+
+			is.NoErr(c.Set("key", "value", cache.Seconds3))
+			ttl, err := c.TTL("key")
+			is.NoErr(err)
+			is.True(ttl > 0 && ttl <= cache.Seconds3)
+
+			is.NoErr(c.Persist("key"))
+			ttl, err = c.TTL("key")
+			is.NoErr(err)
+			is.Eq(time.Duration(cache.Forever), ttl)
+			is.Eq("value", c.Get("key"))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newTestDB(t))
+		})
+		t.Run(tt.name+"_with_prefix", func(t *testing.T) {
+			tt.run(t, newTestDB(t, cache.WithPrefix("app:")))
+		})
+	}
+}
+
+func TestNutsDB_clearPrefix(t *testing.T) {
+	is := assert.New(t)
+	c1 := newTestDB(t)
+	is.NoErr(c1.Set("other", "value", 0))
+
+	c2 := *c1
+	c2.WithOptions(cache.WithPrefix("app:"))
+	is.NoErr(c2.Set("key", "value", 0))
+	is.NoErr(c2.Clear())
+
+	is.False(c2.Has("key"))
+	is.True(c1.Has("other"))
+}
+
+func TestNutsDB_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		return newTestDB(t)
+	}, cachetest.WithTTLPrecision(time.Second))
+}
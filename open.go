@@ -0,0 +1,361 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*************************************************************
+ * driver factory registry
+ *************************************************************/
+
+// Factory create a driver by the config url. the url scheme is the factory name.
+//
+// Usage:
+//
+//	func init() {
+//		cache.RegisterFactory("mydriver", func(cfg *url.URL) (cache.Cache, error) {
+//			return New(cfg.Host, cfg.Query().Get("option")), nil
+//		})
+//	}
+type Factory func(cfg *url.URL) (Cache, error)
+
+var (
+	factoryMu sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// RegisterFactory register the driver factory by name, the name is case-insensitive and used as the DSN url scheme.
+// it is called by the driver packages in init(), and panics if the name is registered twice.
+func RegisterFactory(name string, fn Factory) {
+	factoryMu.Lock()
+	defer factoryMu.Unlock()
+
+	name = strings.ToLower(name)
+	if fn == nil {
+		panic("cache: the factory " + name + " is nil")
+	}
+	if _, ok := factories[name]; ok {
+		panic("cache: the factory " + name + " is registered twice")
+	}
+	factories[name] = fn
+}
+
+// Factories get the sorted registered factory names
+func Factories() []string {
+	factoryMu.RLock()
+	defer factoryMu.RUnlock()
+
+	list := make([]string, 0, len(factories))
+	for name := range factories {
+		list = append(list, name)
+	}
+
+	sort.Strings(list)
+	return list
+}
+
+// HasFactory check the factory is registered
+func HasFactory(name string) bool {
+	_, ok := factory(name)
+	return ok
+}
+
+func factory(name string) (Factory, bool) {
+	factoryMu.RLock()
+	defer factoryMu.RUnlock()
+
+	fn, ok := factories[strings.ToLower(name)]
+	return fn, ok
+}
+
+/*************************************************************
+ * open driver by DSN url
+ *************************************************************/
+
+// Open a driver by the DSN url, the scheme is the factory name.
+// the driver package must be imported for register the factory.
+//
+// Usage:
+//
+//	c, err := cache.Open("memory://")
+//	c, err := cache.Open("file:///var/cache/app?encode=1&prefix=app:")
+//	c, err := cache.Open("redis://:pwd@127.0.0.1:6379/2?prefix=app:&pool=20")
+func Open(dsn string) (Cache, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, &ConfigError{Field: "dsn", Err: err}
+	}
+	if u.Scheme == "" {
+		return nil, &ConfigError{Field: "scheme", Err: errors.New("is required")}
+	}
+
+	fn, ok := factory(u.Scheme)
+	if !ok {
+		return nil, &ConfigError{Field: "scheme", Err: fmt.Errorf("unknown scheme %q, forgot import the driver package?", u.Scheme)}
+	}
+
+	return fn(u)
+}
+
+// URLPath get the file path from the DSN url. eg: "file:///var/cache" => "/var/cache", "file://tmp/app" => "tmp/app"
+func URLPath(u *url.URL) string {
+	return u.Host + u.Path
+}
+
+// URLOptions parse the common driver options from the DSN url query: prefix, encode, debug
+func URLOptions(u *url.URL) ([]func(opt *Option), error) {
+	q := u.Query()
+	optFns := make([]func(opt *Option), 0, 3)
+	if q.Has("prefix") {
+		optFns = append(optFns, WithPrefix(q.Get("prefix")))
+	}
+
+	for _, name := range []string{"encode", "debug"} {
+		if !q.Has(name) {
+			continue
+		}
+
+		val, err := strconv.ParseBool(q.Get(name))
+		if err != nil {
+			return nil, &ConfigError{Field: name, Err: fmt.Errorf("invalid bool %q", q.Get(name))}
+		}
+
+		if name == "encode" {
+			optFns = append(optFns, WithEncode(val))
+		} else {
+			optFns = append(optFns, WithDebug(val))
+		}
+	}
+	return optFns, nil
+}
+
+// URLInt get an int value from the DSN url query. returns the def if not exists.
+func URLInt(u *url.URL, name string, def int) (int, error) {
+	q := u.Query()
+	if !q.Has(name) {
+		return def, nil
+	}
+
+	val, err := strconv.Atoi(q.Get(name))
+	if err != nil {
+		return 0, &ConfigError{Field: name, Err: fmt.Errorf("invalid int %q", q.Get(name))}
+	}
+	return val, nil
+}
+
+// URLDuration get a duration value from the DSN url query. eg: "10s", "5m". returns the def if not exists.
+func URLDuration(u *url.URL, name string, def time.Duration) (time.Duration, error) {
+	q := u.Query()
+	if !q.Has(name) {
+		return def, nil
+	}
+
+	val, err := time.ParseDuration(q.Get(name))
+	if err != nil {
+		return 0, &ConfigError{Field: name, Err: fmt.Errorf("invalid duration %q", q.Get(name))}
+	}
+	return val, nil
+}
+
+func init() {
+	RegisterFactory(DvrMemory, func(u *url.URL) (Cache, error) {
+		// the max size is not enforced by the MemoryCache
+		if u.Query().Has("size") {
+			return nil, &ConfigError{Field: "size", Err: errors.New("is not supported by memory driver, use the gcache driver")}
+		}
+		return NewMemoryCache(), nil
+	})
+
+	RegisterFactory(DvrFile, func(u *url.URL) (Cache, error) {
+		optFns, err := URLOptions(u)
+		if err != nil {
+			return nil, err
+		}
+
+		c := NewFileCache(URLPath(u))
+		c.WithOptions(optFns...)
+		return c, nil
+	})
+}
+
+/*************************************************************
+ * load config for manager
+ *************************************************************/
+
+// ConfigError the invalid config error, the Field is the path of the bad field.
+type ConfigError struct {
+	// Field path. eg: "drivers.session.pool"
+	Field string
+	Err   error
+}
+
+// Error string
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("cache config: %s: %v", e.Field, e.Err)
+}
+
+// Unwrap the error
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Config for the Manager.LoadConfig
+//
+// JSON example:
+//
+//	{
+//		"default": "local",
+//		"drivers": {
+//			"local": "memory://",
+//			"shared": "redis://:pwd@127.0.0.1:6379/2?prefix=app:"
+//		}
+//	}
+type Config struct {
+	// Default driver name. default is the first registered driver.
+	Default string `json:"default" yaml:"default"`
+	// Drivers the DSN url of each driver, the key is the driver name.
+	Drivers map[string]string `json:"drivers" yaml:"drivers"`
+}
+
+// ConfigFromEnv read the config from the ENV vars with the prefix. eg: prefix is "CACHE"
+//
+//	CACHE_DEFAULT=local
+//	CACHE_DRIVER_LOCAL=memory://
+//	CACHE_DRIVER_SHARED=redis://127.0.0.1:6379/2
+//
+// the driver names are in lower case, eg: "local", "shared"
+func ConfigFromEnv(prefix string) *Config {
+	prefix = strings.ToUpper(prefix) + "_"
+	cfg := &Config{
+		Default: strings.ToLower(os.Getenv(prefix + "DEFAULT")),
+		Drivers: make(map[string]string),
+	}
+
+	for _, kv := range os.Environ() {
+		key, val, _ := strings.Cut(kv, "=")
+		if name, ok := strings.CutPrefix(key, prefix+"DRIVER_"); ok && name != "" {
+			cfg.Drivers[strings.ToLower(name)] = val
+		}
+	}
+	return cfg
+}
+
+// LoadConfig open the drivers by config and register them. the cfg can be:
+//
+//   - Config, *Config
+//   - map[string]string the DSN map of drivers, the key is the driver name.
+//   - map[string]any decoded from YAML/JSON, same as the Config. eg: {"default": "local", "drivers": {"local": "memory://"}}
+//
+// If any driver is failed to open, the opened drivers will be closed, and nothing registered.
+func (m *Manager) LoadConfig(cfg any) error {
+	c, err := toConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(c.Drivers))
+	for name := range c.Drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	drivers := make(map[string]Cache, len(names))
+	for _, name := range names {
+		field := "drivers." + name
+		driver, err := Open(c.Drivers[name])
+		if err != nil {
+			var ce *ConfigError
+			if errors.As(err, &ce) {
+				errs = append(errs, &ConfigError{Field: field + "." + ce.Field, Err: ce.Err})
+			} else {
+				errs = append(errs, &ConfigError{Field: field, Err: err})
+			}
+			continue
+		}
+		drivers[name] = driver
+	}
+
+	if c.Default != "" {
+		if _, ok := c.Drivers[c.Default]; !ok && !m.has(c.Default) {
+			errs = append(errs, &ConfigError{Field: "default", Err: fmt.Errorf("driver %q is not configured", c.Default)})
+		}
+	}
+
+	if len(errs) > 0 {
+		for _, driver := range drivers {
+			_ = driver.Close()
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, name := range names {
+		m.Register(name, drivers[name])
+	}
+	if c.Default != "" {
+		m.DefaultUse(c.Default)
+	}
+	return nil
+}
+
+// LoadConfig open the drivers by config and register them to the default manager.
+func LoadConfig(cfg any) error {
+	return std.LoadConfig(cfg)
+}
+
+// convert the cfg value to Config
+func toConfig(cfg any) (*Config, error) {
+	switch v := cfg.(type) {
+	case Config:
+		return &v, nil
+	case *Config:
+		if v == nil {
+			return nil, &ConfigError{Field: "config", Err: errors.New("is nil")}
+		}
+		return v, nil
+	case map[string]string:
+		return &Config{Drivers: v}, nil
+	case map[string]any:
+		return mapToConfig(v)
+	default:
+		return nil, &ConfigError{Field: "config", Err: fmt.Errorf("unsupported type %T", cfg)}
+	}
+}
+
+func mapToConfig(mp map[string]any) (*Config, error) {
+	cfg := &Config{Drivers: make(map[string]string)}
+	for key, val := range mp {
+		switch key {
+		case "default":
+			s, ok := val.(string)
+			if !ok {
+				return nil, &ConfigError{Field: key, Err: fmt.Errorf("must be string, got %T", val)}
+			}
+			cfg.Default = s
+		case "drivers":
+			drivers, ok := val.(map[string]any)
+			if !ok {
+				return nil, &ConfigError{Field: key, Err: fmt.Errorf("must be map, got %T", val)}
+			}
+
+			for name, dsn := range drivers {
+				s, ok := dsn.(string)
+				if !ok {
+					return nil, &ConfigError{Field: key + "." + name, Err: fmt.Errorf("must be DSN string, got %T", dsn)}
+				}
+				cfg.Drivers[name] = s
+			}
+		default:
+			return nil, &ConfigError{Field: key, Err: errors.New("unknown field")}
+		}
+	}
+	return cfg, nil
+}
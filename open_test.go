@@ -0,0 +1,139 @@
+package cache_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func ExampleOpen() {
+	c, err := cache.Open("memory://")
+	if err != nil {
+		panic(err)
+	}
+
+	_ = c.Set("name", "cache value", cache.TwoMinutes)
+	fmt.Println(c.Get("name"))
+
+	// Output:
+	// cache value
+}
+
+func TestOpen(t *testing.T) {
+	is := assert.New(t)
+	is.Contains(cache.Factories(), cache.DvrMemory)
+	is.Contains(cache.Factories(), cache.DvrFile)
+
+	c, err := cache.Open("memory://")
+	is.NoErr(err)
+	is.NotNil(c.(*cache.MemoryCache))
+
+	dir := t.TempDir()
+	c, err = cache.Open("file://" + dir + "?prefix=app:&encode=true")
+	is.NoErr(err)
+	is.NoErr(c.Set("key", "value", 0))
+	is.Eq("value", c.Get("key"))
+	is.Eq("app:key", c.(*cache.FileCache).Key("key"))
+
+	// errors
+	var ce *cache.ConfigError
+	_, err = cache.Open("not-exists://")
+	is.True(errors.As(err, &ce))
+	is.Eq("scheme", ce.Field)
+
+	_, err = cache.Open("/path/to/file")
+	is.ErrMsg(err, "cache config: scheme: is required")
+
+	_, err = cache.Open("memory://?size=100")
+	is.ErrMsg(err, "cache config: size: is not supported by memory driver, use the gcache driver")
+
+	_, err = cache.Open("file:///tmp?encode=yes")
+	is.ErrMsg(err, `cache config: encode: invalid bool "yes"`)
+
+	is.Panics(func() {
+		cache.RegisterFactory(cache.DvrMemory, func(u *url.URL) (cache.Cache, error) {
+			return nil, nil
+		})
+	})
+}
+
+func TestManager_LoadConfig(t *testing.T) {
+	is := assert.New(t)
+
+	// from JSON
+	var mp map[string]any
+	is.NoErr(json.Unmarshal([]byte(`{
+		"default": "local",
+		"drivers": {"file": "file://`+t.TempDir()+`", "local": "memory://"}
+	}`), &mp))
+
+	m := cache.NewManager()
+	is.NoErr(m.LoadConfig(mp))
+	is.Eq("local", m.DefName())
+	is.NotNil(m.Driver("file"))
+	is.NoErr(m.Set("key", "value", 0))
+	is.Eq("value", m.Driver("local").Get("key"))
+
+	// from struct
+	m = cache.NewManager()
+	is.NoErr(m.LoadConfig(cache.Config{Drivers: map[string]string{"b": "memory://", "a": "memory://"}}))
+	is.Eq("a", m.DefName())
+
+	// from map
+	is.NoErr(m.LoadConfig(map[string]string{"c": "memory://"}))
+	is.Eq("a", m.DefName())
+	is.NotNil(m.Driver("c"))
+
+	// from ENV
+	t.Setenv("TEST_CACHE_DEFAULT", "SHARED")
+	t.Setenv("TEST_CACHE_DRIVER_SHARED", "memory://")
+	cfg := cache.ConfigFromEnv("test_cache")
+	is.Eq(&cache.Config{Default: "shared", Drivers: map[string]string{"shared": "memory://"}}, cfg)
+	is.NoErr(m.LoadConfig(cfg))
+	is.Eq("shared", m.DefName())
+}
+
+func TestManager_LoadConfig_errors(t *testing.T) {
+	is := assert.New(t)
+	m := cache.NewManager()
+
+	err := m.LoadConfig(map[string]any{
+		"default": "other",
+		"drivers": map[string]any{
+			"bad":   "file:///tmp?encode=yes",
+			"good":  "memory://",
+			"typo":  "memroy://",
+			"wrong": 123,
+		},
+	})
+	is.ErrMsg(err, "cache config: drivers.wrong: must be DSN string, got int")
+
+	err = m.LoadConfig(cache.Config{
+		Default: "other",
+		Drivers: map[string]string{
+			"bad":  "file:///tmp?encode=yes",
+			"good": "memory://",
+			"typo": "memroy://",
+		},
+	})
+	is.Err(err)
+	is.Eq(`cache config: drivers.bad.encode: invalid bool "yes"
+cache config: drivers.typo.scheme: unknown scheme "memroy", forgot import the driver package?
+cache config: default: driver "other" is not configured`, err.Error())
+	// nothing registered
+	is.Nil(m.Driver("good"))
+
+	var ce *cache.ConfigError
+	is.True(errors.As(err, &ce))
+	is.Eq("drivers.bad.encode", ce.Field)
+
+	is.ErrMsg(m.LoadConfig(map[string]any{"driver": "memory://"}), "cache config: driver: unknown field")
+	is.ErrMsg(m.LoadConfig(map[string]any{"default": 1}), "cache config: default: must be string, got int")
+	is.ErrMsg(m.LoadConfig(map[string]any{"drivers": "memory://"}), "cache config: drivers: must be map, got string")
+	is.ErrMsg(m.LoadConfig([]string{"memory://"}), "cache config: config: unsupported type []string")
+}
@@ -3,12 +3,19 @@
 package redis
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/gookit/cache"
+	"github.com/gookit/cache/internal/rscript"
+	"github.com/gookit/gsr"
 )
 
 // Name driver name
@@ -35,6 +42,7 @@ func New(url, pwd string, dbNum int) *Redigo {
 		url: url, pwd: pwd, dbNum: dbNum,
 	}
 
+	rc.SetName(Name)
 	return rc
 }
 
@@ -46,7 +54,7 @@ func Connect(url, pwd string, dbNum int) *Redigo {
 // Connect to redis server
 func (c *Redigo) Connect() *Redigo {
 	c.pool = newPool(c.url, c.pwd, c.dbNum)
-	c.Logf("connect to server %s db is %d", c.url, c.dbNum)
+	c.Log(slog.LevelInfo, "connect to redis server", slog.String("url", c.url), slog.Int("db", c.dbNum))
 
 	return c
 }
@@ -55,11 +63,25 @@ func (c *Redigo) Connect() *Redigo {
  * methods implements of the gsr.SimpleCacher
  *************************************************************/
 
+// WithContext returns a copy of the driver for operate with ctx
+func (c *Redigo) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *c
+	cp.SetContext(ctx)
+	return &cp
+}
+
 // Get value by key
 func (c *Redigo) Get(key string) any {
 	bts, err := redis.Bytes(c.exec("Get", c.Key(key)))
+	if err != nil {
+		// not exists is not an error
+		if err != redis.ErrNil {
+			c.SetOpErr("Get", key, err)
+		}
+		return nil
+	}
 
-	return c.Unmarshal(bts, err)
+	return c.Unmarshal(bts, nil)
 }
 
 // GetAs get cache and unmarshal to ptr
@@ -79,7 +101,7 @@ func (c *Redigo) Set(key string, val any, ttl time.Duration) (err error) {
 		return err
 	}
 
-	_, err = c.exec("SetEx", c.Key(key), int64(ttl/time.Second), val)
+	_, err = c.exec("Set", setArgs(c.Key(key), val, ttl)...)
 	return
 }
 
@@ -93,16 +115,13 @@ func (c *Redigo) Del(key string) (err error) {
 func (c *Redigo) Has(key string) bool {
 	// return 0 OR 1
 	one, err := redis.Int(c.exec("Exists", c.Key(key)))
-	c.SetLastErr(err)
+	c.SetOpErr("Has", key, err)
 
 	return one == 1
 }
 
 // GetMulti values by keys
 func (c *Redigo) GetMulti(keys []string) map[string]any {
-	conn := c.pool.Get()
-	defer conn.Close()
-
 	args := make([]any, 0, len(keys))
 	for _, key := range keys {
 		args = append(args, c.Key(key))
@@ -110,13 +129,20 @@ func (c *Redigo) GetMulti(keys []string) map[string]any {
 
 	list, err := redis.Values(c.exec("MGet", args...))
 	if err != nil {
-		c.SetLastErr(err)
+		c.SetOpErr("GetMulti", "", err)
 		return nil
 	}
 
 	values := make(map[string]any, len(keys))
 	for i, val := range list {
-		values[keys[i]] = val
+		if val == nil { // not exists
+			continue
+		}
+
+		bts, err := redis.Bytes(val, nil)
+		if val = c.Unmarshal(bts, err); val != nil {
+			values[keys[i]] = val
+		}
 	}
 
 	return values
@@ -124,7 +150,10 @@ func (c *Redigo) GetMulti(keys []string) map[string]any {
 
 // SetMulti values
 func (c *Redigo) SetMulti(values map[string]any, ttl time.Duration) (err error) {
-	conn := c.pool.Get()
+	conn, err := c.conn()
+	if err != nil {
+		return err
+	}
 	defer conn.Close()
 
 	// open multi
@@ -133,14 +162,18 @@ func (c *Redigo) SetMulti(values map[string]any, ttl time.Duration) (err error)
 		return err
 	}
 
-	ttlSec := int64(ttl / time.Second)
 	for key, val := range values {
-		// bs, _ := cache.Marshal(val)
-		conn.Send("SetEx", c.Key(key), ttlSec, val)
+		if val, err = c.Marshal(val); err != nil {
+			_, _ = conn.Do("Discard")
+			return err
+		}
+		if err = conn.Send("Set", setArgs(c.Key(key), val, ttl)...); err != nil {
+			return err
+		}
 	}
 
 	// do exec
-	_, err = redis.Ints(conn.Do("Exec"))
+	_, err = redis.DoContext(conn, c.Context(), "Exec")
 	return
 }
 
@@ -160,12 +193,296 @@ func (c *Redigo) Close() error {
 	return c.pool.Close()
 }
 
-// Clear all caches
+// Clear all caches. if the prefix is set, will only delete the keys with the prefix.
 func (c *Redigo) Clear() error {
-	_, err := c.exec("FlushDb")
+	return c.clear(c.Key(""))
+}
+
+// ClearPrefix delete the caches which key has the prefix, the driver prefix will be added.
+func (c *Redigo) ClearPrefix(prefix string) error {
+	return c.clear(c.Key(prefix))
+}
+
+// delete the keys by the real key prefix, will flush the db if prefix is empty.
+func (c *Redigo) clear(prefix string) error {
+	conn, err := c.conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := c.Context()
+	if prefix == "" {
+		_, err = redis.DoContext(conn, ctx, "FlushDb")
+		return err
+	}
+
+	cursor := 0
+	for {
+		values, err := redis.Values(redis.DoContext(conn, ctx, "Scan", cursor, "MATCH", prefix+"*", "COUNT", 100))
+		if err != nil {
+			return err
+		}
+
+		var keys []any
+		if _, err = redis.Scan(values, &cursor, &keys); err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if _, err = redis.DoContext(conn, ctx, "Del", keys...); err != nil {
+				return err
+			}
+		}
+
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+/*************************************************************
+ * methods implements of the cache.Counter
+ *************************************************************/
+
+// Incr increment the key value by 1
+func (c *Redigo) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, 1, ttl...)
+}
+
+// Decr decrement the key value by 1
+func (c *Redigo) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return c.IncrBy(key, -1, ttl...)
+}
+
+// IncrBy increment the key value by delta.
+// the ttl is only used when the key is created.
+func (c *Redigo) IncrBy(key string, delta int64, ttl ...time.Duration) (int64, error) {
+	if t := cache.CreateTTL(ttl); t > 0 {
+		return redis.Int64(c.incrWithTTL("IncrBy", key, delta, t))
+	}
+	return redis.Int64(c.exec("IncrBy", c.Key(key), delta))
+}
+
+// IncrByFloat increment the key value by float delta.
+// the ttl is only used when the key is created.
+func (c *Redigo) IncrByFloat(key string, delta float64, ttl ...time.Duration) (float64, error) {
+	if t := cache.CreateTTL(ttl); t > 0 {
+		return redis.Float64(c.incrWithTTL("IncrByFloat", key, delta, t))
+	}
+	return redis.Float64(c.exec("IncrByFloat", c.Key(key), delta))
+}
+
+// create the key with ttl if not exists, then do increment in a transaction.
+func (c *Redigo) incrWithTTL(commandName, key string, delta any, ttl time.Duration) (any, error) {
+	conn, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	key = c.Key(key)
+	_ = conn.Send("Multi")
+	_ = conn.Send("Set", key, 0, "PX", ttl.Milliseconds(), "NX")
+	_ = conn.Send(commandName, key, delta)
+
+	replies, err := redis.Values(redis.DoContext(conn, c.Context(), "Exec"))
+	if err != nil {
+		return nil, err
+	}
+	return replies[1], nil
+}
+
+/*************************************************************
+ * methods implements of the cache.ConditionalSetter
+ *************************************************************/
+
+// compare and swap script, see rscript.CompareAndSwap
+var casScript = redis.NewScript(1, rscript.CompareAndSwap)
+
+// compare and delete script, see rscript.CompareAndDelete
+var cadScript = redis.NewScript(1, rscript.CompareAndDelete)
+
+// Add set the key value only if the key does not exist
+func (c *Redigo) Add(key string, val any, ttl time.Duration) (bool, error) {
+	return c.setWithCond("NX", key, val, ttl)
+}
+
+// Replace set the key value only if the key already exists
+func (c *Redigo) Replace(key string, val any, ttl time.Duration) (bool, error) {
+	return c.setWithCond("XX", key, val, ttl)
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (c *Redigo) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (bool, error) {
+	oldVal, err := c.Marshal(oldVal)
+	if err != nil {
+		return false, err
+	}
+	if newVal, err = c.Marshal(newVal); err != nil {
+		return false, err
+	}
+
+	conn, err := c.conn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	n, err := redis.Int(casScript.DoContext(c.Context(), conn, c.Key(key), oldVal, newVal, ttl.Milliseconds()))
+	return n == 1, err
+}
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal
+func (c *Redigo) CompareAndDelete(key string, oldVal any) (bool, error) {
+	oldVal, err := c.Marshal(oldVal)
+	if err != nil {
+		return false, err
+	}
+
+	conn, err := c.conn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	n, err := redis.Int(cadScript.DoContext(c.Context(), conn, c.Key(key), oldVal))
+	return n == 1, err
+}
+
+// set value by key with condition NX or XX
+func (c *Redigo) setWithCond(cond, key string, val any, ttl time.Duration) (bool, error) {
+	val, err := c.Marshal(val)
+	if err != nil {
+		return false, err
+	}
+
+	args := setArgs(c.Key(key), val, ttl)
+	reply, err := c.exec("Set", append(args, cond)...)
+	return reply != nil, err
+}
+
+/*************************************************************
+ * methods implements of the cache.TTLer
+ *************************************************************/
+
+// TTL get the remaining time to live of the key
+func (c *Redigo) TTL(key string) (time.Duration, error) {
+	ms, err := redis.Int64(c.exec("PTTL", c.Key(key)))
+	if err != nil {
+		return 0, err
+	}
+
+	switch ms {
+	case -2: // key not exists
+		return 0, cache.ErrNotFound
+	case -1: // key has no expiration
+		return cache.Forever, nil
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Expire set a new ttl for the key. ttl <= 0 will remove the key expiration.
+func (c *Redigo) Expire(key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return c.Persist(key)
+	}
+
+	ok, err := redis.Bool(c.exec("PExpire", c.Key(key), ttl.Milliseconds()))
+	if err == nil && !ok {
+		return cache.ErrNotFound
+	}
+	return err
+}
+
+// Persist remove the key expiration
+func (c *Redigo) Persist(key string) error {
+	ok, err := redis.Bool(c.exec("Persist", c.Key(key)))
+	if err != nil || ok {
+		return err
+	}
+
+	// returns 0 if key not exists or has no expiration
+	if !c.Has(key) {
+		return cache.ErrNotFound
+	}
+	return nil
+}
+
+// Touch refresh the key expiration to ttl from now
+func (c *Redigo) Touch(key string, ttl time.Duration) error {
+	if err := c.Expire(key, ttl); err != cache.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+/*************************************************************
+ * methods for pub/sub
+ *************************************************************/
+
+// SubscribeRetryInterval the wait time before re-subscribe after the connection is broken
+var SubscribeRetryInterval = time.Second
+
+// Publish the message to the channel
+func (c *Redigo) Publish(ctx context.Context, channel string, msg []byte) error {
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = redis.DoContext(conn, ctx, "Publish", channel, msg)
 	return err
 }
 
+// Subscribe the channel and call the handler for each message. it blocks until the ctx is done.
+//
+// The broken connection will be reconnected and re-subscribed after SubscribeRetryInterval,
+// the onSubscribed(can be nil) will be called after each (re)subscribed.
+func (c *Redigo) Subscribe(ctx context.Context, channel string, handler func(msg []byte), onSubscribed func()) error {
+	for {
+		err := c.subscribe(ctx, channel, handler, onSubscribed)
+		if ctx.Err() != nil {
+			return nil
+		}
+		c.LogErr("subscribe channel failed, will retry", err,
+			slog.String("channel", channel), slog.Duration("retry", SubscribeRetryInterval))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(SubscribeRetryInterval):
+		}
+	}
+}
+
+func (c *Redigo) subscribe(ctx context.Context, channel string, handler func(msg []byte), onSubscribed func()) error {
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	psc := redis.PubSubConn{Conn: conn}
+	defer psc.Close()
+
+	if err = psc.Subscribe(channel); err != nil {
+		return err
+	}
+
+	for {
+		switch v := psc.ReceiveContext(ctx).(type) {
+		case redis.Message:
+			handler(v.Data)
+		case redis.Subscription:
+			if v.Kind == "subscribe" && onSubscribed != nil {
+				onSubscribed()
+			}
+		case error:
+			return v
+		}
+	}
+}
+
 /*************************************************************
  * helper methods
  *************************************************************/
@@ -185,26 +502,39 @@ func (c *Redigo) String() string {
 	return fmt.Sprintf("connection info. url: %s, pwd: %s, dbNum: %d", c.url, pwd, c.dbNum)
 }
 
+// build the args for the SET command. ttl <= 0 means the key will never expire.
+func setArgs(key string, val any, ttl time.Duration) []any {
+	if ttl > 0 {
+		return []any{key, val, "PX", max(ttl.Milliseconds(), 1)}
+	}
+	return []any{key, val}
+}
+
 // actually do the redis cmds, args[0] must be the key name.
 func (c *Redigo) exec(commandName string, args ...any) (reply any, err error) {
 	if len(args) < 1 {
 		return nil, errors.New("missing required arguments")
 	}
 
-	conn := c.pool.Get()
+	conn, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
 	defer conn.Close()
 
-	if c.IsDebug() {
+	if c.DebugEnabled() {
 		st := time.Now()
-		reply, err = conn.Do(commandName, args...)
-		c.Logf(
-			"operate redis cache. command: %s, key: %v, elapsed time: %.03f\n",
-			commandName, args[0], time.Since(st).Seconds()*1000,
-		)
+		reply, err = redis.DoContext(conn, c.Context(), commandName, args...)
+		c.DebugOp(commandName, fmt.Sprint(args[0]), st, err)
 		return
 	}
 
-	return conn.Do(commandName, args...)
+	return redis.DoContext(conn, c.Context(), commandName, args...)
+}
+
+// get a connection from the pool, it will be bounded by the context.
+func (c *Redigo) conn() (redis.Conn, error) {
+	return c.pool.GetContext(c.Context())
 }
 
 // create new pool
@@ -213,8 +543,8 @@ func newPool(url, password string, dbNum int) *redis.Pool {
 		MaxIdle: 5,
 		// timeout
 		IdleTimeout: 240 * time.Second,
-		Dial: func() (redis.Conn, error) {
-			c, err := redis.Dial("tcp", url)
+		DialContext: func(ctx context.Context) (redis.Conn, error) {
+			c, err := redis.DialContext(ctx, "tcp", url)
 			if err != nil {
 				return nil, err
 			}
@@ -235,3 +565,57 @@ func newPool(url, password string, dbNum int) *redis.Pool {
 		},
 	}
 }
+
+/*************************************************************
+ * open by DSN url
+ *************************************************************/
+
+func init() {
+	cache.RegisterFactory("redis", openURL)
+	cache.RegisterFactory(Name, openURL)
+}
+
+// open by the DSN url. eg: "redis://:pwd@127.0.0.1:6379/2?prefix=app:&pool=20"
+func openURL(u *url.URL) (cache.Cache, error) {
+	addr, pwd, dbNum, err := parseURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := cache.URLInt(u, "pool", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	optFns, err := cache.URLOptions(u)
+	if err != nil {
+		return nil, err
+	}
+
+	c := New(addr, pwd, dbNum)
+	c.WithOptions(optFns...)
+	c.Connect()
+	if pool > 0 {
+		c.pool.MaxActive = pool
+		c.pool.MaxIdle = min(c.pool.MaxIdle, pool)
+	}
+	return c, nil
+}
+
+// parse the address, password and db number from the url
+func parseURL(u *url.URL) (addr, pwd string, dbNum int, err error) {
+	addr = u.Host
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	if u.User != nil {
+		pwd, _ = u.User.Password()
+	}
+
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if dbNum, err = strconv.Atoi(db); err != nil {
+			err = &cache.ConfigError{Field: "db", Err: fmt.Errorf("invalid db number %q", db)}
+		}
+	}
+	return
+}
@@ -1,10 +1,17 @@
 package redis_test
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"log/slog"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/cache/cachetest/fakeserver"
 	"github.com/gookit/cache/redis"
 	"github.com/gookit/goutil/dump"
 	"github.com/gookit/goutil/strutil"
@@ -28,6 +35,21 @@ func Example() {
 	fmt.Print(val)
 }
 
+// the fake redis server address for tests
+var srvAddr string
+
+func TestMain(m *testing.M) {
+	srv, err := fakeserver.NewRedis()
+	if err != nil {
+		panic(err)
+	}
+
+	srvAddr = srv.Addr()
+	code := m.Run()
+	_ = srv.Close()
+	os.Exit(code)
+}
+
 var c *redis.Redigo
 
 func getC() *redis.Redigo {
@@ -35,7 +57,7 @@ func getC() *redis.Redigo {
 		return c
 	}
 
-	c = redis.New("127.0.0.1:6379", "", 0).Connect()
+	c = redis.New(srvAddr, "", 0).Connect()
 	c.WithOptions(cache.WithPrefix("rdg"), cache.WithEncode(true))
 	return c
 }
@@ -96,3 +118,194 @@ func TestRedigo_object(t *testing.T) {
 	assert.False(t, c.Has(key))
 	assert.Empty(t, c.Get(key))
 }
+
+func TestRedigo_counter(t *testing.T) {
+	c := getC()
+
+	var _ cache.Counter = c
+	key := strutil.RandomCharsV2(12)
+
+	num, err := c.Incr(key, cache.Seconds3)
+	assert.NoError(t, err)
+	assert.Eq(t, int64(1), num)
+
+	num, err = c.IncrBy(key, 5)
+	assert.NoError(t, err)
+	assert.Eq(t, int64(6), num)
+
+	num, err = c.Decr(key)
+	assert.NoError(t, err)
+	assert.Eq(t, int64(5), num)
+
+	f, err := c.IncrByFloat(key, 0.5)
+	assert.NoError(t, err)
+	assert.Eq(t, 5.5, f)
+
+	assert.NoError(t, c.Del(key))
+}
+
+func TestRedigo_conditional(t *testing.T) {
+	c := getC()
+
+	var _ cache.ConditionalSetter = c
+	key := strutil.RandomCharsV2(12)
+
+	ok, err := c.Replace(key, "v0", cache.Seconds3)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = c.Add(key, "v1", cache.Seconds3)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = c.Add(key, "v2", cache.Seconds3)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = c.CompareAndSwap(key, "v0", "v2", cache.Seconds3)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = c.CompareAndSwap(key, "v1", "v2", cache.Seconds3)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Eq(t, "v2", c.Get(key))
+
+	var _ cache.CompareDeleter = c
+	ok, err = c.CompareAndDelete(key, "v1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = c.CompareAndDelete(key, "v2")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, c.Has(key))
+}
+
+func TestRedigo_ttl(t *testing.T) {
+	c := getC()
+
+	var _ cache.TTLer = c
+	key := strutil.RandomCharsV2(12)
+
+	_, err := c.TTL(key)
+	assert.ErrIs(t, err, cache.ErrNotFound)
+	assert.ErrIs(t, c.Expire(key, cache.Seconds3), cache.ErrNotFound)
+	assert.ErrIs(t, c.Persist(key), cache.ErrNotFound)
+	assert.NoError(t, c.Touch(key, cache.Seconds3))
+
+	assert.NoError(t, c.Set(key, "value", cache.OneMinutes))
+	assert.NoError(t, c.Persist(key))
+	ttl, err := c.TTL(key)
+	assert.NoError(t, err)
+	assert.Eq(t, time.Duration(cache.Forever), ttl)
+
+	assert.NoError(t, c.Expire(key, cache.OneMinutes))
+	ttl, err = c.TTL(key)
+	assert.NoError(t, err)
+	assert.Gt(t, ttl, cache.Seconds30)
+
+	assert.NoError(t, c.Del(key))
+}
+
+func TestRedigo_WithContext(t *testing.T) {
+	c := getC()
+	key := strutil.RandomCharsV2(12)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cc := c.WithContext(ctx)
+	assert.NoError(t, cc.Set(key, "value", cache.Seconds3))
+	assert.Eq(t, "value", cc.Get(key))
+
+	cancel()
+	assert.Err(t, cc.Del(key))
+	assert.Eq(t, "value", c.Get(key))
+	assert.NoError(t, c.Del(key))
+}
+
+func TestRedigo_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		c := redis.Connect(srvAddr, "", 0)
+		c.WithOptions(cache.WithPrefix("rdg-suite:"), cache.WithEncode(true))
+		t.Cleanup(func() {
+			assert.NoErr(t, c.Clear())
+			assert.NoErr(t, c.Close())
+		})
+		return c
+	})
+}
+
+func TestRedigo_open(t *testing.T) {
+	is := assert.New(t)
+	c, err := cache.Open("redis://:@" + srvAddr + "/1?prefix=open:&encode=true&pool=5")
+	is.NoErr(err)
+	defer c.Close()
+
+	rc := c.(*redis.Redigo)
+	is.Eq("open:key", rc.Key("key"))
+	is.NoErr(rc.Set("key", "value", 0))
+	is.Eq("value", rc.Get("key"))
+	is.NoErr(rc.Clear())
+
+	is.True(cache.HasFactory(cache.DvrRedis))
+	_, err = cache.Open("redigo://" + srvAddr + "/db1")
+	is.ErrMsg(err, `cache config: db: invalid db number "db1"`)
+}
+
+func TestRedigo_log(t *testing.T) {
+	is := assert.New(t)
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := redis.New(srvAddr, "", 0)
+	c.WithOptions(cache.WithPrefix("rdg-log:"), cache.WithSlog(logger))
+	c.Connect()
+	defer c.Close()
+
+	is.StrContains(buf.String(), `msg="connect to redis server" driver=redigo`)
+	is.NoErr(c.Set("key", "value", 0))
+	is.StrContains(buf.String(), `level=DEBUG msg="cache operation" driver=redigo op=Set key=rdg-log:key duration=`)
+
+	// not exists is not an error
+	buf.Reset()
+	is.Nil(c.Get("not-exist"))
+	is.NotContains(buf.String(), "level=ERROR")
+}
+
+// run the suite and lua scripts on a real redis server, eg: REDIS_ADDR=127.0.0.1:6379
+func TestRedigo_realServer(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("skip: the REDIS_ADDR is not set")
+	}
+
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		c := redis.Connect(addr, "", 0)
+		c.WithOptions(cache.WithPrefix("rdg-real:"), cache.WithEncode(true))
+		t.Cleanup(func() {
+			assert.NoErr(t, c.Clear())
+			assert.NoErr(t, c.Close())
+		})
+		return c
+	})
+
+	// the scripts are reloaded after flushed. NOSCRIPT on EVALSHA
+	is := assert.New(t)
+	c := redis.Connect(addr, "", 0)
+	c.WithOptions(cache.WithPrefix("rdg-real:"), cache.WithEncode(true))
+	defer c.Close()
+
+	flush := func() {
+		conn := c.Pool().Get()
+		defer conn.Close()
+		_, err := conn.Do("SCRIPT", "FLUSH")
+		is.NoErr(err)
+	}
+
+	flush()
+	is.NoErr(c.Set("cas", "v1", 0))
+	ok, err := c.CompareAndSwap("cas", "v1", "v2", time.Minute)
+	is.NoErr(err)
+	is.True(ok)
+	flush()
+	ok, err = c.CompareAndDelete("cas", "v2")
+	is.NoErr(err)
+	is.True(ok)
+	is.False(c.Has("cas"))
+}
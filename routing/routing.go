@@ -0,0 +1,312 @@
+// Package routing provide a routing driver, it dispatches each key to a named driver in the Manager
+// by the prefix, glob or regex rules.
+//
+// Usage:
+//
+//	cache.Register(goredis.Name, goredis.Connect("127.0.0.1:6379", "", 0))
+//	cache.Register(cache.DvrMemory, cache.NewMemoryCache())
+//
+//	r := routing.New(cache.Std(), cache.DvrMemory).
+//		Prefix("session:", goredis.Name).
+//		Glob("rate:*:limit", goredis.Name)
+//
+//	cache.Register(routing.Name, r)
+//	cache.DefaultUse(routing.Name)
+//
+//	// the key will be saved to redis
+//	cache.Set("session:abc", "value", 0)
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/gsr"
+)
+
+// Name driver name
+const Name = "routing"
+
+// ErrLoop the route points to the router itself
+var ErrLoop = errors.New("routing: the route points to the router itself")
+
+// RouteError the error of an operation on a route driver
+type RouteError struct {
+	// Driver name
+	Driver string
+	Err    error
+}
+
+// Error string
+func (e *RouteError) Error() string {
+	return fmt.Sprintf("routing: driver %s: %v", e.Driver, e.Err)
+}
+
+// Unwrap the driver error
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// a route rule
+type route struct {
+	match  func(key string) bool
+	driver string
+}
+
+// Router the routing driver
+type Router struct {
+	*rules
+	m *cache.Manager
+	// context for operate
+	ctx context.Context
+}
+
+// the route rules shared by the driver copies
+type rules struct {
+	mu     sync.RWMutex
+	routes []route
+	// default driver name
+	def string
+}
+
+// New create a Router on the Manager, the def is the default driver name for the unmatched keys.
+//
+// The drivers are looked up from the Manager on each operation, so they can be replaced or lazily created.
+// they are not wrapped by the hooks of Manager, the hooks run once on the router if it is got from the Manager.
+func New(m *cache.Manager, def string) *Router {
+	return &Router{
+		rules: &rules{def: def},
+		m:     m,
+	}
+}
+
+// Prefix add a route for the keys with the prefix
+func (r *Router) Prefix(prefix, driver string) *Router {
+	return r.Match(func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	}, driver)
+}
+
+// Glob add a route for the keys match the glob pattern, the syntax is same as path.Match. eg: "rate:*:limit"
+//
+// It panics if the pattern is malformed.
+func (r *Router) Glob(pattern, driver string) *Router {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic("routing: invalid glob pattern " + pattern + ": " + err.Error())
+	}
+
+	return r.Match(func(key string) bool {
+		ok, _ := path.Match(pattern, key)
+		return ok
+	}, driver)
+}
+
+// Regex add a route for the keys match the regexp. It panics if the expr cannot be parsed.
+func (r *Router) Regex(expr, driver string) *Router {
+	re := regexp.MustCompile(expr)
+	return r.Match(re.MatchString, driver)
+}
+
+// Match add a route by custom match func. the routes are checked in the order they are added.
+func (r *Router) Match(fn func(key string) bool, driver string) *Router {
+	r.mu.Lock()
+	r.routes = append(r.routes, route{match: fn, driver: driver})
+	r.mu.Unlock()
+	return r
+}
+
+// Route get the driver name of the key, returns the default driver if no route matched.
+func (r *Router) Route(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rt := range r.routes {
+		if rt.match(key) {
+			return rt.driver
+		}
+	}
+	return r.def
+}
+
+// Drivers get the driver names of all routes and the default, without duplicates.
+func (r *Router) Drivers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := []string{r.def}
+	for _, rt := range r.routes {
+		if !contains(names, rt.driver) {
+			names = append(names, rt.driver)
+		}
+	}
+	return names
+}
+
+// WithContext returns a copy of the driver for operate with ctx.
+// the copy shares the routes with the origin, and the ctx will be passed to the drivers.
+func (r *Router) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *r
+	cp.ctx = ctx
+	return &cp
+}
+
+/*************************************************************
+ * methods implements of the gsr.SimpleCacher
+ *************************************************************/
+
+// Has cache key
+func (r *Router) Has(key string) bool {
+	c, err := r.driver(r.Route(key))
+	return err == nil && c.Has(key)
+}
+
+// Get value by key
+func (r *Router) Get(key string) any {
+	c, err := r.driver(r.Route(key))
+	if err != nil {
+		return nil
+	}
+	return c.Get(key)
+}
+
+// Set value by key
+func (r *Router) Set(key string, val any, ttl time.Duration) error {
+	name := r.Route(key)
+	return r.do(name, func(c cache.Cache) error {
+		return c.Set(key, val, ttl)
+	})
+}
+
+// Del value by key
+func (r *Router) Del(key string) error {
+	name := r.Route(key)
+	return r.do(name, func(c cache.Cache) error {
+		return c.Del(key)
+	})
+}
+
+// GetMulti values by keys, the keys are split by route and the results are merged.
+func (r *Router) GetMulti(keys []string) map[string]any {
+	values := make(map[string]any, len(keys))
+	for name, group := range r.group(keys) {
+		c, err := r.driver(name)
+		if err != nil {
+			continue
+		}
+
+		for key, val := range c.GetMulti(group) {
+			values[key] = val
+		}
+	}
+	return values
+}
+
+// SetMulti values, the values are split by route.
+func (r *Router) SetMulti(values map[string]any, ttl time.Duration) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	var errs []error
+	for name, group := range r.group(keys) {
+		part := make(map[string]any, len(group))
+		for _, key := range group {
+			part[key] = values[key]
+		}
+
+		errs = append(errs, r.do(name, func(c cache.Cache) error {
+			return c.SetMulti(part, ttl)
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// DelMulti values by keys, the keys are split by route.
+func (r *Router) DelMulti(keys []string) error {
+	var errs []error
+	for name, group := range r.group(keys) {
+		errs = append(errs, r.do(name, func(c cache.Cache) error {
+			return c.DelMulti(group)
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// Clear all caches of the route drivers.
+//
+// NOTICE: the whole drivers will be cleared, include the keys not written by the router.
+func (r *Router) Clear() error {
+	var errs []error
+	for _, name := range r.Drivers() {
+		errs = append(errs, r.do(name, func(c cache.Cache) error {
+			return c.Clear()
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// Close do nothing, the drivers are managed by the Manager.
+func (r *Router) Close() error {
+	return nil
+}
+
+/*************************************************************
+ * helper methods
+ *************************************************************/
+
+// get the driver by name from the manager, without the hooks.
+// returns ErrLoop if the driver is the router itself.
+func (r *Router) driver(name string) (cache.Cache, error) {
+	c, err := r.m.LookupRaw(name)
+	if err != nil {
+		return nil, err
+	}
+	if rr, ok := cache.Unwrap(c).(*Router); ok && rr.rules == r.rules {
+		return nil, ErrLoop
+	}
+
+	if r.ctx != nil {
+		return cache.WithContext(c, r.ctx), nil
+	}
+	return c, nil
+}
+
+// run the operation on the driver, the error will be wrapped as RouteError
+func (r *Router) do(name string, fn func(c cache.Cache) error) error {
+	c, err := r.driver(name)
+	if err == nil {
+		err = fn(c)
+	}
+
+	if err != nil {
+		return &RouteError{Driver: name, Err: err}
+	}
+	return nil
+}
+
+// group the keys by route
+func (r *Router) group(keys []string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, key := range keys {
+		name := r.Route(key)
+		groups[name] = append(groups[name], key)
+	}
+	return groups
+}
+
+func contains(names []string, name string) bool {
+	for _, s := range names {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
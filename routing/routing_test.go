@@ -0,0 +1,128 @@
+package routing_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/cache/gocache"
+	"github.com/gookit/cache/routing"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func Example() {
+	m := cache.NewManager()
+	remote, local := gocache.NewSimple(), cache.NewMemoryCache() // eg: redis and local memory
+	m.Register("remote", remote).Register("local", local)
+
+	r := routing.New(m, "local").Prefix("session:", "remote")
+	m.Register(routing.Name, r)
+	m.DefaultUse(routing.Name)
+
+	_ = m.Set("session:abc", "value", 0)
+	_ = m.Set("other", "value", 0)
+	fmt.Println(remote.Has("session:abc"), local.Has("other"))
+
+	// Output:
+	// true true
+}
+
+func TestRouter_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		m := cache.NewManager()
+		m.Register("a", gocache.NewSimple()).Register("b", gocache.NewSimple())
+		return routing.New(m, "a").Prefix("k1", "b").Regex(`^(key|num)$`, "b")
+	})
+}
+
+func TestRouter(t *testing.T) {
+	is := assert.New(t)
+	m := cache.NewManager()
+	d1, d2, d3 := gocache.NewSimple(), gocache.NewSimple(), gocache.NewSimple()
+	m.Register("d1", d1).Register("d2", d2).Register("d3", d3)
+
+	r := routing.New(m, "d1").
+		Prefix("session:", "d2").
+		Glob("rate:*:limit", "d3").
+		Regex(`^user:\d+$`, "d3")
+	is.Eq([]string{"d1", "d2", "d3"}, r.Drivers())
+
+	is.Eq("d2", r.Route("session:abc"))
+	is.Eq("d3", r.Route("rate:api:limit"))
+	is.Eq("d1", r.Route("rate:api:count"))
+	is.Eq("d3", r.Route("user:12"))
+	is.Eq("d1", r.Route("user:abc"))
+
+	// multi keys are split by route
+	is.NoErr(r.SetMulti(map[string]any{"session:abc": 1, "rate:api:limit": 2, "user:12": 3, "other": 4}, 0))
+	is.True(d2.Has("session:abc"))
+	is.True(d3.Has("rate:api:limit"))
+	is.True(d3.Has("user:12"))
+	is.True(d1.Has("other"))
+	is.False(d1.Has("session:abc"))
+
+	vals := r.GetMulti([]string{"session:abc", "user:12", "other", "not-exist"})
+	is.Eq(map[string]any{"session:abc": 1, "user:12": 3, "other": 4}, vals)
+
+	is.NoErr(r.DelMulti([]string{"session:abc", "user:12"}))
+	is.False(d2.Has("session:abc"))
+	is.False(d3.Has("user:12"))
+	is.True(r.Has("rate:api:limit"))
+
+	is.NoErr(r.Clear())
+	is.False(d1.Has("other"))
+	is.False(d3.Has("rate:api:limit"))
+	is.NoErr(r.Close())
+
+	is.Panics(func() {
+		r.Glob("[a-", "d1")
+	})
+}
+
+func TestRouter_missingDriver(t *testing.T) {
+	is := assert.New(t)
+	m := cache.NewManager()
+	m.Register("d1", gocache.NewSimple())
+	r := routing.New(m, "d1").Prefix("remote:", "not-exist")
+
+	err := r.Set("remote:key", "value", 0)
+	var re *routing.RouteError
+	is.True(errors.As(err, &re))
+	is.Eq("not-exist", re.Driver)
+	is.ErrMsg(err, "routing: driver not-exist: cache driver: not-exist is not registered")
+
+	is.Nil(r.Get("remote:key"))
+	is.False(r.Has("remote:key"))
+	is.Err(r.SetMulti(map[string]any{"remote:key": 1, "key": 2}, 0))
+	is.Eq(map[string]any{"key": 2}, r.GetMulti([]string{"remote:key", "key"}))
+
+	// lazy created by the manager
+	m.Configure("not-exist", "memory://")
+	is.NoErr(r.Set("remote:key", "value", 0))
+	is.Eq("value", m.Driver("not-exist").Get("remote:key"))
+}
+
+func TestRouter_hooksAndLoop(t *testing.T) {
+	is := assert.New(t)
+	m := cache.NewManager()
+	m.Register("d1", gocache.NewSimple())
+
+	var ops []string
+	m.UseHook(cache.HookFunc(func(_ context.Context, op *cache.Operation) {
+		ops = append(ops, op.Driver+"."+op.Name)
+	}))
+
+	// the hooks run once on the router
+	m.Register(routing.Name, routing.New(m, "d1").Prefix("self:", routing.Name))
+	r := m.Driver(routing.Name)
+	is.NoErr(r.Set("key", "value", 0))
+	is.Eq("value", r.Get("key"))
+	is.Eq([]string{"routing.Set", "routing.Get"}, ops)
+
+	// the route points to the router itself
+	is.ErrIs(r.Set("self:key", "value", 0), routing.ErrLoop)
+	is.Nil(r.Get("self:key"))
+}
@@ -0,0 +1,509 @@
+// Package sharded provide a sharded cache driver, it spreads the keys across the cache backends
+// by a consistent hash ring with virtual nodes.
+//
+//   - The multi keys operations are split per node and run in parallel.
+//   - With the replication factor R, a key will be written to R nodes, and read from the first available one.
+//   - Add or remove a node only moves the keys owned by it.
+//
+// Usage:
+//
+//	c := sharded.New(map[string]cache.Cache{
+//		"redis1": goredis.Connect("10.0.0.1:6379", "", 0),
+//		"redis2": goredis.Connect("10.0.0.2:6379", "", 0),
+//		"redis3": goredis.Connect("10.0.0.3:6379", "", 0),
+//	}, sharded.WithReplicas(2))
+//
+//	cache.Register(sharded.Name, c)
+package sharded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/gsr"
+)
+
+// Name driver name
+const Name = "sharded"
+
+// DefaultVirtualNodes default virtual nodes number of each node on the hash ring
+const DefaultVirtualNodes = 160
+
+// ErrNoNodes there is no node in the ring
+var ErrNoNodes = errors.New("sharded: no available nodes")
+
+// NodeError the error of an operation on a node
+type NodeError struct {
+	// Node name
+	Node string
+	Err  error
+}
+
+// Error string
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("sharded: node %s: %v", e.Node, e.Err)
+}
+
+// Unwrap the node error
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// Options for the Sharded
+type Options struct {
+	// VirtualNodes the virtual nodes number of each node on the hash ring. default is DefaultVirtualNodes
+	VirtualNodes int
+	// Replicas the replication factor, a key will be written to the number of nodes. default is 1
+	Replicas int
+}
+
+// WithVirtualNodes set the virtual nodes number of each node
+func WithVirtualNodes(n int) func(opt *Options) {
+	return func(opt *Options) {
+		opt.VirtualNodes = n
+	}
+}
+
+// WithReplicas set the replication factor
+func WithReplicas(n int) func(opt *Options) {
+	return func(opt *Options) {
+		opt.Replicas = n
+	}
+}
+
+// the shared state of the driver copies
+type state struct {
+	mu    sync.RWMutex
+	ring  *ring
+	nodes map[string]cache.Cache
+}
+
+// Sharded the sharded cache driver
+type Sharded struct {
+	*state
+	opt Options
+	// context for operate
+	ctx context.Context
+}
+
+// New create a Sharded driver by the named nodes. the names are used for locate the keys,
+// so keep them stable between restarts and instances.
+func New(nodes map[string]cache.Cache, optFns ...func(opt *Options)) *Sharded {
+	opt := Options{
+		VirtualNodes: DefaultVirtualNodes,
+		Replicas:     1,
+	}
+	for _, fn := range optFns {
+		fn(&opt)
+	}
+
+	if opt.VirtualNodes <= 0 {
+		opt.VirtualNodes = DefaultVirtualNodes
+	}
+	if opt.Replicas <= 0 {
+		opt.Replicas = 1
+	}
+
+	s := &Sharded{
+		state: &state{nodes: make(map[string]cache.Cache, len(nodes))},
+		opt:   opt,
+	}
+	for name, c := range nodes {
+		s.nodes[name] = c
+	}
+
+	s.ring = newRing(s.nodes, opt.VirtualNodes)
+	return s
+}
+
+// Add a node, the node with same name will be replaced.
+func (s *Sharded) Add(name string, c cache.Cache) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nodes[name] = c
+	s.ring = newRing(s.nodes, s.opt.VirtualNodes)
+}
+
+// Remove a node by name, returns the removed node. it will not close the node.
+func (s *Sharded) Remove(name string) cache.Cache {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.nodes[name]
+	if ok {
+		delete(s.nodes, name)
+		s.ring = newRing(s.nodes, s.opt.VirtualNodes)
+	}
+	return c
+}
+
+// Nodes get the sorted node names
+func (s *Sharded) Nodes() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.nodes))
+	for name := range s.nodes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Locate get the node names of the key, the first one is the primary node.
+func (s *Sharded) Locate(key string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ring.locate(key, s.opt.Replicas)
+}
+
+// WithContext returns a copy of the driver for operate with ctx.
+// the copy shares the nodes with the origin, and the ctx will be passed to the nodes.
+func (s *Sharded) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *s
+	cp.ctx = ctx
+	return &cp
+}
+
+// returns the context error if it is done
+func (s *Sharded) ctxErr() error {
+	if s.ctx != nil {
+		return s.ctx.Err()
+	}
+	return nil
+}
+
+/*************************************************************
+ * methods implements of the gsr.SimpleCacher
+ *************************************************************/
+
+// Has cache key in any replica node
+func (s *Sharded) Has(key string) bool {
+	if s.ctxErr() != nil {
+		return false
+	}
+
+	_, nodes := s.replicas(key)
+	for _, c := range nodes {
+		if c.Has(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get value by key from the first available replica node
+func (s *Sharded) Get(key string) any {
+	if s.ctxErr() != nil {
+		return nil
+	}
+
+	_, nodes := s.replicas(key)
+	for _, c := range nodes {
+		if val := c.Get(key); val != nil {
+			return val
+		}
+	}
+	return nil
+}
+
+// Set value by key to the replica nodes
+func (s *Sharded) Set(key string, val any, ttl time.Duration) error {
+	return s.eachReplica(key, func(c cache.Cache) error {
+		return c.Set(key, val, ttl)
+	})
+}
+
+// Del value by key from the replica nodes
+func (s *Sharded) Del(key string) error {
+	return s.eachReplica(key, func(c cache.Cache) error {
+		return c.Del(key)
+	})
+}
+
+// GetMulti values by keys. the keys are grouped by node and read in parallel,
+// the missing keys will be read from the next replica nodes.
+func (s *Sharded) GetMulti(keys []string) map[string]any {
+	if s.ctxErr() != nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	locs := make(map[string][]string, len(keys))
+	for _, key := range keys {
+		locs[key] = s.ring.locate(key, s.opt.Replicas)
+	}
+	s.mu.RUnlock()
+
+	values := make(map[string]any, len(keys))
+	missing := keys
+	for i := 0; i < s.opt.Replicas && len(missing) > 0; i++ {
+		groups := make(map[string][]string)
+		for _, key := range missing {
+			if loc := locs[key]; i < len(loc) {
+				groups[loc[i]] = append(groups[loc[i]], key)
+			}
+		}
+		if len(groups) == 0 {
+			break
+		}
+
+		var mu sync.Mutex
+		_ = s.parallel(groups, func(c cache.Cache, keys []string) error {
+			found := c.GetMulti(keys)
+			mu.Lock()
+			for key, val := range found {
+				if val != nil {
+					values[key] = val
+				}
+			}
+			mu.Unlock()
+			return nil
+		})
+
+		next := missing[:0:0]
+		for _, key := range missing {
+			if _, ok := values[key]; !ok {
+				next = append(next, key)
+			}
+		}
+		missing = next
+	}
+	return values
+}
+
+// SetMulti values to the replica nodes. the values are grouped by node and written in parallel.
+func (s *Sharded) SetMulti(values map[string]any, ttl time.Duration) error {
+	if err := s.ctxErr(); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	return s.parallel(s.group(keys), func(c cache.Cache, keys []string) error {
+		part := make(map[string]any, len(keys))
+		for _, key := range keys {
+			part[key] = values[key]
+		}
+		return c.SetMulti(part, ttl)
+	})
+}
+
+// DelMulti values by keys from the replica nodes. the keys are grouped by node and deleted in parallel.
+func (s *Sharded) DelMulti(keys []string) error {
+	if err := s.ctxErr(); err != nil {
+		return err
+	}
+
+	return s.parallel(s.group(keys), func(c cache.Cache, keys []string) error {
+		return c.DelMulti(keys)
+	})
+}
+
+// Clear all nodes in parallel
+func (s *Sharded) Clear() error {
+	if err := s.ctxErr(); err != nil {
+		return err
+	}
+
+	return s.parallel(s.group(nil), func(c cache.Cache, _ []string) error {
+		return c.Clear()
+	})
+}
+
+// Close all nodes
+func (s *Sharded) Close() error {
+	return s.parallel(s.group(nil), func(c cache.Cache, _ []string) error {
+		return c.Close()
+	})
+}
+
+/*************************************************************
+ * helper methods
+ *************************************************************/
+
+// get the names and the replica nodes of the key, they are resolved in one lock.
+func (s *Sharded) replicas(key string) ([]string, []cache.Cache) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := s.ring.locate(key, s.opt.Replicas)
+	list := make([]cache.Cache, 0, len(names))
+	for _, name := range names {
+		list = append(list, s.node(name))
+	}
+	return names, list
+}
+
+// get the node by name, must be called with lock.
+func (s *Sharded) node(name string) cache.Cache {
+	if s.ctx != nil {
+		return cache.WithContext(s.nodes[name], s.ctx)
+	}
+	return s.nodes[name]
+}
+
+// run the operation on each replica node of the key
+func (s *Sharded) eachReplica(key string, fn func(c cache.Cache) error) error {
+	if err := s.ctxErr(); err != nil {
+		return err
+	}
+
+	names, nodes := s.replicas(key)
+	if len(names) == 0 {
+		return ErrNoNodes
+	}
+
+	var errs []error
+	for i, c := range nodes {
+		if err := fn(c); err != nil {
+			errs = append(errs, &NodeError{Node: names[i], Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// group the keys by the replica nodes. if keys is nil, returns all nodes.
+func (s *Sharded) group(keys []string) map[string][]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groups := make(map[string][]string)
+	if keys == nil {
+		for name := range s.nodes {
+			groups[name] = nil
+		}
+		return groups
+	}
+
+	for _, key := range keys {
+		for _, name := range s.ring.locate(key, s.opt.Replicas) {
+			groups[name] = append(groups[name], key)
+		}
+	}
+	return groups
+}
+
+// run the operation on the grouped nodes in parallel, the errors will be joined.
+func (s *Sharded) parallel(groups map[string][]string, fn func(c cache.Cache, keys []string) error) error {
+	if len(groups) == 0 && len(s.Nodes()) == 0 {
+		return ErrNoNodes
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	s.mu.RLock()
+	for name, keys := range groups {
+		c := s.node(name)
+		if c == nil { // removed
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, c cache.Cache, keys []string) {
+			defer wg.Done()
+			if err := fn(c, keys); err != nil {
+				mu.Lock()
+				errs = append(errs, &NodeError{Node: name, Err: err})
+				mu.Unlock()
+			}
+		}(name, c, keys)
+	}
+	s.mu.RUnlock()
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+/*************************************************************
+ * consistent hash ring
+ *************************************************************/
+
+type point struct {
+	hash uint64
+	node string
+}
+
+type ring struct {
+	points []point
+	// the number of nodes
+	size int
+}
+
+func newRing(nodes map[string]cache.Cache, vnodes int) *ring {
+	r := &ring{
+		points: make([]point, 0, len(nodes)*vnodes),
+		size:   len(nodes),
+	}
+
+	for name := range nodes {
+		for i := 0; i < vnodes; i++ {
+			r.points = append(r.points, point{hash: hashKey(name + "#" + strconv.Itoa(i)), node: name})
+		}
+	}
+
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash == r.points[j].hash {
+			return r.points[i].node < r.points[j].node
+		}
+		return r.points[i].hash < r.points[j].hash
+	})
+	return r
+}
+
+// locate the n distinct nodes of the key, walk the ring clockwise from the key hash.
+func (r *ring) locate(key string, n int) []string {
+	if len(r.points) == 0 {
+		return nil
+	}
+
+	n = min(n, r.size)
+	h := hashKey(key)
+	start := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i].hash >= h
+	})
+
+	names := make([]string, 0, n)
+	for i := 0; i < len(r.points) && len(names) < n; i++ {
+		name := r.points[(start+i)%len(r.points)].node
+		if !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// hash the key by fnv-1a, and mix the bits for better distribution.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	// the finalizer of splitmix64
+	x := h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+func contains(names []string, name string) bool {
+	for _, s := range names {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package sharded_test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/cache/gocache"
+	"github.com/gookit/cache/sharded"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func Example() {
+	c := sharded.New(map[string]cache.Cache{
+		"node1": gocache.NewSimple(), // eg: redis, memcached
+		"node2": gocache.NewSimple(),
+		"node3": gocache.NewSimple(),
+	}, sharded.WithReplicas(2))
+
+	_ = c.Set("name", "cache value", cache.TwoMinutes)
+	fmt.Println(c.Get("name"), len(c.Locate("name")))
+
+	// Output:
+	// cache value 2
+}
+
+func newNodes(n int) map[string]cache.Cache {
+	nodes := make(map[string]cache.Cache, n)
+	for i := 0; i < n; i++ {
+		nodes["node"+strconv.Itoa(i)] = gocache.NewSimple()
+	}
+	return nodes
+}
+
+func TestSharded_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		return sharded.New(newNodes(3))
+	})
+
+	t.Run("replicas", func(t *testing.T) {
+		cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+			return sharded.New(newNodes(3), sharded.WithReplicas(2))
+		})
+	})
+}
+
+func TestSharded_distribution(t *testing.T) {
+	is := assert.New(t)
+	nodes := newNodes(4)
+	c := sharded.New(nodes)
+	is.Eq([]string{"node0", "node1", "node2", "node3"}, c.Nodes())
+
+	values := make(map[string]any, 10000)
+	for i := 0; i < 10000; i++ {
+		values["key"+strconv.Itoa(i)] = i
+	}
+	is.NoErr(c.SetMulti(values, 0))
+
+	// each node owns about 1/4 keys
+	for name, node := range nodes {
+		n := len(node.(*gocache.GoCache).Db().Items())
+		is.True(n > 1500 && n < 3500, "node %s has %d keys", name, n)
+	}
+
+	// each key is stored on the located node only
+	for i := 0; i < 100; i++ {
+		key := "key" + strconv.Itoa(i)
+		loc := c.Locate(key)
+		is.Len(loc, 1)
+		is.True(nodes[loc[0]].Has(key))
+	}
+}
+
+func TestSharded_membership(t *testing.T) {
+	is := assert.New(t)
+	c := sharded.New(newNodes(4))
+
+	keys := make([]string, 10000)
+	before := make(map[string]string, len(keys))
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+		before[keys[i]] = c.Locate(keys[i])[0]
+	}
+
+	// add a node, only the keys moved to the new node
+	c.Add("node4", gocache.NewSimple())
+	moved := 0
+	for _, key := range keys {
+		if loc := c.Locate(key)[0]; loc != before[key] {
+			is.Eq("node4", loc)
+			moved++
+		}
+	}
+	is.True(moved > 1000 && moved < 3000, "moved %d keys", moved)
+
+	// remove the node, the keys back to the origin nodes
+	is.NotNil(c.Remove("node4"))
+	is.Nil(c.Remove("node4"))
+	for _, key := range keys {
+		is.Eq(before[key], c.Locate(key)[0])
+	}
+
+	// remove a node, only the keys on it moved
+	c.Remove("node0")
+	for _, key := range keys {
+		if before[key] != "node0" {
+			is.Eq(before[key], c.Locate(key)[0])
+		}
+	}
+}
+
+func TestSharded_membership_concurrent(t *testing.T) {
+	is := assert.New(t)
+	c := sharded.New(newNodes(2), sharded.WithReplicas(3))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			c.Add("node9", gocache.NewSimple())
+			c.Remove("node9")
+		}
+	}()
+
+	for i := 0; i < 2000; i++ {
+		is.NoErr(c.Set("key"+strconv.Itoa(i), i, 0))
+		is.NoErr(c.Del("key" + strconv.Itoa(i)))
+	}
+	<-done
+}
+
+func TestSharded_replicas(t *testing.T) {
+	is := assert.New(t)
+	nodes := newNodes(3)
+	c := sharded.New(nodes, sharded.WithReplicas(2))
+
+	is.NoErr(c.Set("key", "value", 0))
+	loc := c.Locate("key")
+	is.Len(loc, 2)
+	is.NotEq(loc[0], loc[1])
+	for _, name := range loc {
+		is.Eq("value", nodes[name].Get("key"))
+	}
+
+	// read from the next replica on the primary lost
+	is.NoErr(nodes[loc[0]].Clear())
+	is.Eq("value", c.Get("key"))
+	is.True(c.Has("key"))
+
+	is.NoErr(c.SetMulti(map[string]any{"k1": 1, "k2": 2, "k3": 3}, 0))
+	for name := range nodes {
+		is.NoErr(nodes[name].Del("k1"))
+		break
+	}
+	is.Eq(map[string]any{"k1": 1, "k2": 2, "k3": 3}, c.GetMulti([]string{"k1", "k2", "k3", "k4"}))
+
+	is.NoErr(c.DelMulti([]string{"k1", "k2", "k3"}))
+	for _, node := range nodes {
+		is.Empty(node.GetMulti([]string{"k1", "k2", "k3"}))
+	}
+
+	// the replicas are bounded by the nodes number
+	is.Len(sharded.New(newNodes(1), sharded.WithReplicas(3)).Locate("key"), 1)
+}
+
+// a node always failed on write
+type failedNode struct {
+	cache.Cache
+}
+
+var errWrite = errors.New("write failed")
+
+func (f *failedNode) Set(string, any, time.Duration) error {
+	return errWrite
+}
+
+func (f *failedNode) SetMulti(map[string]any, time.Duration) error {
+	return errWrite
+}
+
+func TestSharded_errors(t *testing.T) {
+	is := assert.New(t)
+
+	c := sharded.New(nil)
+	is.ErrIs(c.Set("key", "value", 0), sharded.ErrNoNodes)
+	is.ErrIs(c.Clear(), sharded.ErrNoNodes)
+	is.Nil(c.Get("key"))
+	is.Empty(c.Locate("key"))
+
+	c.Add("bad", &failedNode{Cache: gocache.NewSimple()})
+	err := c.Set("key", "value", 0)
+	is.ErrIs(err, errWrite)
+	is.ErrMsg(err, "sharded: node bad: write failed")
+
+	var ne *sharded.NodeError
+	is.True(errors.As(err, &ne))
+	is.Eq("bad", ne.Node)
+
+	is.ErrIs(c.SetMulti(map[string]any{"k1": 1}, 0), errWrite)
+	is.NoErr(c.Close())
+}
@@ -0,0 +1,61 @@
+package cache
+
+import "time"
+
+// EvictionCounter interface definition. for drivers can report the evicted items number.
+//
+// The evictions are the items removed by the driver itself, eg: expired or out of size.
+// the explicit deletes are not counted.
+type EvictionCounter interface {
+	// Evictions get the number of the evicted items
+	Evictions() uint64
+}
+
+// Stats interface definition. for the drivers report the operation statistics.
+//
+// see the metrics.New() for record the stats of any cache.
+type Stats interface {
+	EvictionCounter
+	// Hits get the number of the keys found on read
+	Hits() uint64
+	// Misses get the number of the keys not found on read
+	Misses() uint64
+	// Sets get the number of the keys written
+	Sets() uint64
+	// Deletes get the number of the keys deleted
+	Deletes() uint64
+	// Errors get the number of the failed operations
+	Errors() uint64
+	// Latencies get the latency histograms of the operations, the key is operation name. eg: "Get"
+	Latencies() map[string]Histogram
+}
+
+// Histogram the latency distribution of an operation
+type Histogram struct {
+	// Bounds the upper bounds of the buckets, in ascending order.
+	Bounds []time.Duration
+	// Counts the number of the observations in each bucket, not cumulative.
+	// the length is len(Bounds)+1, the last one is for the observations greater than all bounds.
+	Counts []uint64
+	// Count the total number of the observations
+	Count uint64
+	// Sum the total latency of the observations
+	Sum time.Duration
+}
+
+// Mean get the mean latency of the observations
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// HitRatio get the hit ratio of the stats, returns 0 if no read.
+func HitRatio(s Stats) float64 {
+	hits, misses := s.Hits(), s.Misses()
+	if total := hits + misses; total > 0 {
+		return float64(hits) / float64(total)
+	}
+	return 0
+}
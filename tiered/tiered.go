@@ -0,0 +1,300 @@
+// Package tiered provide a multi-level cache driver, it composes the cache drivers as tiers.
+//
+// The first tier is the fastest one, eg: an in-process memory cache,
+// and the last tier is the backing one, eg: redis.
+//
+//   - Reads go through the tiers in order, and the hit value will be promoted into the faster tiers.
+//   - Writes and deletes go to all tiers, from the last tier to the first.
+//   - The values in the faster tiers have a bounded ttl, see Options.FastTTL
+//
+// Usage:
+//
+//	c := tiered.New(gocache.NewSimple(), goredis.Connect("127.0.0.1:6379", "", 0))
+//	c.WithOptions(tiered.WithFastTTL(cache.OneMinutes))
+//
+//	cache.Register(tiered.Name, c)
+package tiered
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/gsr"
+)
+
+// Name driver name
+const Name = "tiered"
+
+// DefaultFastTTL default max ttl for the values in the faster tiers
+const DefaultFastTTL = time.Minute
+
+// TierError the error of an operation on a tier
+type TierError struct {
+	// Tier index of the tiers, 0 is the fastest tier
+	Tier int
+	Err  error
+}
+
+// Error string
+func (e *TierError) Error() string {
+	return fmt.Sprintf("tiered: tier#%d: %v", e.Tier, e.Err)
+}
+
+// Unwrap the tier error
+func (e *TierError) Unwrap() error {
+	return e.Err
+}
+
+// Options for the Tiered
+type Options struct {
+	// FastTTL the max ttl for the values in the faster tiers(all tiers except the last).
+	// it limits how long a faster tier can serve a stale value. default is DefaultFastTTL
+	FastTTL time.Duration
+	// NoPromote disable promote the hit value into the faster tiers on read.
+	NoPromote bool
+}
+
+// WithFastTTL set the max ttl for the values in the faster tiers. ttl <= 0 will use the DefaultFastTTL
+func WithFastTTL(ttl time.Duration) func(opt *Options) {
+	return func(opt *Options) {
+		opt.FastTTL = ttl
+	}
+}
+
+// WithNoPromote disable promote the hit value into the faster tiers on read.
+func WithNoPromote() func(opt *Options) {
+	return func(opt *Options) {
+		opt.NoPromote = true
+	}
+}
+
+// Tiered the multi-level cache driver
+type Tiered struct {
+	tiers []cache.Cache
+	opt   Options
+	// context for operate
+	ctx context.Context
+}
+
+// New create a Tiered driver by tiers. the first tier is the fastest one.
+//
+// It panics if no tier is given.
+func New(tiers ...cache.Cache) *Tiered {
+	if len(tiers) == 0 {
+		panic("tiered: at least one tier is required")
+	}
+
+	return &Tiered{
+		tiers: tiers,
+		opt:   Options{FastTTL: DefaultFastTTL},
+	}
+}
+
+// WithOptions set the options
+func (t *Tiered) WithOptions(optFns ...func(opt *Options)) {
+	for _, fn := range optFns {
+		fn(&t.opt)
+	}
+
+	if t.opt.FastTTL <= 0 {
+		t.opt.FastTTL = DefaultFastTTL
+	}
+}
+
+// Tiers get the tier drivers
+func (t *Tiered) Tiers() []cache.Cache {
+	return t.tiers
+}
+
+// WithContext returns a copy of the driver for operate with ctx.
+// the ctx will be passed to the tiers that implement the cache.ContextCacher
+func (t *Tiered) WithContext(ctx context.Context) gsr.ContextCacher {
+	cp := *t
+	cp.ctx = ctx
+	cp.tiers = make([]cache.Cache, len(t.tiers))
+	for i, tier := range t.tiers {
+		cp.tiers[i] = cache.WithContext(tier, ctx)
+	}
+	return &cp
+}
+
+// returns the context error if it is done
+func (t *Tiered) ctxErr() error {
+	if t.ctx != nil {
+		return t.ctx.Err()
+	}
+	return nil
+}
+
+/*************************************************************
+ * methods implements of the gsr.SimpleCacher
+ *************************************************************/
+
+// Has cache key in any tier
+func (t *Tiered) Has(key string) bool {
+	if t.ctxErr() != nil {
+		return false
+	}
+
+	for _, tier := range t.tiers {
+		if tier.Has(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get value by key. the value found in a slower tier will be promoted into the faster tiers.
+func (t *Tiered) Get(key string) any {
+	if t.ctxErr() != nil {
+		return nil
+	}
+
+	for i, tier := range t.tiers {
+		if val := tier.Get(key); val != nil {
+			if i > 0 && !t.opt.NoPromote {
+				ttl := t.promoteTTL(tier, key)
+				for _, fast := range t.tiers[:i] {
+					_ = fast.Set(key, val, ttl)
+				}
+			}
+			return val
+		}
+	}
+	return nil
+}
+
+// Set value by key to all tiers
+func (t *Tiered) Set(key string, val any, ttl time.Duration) error {
+	return t.each(func(i int, tier cache.Cache) error {
+		return tier.Set(key, val, t.tierTTL(i, ttl))
+	})
+}
+
+// Del value by key from all tiers
+func (t *Tiered) Del(key string) error {
+	return t.each(func(_ int, tier cache.Cache) error {
+		return tier.Del(key)
+	})
+}
+
+// GetMulti values by keys. the values found in a slower tier will be promoted into the faster tiers.
+//
+// NOTICE: the promoted values always use the Options.FastTTL as ttl.
+func (t *Tiered) GetMulti(keys []string) map[string]any {
+	if t.ctxErr() != nil {
+		return nil
+	}
+
+	values := make(map[string]any, len(keys))
+	missing := keys
+	for i, tier := range t.tiers {
+		if len(missing) == 0 {
+			break
+		}
+
+		found := make(map[string]any, len(missing))
+		for key, val := range tier.GetMulti(missing) {
+			if val != nil {
+				found[key] = val
+				values[key] = val
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+
+		if i > 0 && !t.opt.NoPromote {
+			for _, fast := range t.tiers[:i] {
+				_ = fast.SetMulti(found, t.opt.FastTTL)
+			}
+		}
+
+		next := make([]string, 0, len(missing)-len(found))
+		for _, key := range missing {
+			if _, ok := found[key]; !ok {
+				next = append(next, key)
+			}
+		}
+		missing = next
+	}
+	return values
+}
+
+// SetMulti values to all tiers
+func (t *Tiered) SetMulti(values map[string]any, ttl time.Duration) error {
+	return t.each(func(i int, tier cache.Cache) error {
+		return tier.SetMulti(values, t.tierTTL(i, ttl))
+	})
+}
+
+// DelMulti values by keys from all tiers
+func (t *Tiered) DelMulti(keys []string) error {
+	return t.each(func(_ int, tier cache.Cache) error {
+		return tier.DelMulti(keys)
+	})
+}
+
+// Clear all tiers
+func (t *Tiered) Clear() error {
+	return t.each(func(_ int, tier cache.Cache) error {
+		return tier.Clear()
+	})
+}
+
+// Close all tiers
+func (t *Tiered) Close() error {
+	var errs []error
+	for i, tier := range t.tiers {
+		if err := tier.Close(); err != nil {
+			errs = append(errs, &TierError{Tier: i, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+/*************************************************************
+ * helper methods
+ *************************************************************/
+
+// run the operation on all tiers, from the last tier to the first.
+// the errors of the tiers will be joined, each one is a *TierError.
+func (t *Tiered) each(fn func(i int, tier cache.Cache) error) error {
+	if err := t.ctxErr(); err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := len(t.tiers) - 1; i >= 0; i-- {
+		if err := fn(i, t.tiers[i]); err != nil {
+			errs = append(errs, &TierError{Tier: i, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// the ttl for write to the tier. the faster tiers are bounded by FastTTL
+func (t *Tiered) tierTTL(i int, ttl time.Duration) time.Duration {
+	if i == len(t.tiers)-1 {
+		return ttl
+	}
+
+	if ttl <= 0 || ttl > t.opt.FastTTL {
+		return t.opt.FastTTL
+	}
+	return ttl
+}
+
+// the ttl for promote the value from the tier. the remaining ttl will be
+// used if the tier implements the cache.TTLer
+func (t *Tiered) promoteTTL(tier cache.Cache, key string) time.Duration {
+	if tl, ok := tier.(cache.TTLer); ok {
+		if ttl, err := tl.TTL(key); err == nil {
+			return t.tierTTL(0, ttl)
+		}
+	}
+	return t.opt.FastTTL
+}
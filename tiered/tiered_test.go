@@ -0,0 +1,120 @@
+package tiered_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/cache/gocache"
+	"github.com/gookit/cache/tiered"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func Example() {
+	l1 := cache.NewMemoryCache()
+	l2 := gocache.NewSimple() // eg: redis, memcached
+	c := tiered.New(l1, l2)
+
+	// write to all tiers
+	_ = c.Set("name", "cache value", cache.TwoMinutes)
+	fmt.Println(l1.Has("name"), l2.Has("name"))
+
+	// the value in l2 will be promoted into l1 on read
+	_ = l1.Del("name")
+	fmt.Println(c.Get("name"), l1.Has("name"))
+
+	// Output:
+	// true true
+	// cache value true
+}
+
+func TestTiered_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		return tiered.New(gocache.NewSimple(), gocache.NewSimple())
+	})
+}
+
+func TestTiered_promote(t *testing.T) {
+	is := assert.New(t)
+	l1, l2 := gocache.NewSimple(), gocache.NewSimple()
+	c := tiered.New(l1, l2)
+	c.WithOptions(tiered.WithFastTTL(cache.OneMinutes))
+
+	// the ttl in faster tier is bounded
+	is.NoErr(c.Set("key", "value", cache.Forever))
+	ttl, err := l1.TTL("key")
+	is.NoErr(err)
+	is.Gt(ttl, cache.Seconds30)
+	is.Lte(ttl, cache.OneMinutes)
+	ttl, err = l2.TTL("key")
+	is.NoErr(err)
+	is.Eq(time.Duration(cache.Forever), ttl)
+
+	// promote with the remaining ttl
+	is.NoErr(l1.Del("key"))
+	is.NoErr(l2.Set("key2", "value2", cache.Seconds10))
+	is.Eq("value", c.Get("key"))
+	is.Eq("value2", c.Get("key2"))
+	ttl, err = l1.TTL("key")
+	is.NoErr(err)
+	is.Gt(ttl, cache.Seconds30)
+	ttl, err = l1.TTL("key2")
+	is.NoErr(err)
+	is.Lte(ttl, cache.Seconds10)
+
+	// multi
+	is.NoErr(l2.SetMulti(map[string]any{"k1": "v1", "k2": "v2"}, 0))
+	is.NoErr(l1.Set("k1", "v1-l1", 0))
+	vals := c.GetMulti([]string{"k1", "k2", "k3"})
+	is.Eq(map[string]any{"k1": "v1-l1", "k2": "v2"}, vals)
+	is.Eq("v2", l1.Get("k2"))
+	is.Nil(c.Get("k3"))
+
+	// no promote
+	c.WithOptions(tiered.WithNoPromote())
+	is.NoErr(l2.Set("key3", "value3", 0))
+	is.Eq("value3", c.Get("key3"))
+	is.False(l1.Has("key3"))
+}
+
+// a tier always failed on write
+type failedTier struct {
+	cache.Cache
+}
+
+var errWrite = errors.New("write failed")
+
+func (f *failedTier) Set(string, any, time.Duration) error {
+	return errWrite
+}
+
+func (f *failedTier) Close() error {
+	return errWrite
+}
+
+func TestTiered_errors(t *testing.T) {
+	is := assert.New(t)
+	l1, l2 := gocache.NewSimple(), &failedTier{Cache: gocache.NewSimple()}
+	c := tiered.New(l1, l2)
+
+	// the error of tier is reported, and other tiers are still written
+	err := c.Set("key", "value", 0)
+	is.ErrIs(err, errWrite)
+	is.True(l1.Has("key"))
+
+	var te *tiered.TierError
+	is.True(errors.As(err, &te))
+	is.Eq(1, te.Tier)
+	is.ErrMsg(err, "tiered: tier#1: write failed")
+
+	is.NoErr(c.Del("key"))
+	is.False(c.Has("key"))
+	is.ErrIs(c.Close(), errWrite)
+
+	is.Panics(func() {
+		tiered.New()
+	})
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"errors"
+)
+
+// Tracer a minimal tracer interface, it is easy to adapt the OpenTelemetry tracer.
+//
+// Example for OpenTelemetry:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, cache.Span) {
+//		ctx, span := t.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+//		return ctx, otelSpan{span}
+//	}
+type Tracer interface {
+	// Start a span with name, returns the ctx with the span.
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span a minimal span interface
+type Span interface {
+	// SetAttribute set an attribute of the span
+	SetAttribute(key string, val any)
+	// RecordError record the error and mark the span failed
+	RecordError(err error)
+	// End the span
+	End()
+}
+
+// span attribute keys of the TracingHook
+const (
+	AttrDriver    = "cache.driver"
+	AttrOperation = "cache.operation"
+	AttrKey       = "cache.key"
+	AttrKeysCount = "cache.keys_count"
+	AttrResult    = "cache.result"
+	AttrHits      = "cache.hits"
+)
+
+// TracingHook create a hook for start a span around each operation, the span name is "cache.{Operation}". eg: "cache.Get"
+//
+// The span has the attributes: AttrDriver, AttrOperation, AttrResult, and AttrKey for single key,
+// AttrKeysCount for multi keys, AttrHits for multi read.
+// The ErrNotFound is not recorded as error.
+func TracingHook(tracer Tracer) Hook {
+	return &tracingHook{tracer: tracer}
+}
+
+type tracingHook struct {
+	tracer Tracer
+}
+
+type spanCtxKey struct{}
+
+// BeforeOp start the span
+func (t *tracingHook) BeforeOp(ctx context.Context, op *Operation) context.Context {
+	ctx, span := t.tracer.Start(ctx, "cache."+op.Name)
+	return context.WithValue(ctx, spanCtxKey{}, span)
+}
+
+// AfterOp end the span
+func (t *tracingHook) AfterOp(ctx context.Context, op *Operation) {
+	span, ok := ctx.Value(spanCtxKey{}).(Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttribute(AttrDriver, op.Driver)
+	span.SetAttribute(AttrOperation, op.Name)
+	span.SetAttribute(AttrResult, op.Result.String())
+	if len(op.Keys) == 1 {
+		span.SetAttribute(AttrKey, op.Keys[0])
+	} else if len(op.Keys) > 1 {
+		span.SetAttribute(AttrKeysCount, len(op.Keys))
+		if op.Name == "GetMulti" {
+			span.SetAttribute(AttrHits, op.Hits)
+		}
+	}
+
+	if op.Err != nil && !errors.Is(op.Err, ErrNotFound) {
+		span.RecordError(op.Err)
+	}
+	span.End()
+}
@@ -0,0 +1,678 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/gookit/gsr"
+)
+
+// Call the info of an operation for the interceptors
+type Call struct {
+	// Op name of the operation. eg: "Get", "SetMulti", "Incr"
+	Op string
+	// Keys of the operation, it is empty for Clear. the interceptors can change them,
+	// it is a copy of the keys passed by caller.
+	Keys []string
+	// Values to write for the write operations, the Values[i] is the value of Keys[i].
+	// eg: Set, SetMulti, Add, CompareAndSwap. the interceptors can change them.
+	Values []any
+	// TTL for the write operations
+	TTL time.Duration
+	// Result of the operation, it is set by the driver call and can be changed by the interceptors. types:
+	//
+	//	Get: any, Has: bool, GetMulti: map[string]any, Incr...: int64, IncrByFloat: float64
+	//	Add, Replace, CompareAndSwap, CompareAndDelete: bool, TTL: time.Duration
+	Result any
+}
+
+// IsRead check the operation is a read: Get, Has, GetMulti, TTL
+func (c *Call) IsRead() bool {
+	switch c.Op {
+	case "Get", "Has", "GetMulti", "TTL":
+		return true
+	}
+	return false
+}
+
+// Hits get the number of the keys found by the read operation, the nil value is not found.
+// it should be called after the operation.
+func (c *Call) Hits() (found int) {
+	switch val := c.Result.(type) {
+	case bool:
+		if val && c.Op == "Has" {
+			found = 1
+		}
+	case map[string]any:
+		for _, key := range c.Keys {
+			if val[key] != nil {
+				found++
+			}
+		}
+	default:
+		if val != nil && c.IsRead() {
+			found = 1
+		}
+	}
+	return
+}
+
+// Interceptor intercept the operations of the wrapped driver. it should call next to continue the pipeline,
+// the ctx passed to next will be passed to the driver if it implements ContextCacher.
+//
+// The returned error of the reads(Get, Has, GetMulti) is dropped, the zero value will be returned.
+type Interceptor func(ctx context.Context, call *Call, next func(ctx context.Context) error) error
+
+// Wrap the driver with interceptors, the interceptors are called in order for each operation.
+//
+// The optional interfaces(Counter, ConditionalSetter, CompareDeleter, TTLer, PrefixClearer) are preserved,
+// the returned cache implements them only if the driver implements them. the ContextCacher and
+// EvictionCounter are always implemented.
+//
+// Usage:
+//
+//	c := cache.Wrap(rds, cache.ValidateKeys(func(key string) error {
+//		if key == "" {
+//			return errors.New("empty key")
+//		}
+//		return nil
+//	}), cache.Retry(3, 10*time.Millisecond))
+func Wrap(c Cache, interceptors ...Interceptor) Cache {
+	return newWrapped(&wrapped{inner: c, interceptors: interceptors})
+}
+
+// Unwrap get the driver wrapped by Wrap(), returns c if it is not wrapped.
+func Unwrap(c Cache) Cache {
+	if w, ok := c.(interface{ Unwrap() Cache }); ok {
+		return w.Unwrap()
+	}
+	return c
+}
+
+// ValidateKeys create an interceptor to validate the keys of each operation.
+func ValidateKeys(fn func(key string) error) Interceptor {
+	return func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+		for _, key := range call.Keys {
+			if err := fn(key); err != nil {
+				return err
+			}
+		}
+		return next(ctx)
+	}
+}
+
+// Retry create an interceptor to retry the failed operation, times is the max retry times.
+//
+// The ErrNotFound, ErrNotNumber, ErrNotSupported and the ctx errors will not be retried.
+func Retry(times int, backoff time.Duration) Interceptor {
+	return func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+		err := next(ctx)
+		for i := 0; i < times && err != nil && retryable(err); i++ {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+			err = next(ctx)
+		}
+		return err
+	}
+}
+
+var errKeysValues = errors.New("cache: the Keys and Values of Call are mismatched")
+
+func retryable(err error) bool {
+	for _, e := range []error{ErrNotFound, ErrNotNumber, ErrNotSupported, context.Canceled, context.DeadlineExceeded} {
+		if errors.Is(err, e) {
+			return false
+		}
+	}
+	return true
+}
+
+// wrapped the base of the wrapped driver
+type wrapped struct {
+	inner        Cache
+	interceptors []Interceptor
+	// context for operate
+	ctx context.Context
+}
+
+// Unwrap get the wrapped driver
+func (w *wrapped) Unwrap() Cache {
+	return w.inner
+}
+
+// WithContext returns a copy of the driver for operate with ctx.
+// the ctx will be passed to the interceptors, and the driver if it implements ContextCacher.
+func (w *wrapped) WithContext(ctx context.Context) gsr.ContextCacher {
+	return newWrapped(&wrapped{
+		inner:        WithContext(w.inner, ctx),
+		interceptors: w.interceptors,
+		ctx:          ctx,
+	}).(gsr.ContextCacher)
+}
+
+// Evictions get the number of the evicted items of the driver. returns 0 if it is not EvictionCounter
+func (w *wrapped) Evictions() uint64 {
+	if ec, ok := w.inner.(EvictionCounter); ok {
+		return ec.Evictions()
+	}
+	return 0
+}
+
+// run the call through the interceptors, the fn do the operation on the driver.
+func (w *wrapped) run(call *Call, fn func(c Cache) error) error {
+	base := w.ctx
+	if base == nil {
+		base = context.Background()
+	}
+
+	handler := func(ctx context.Context) error {
+		c := w.inner
+		if ctx != base {
+			c = WithContext(c, ctx)
+		}
+		return fn(c)
+	}
+
+	for i := len(w.interceptors) - 1; i >= 0; i-- {
+		ic, next := w.interceptors[i], handler
+		handler = func(ctx context.Context) error {
+			return ic(ctx, call, next)
+		}
+	}
+	return handler(base)
+}
+
+/*************************************************************
+ * methods implements of the gsr.SimpleCacher
+ *************************************************************/
+
+// Has cache key
+func (w *wrapped) Has(key string) bool {
+	call := &Call{Op: "Has", Keys: []string{key}}
+	if w.run(call, func(c Cache) error {
+		call.Result = c.Has(call.Keys[0])
+		return nil
+	}) != nil {
+		return false
+	}
+
+	ok, _ := call.Result.(bool)
+	return ok
+}
+
+// Get value by key
+func (w *wrapped) Get(key string) any {
+	call := &Call{Op: "Get", Keys: []string{key}}
+	if w.run(call, func(c Cache) error {
+		call.Result = c.Get(call.Keys[0])
+		return nil
+	}) != nil {
+		return nil
+	}
+	return call.Result
+}
+
+// Set value by key
+func (w *wrapped) Set(key string, val any, ttl time.Duration) error {
+	call := &Call{Op: "Set", Keys: []string{key}, Values: []any{val}, TTL: ttl}
+	return w.run(call, func(c Cache) error {
+		return c.Set(call.Keys[0], call.Values[0], call.TTL)
+	})
+}
+
+// Del value by key
+func (w *wrapped) Del(key string) error {
+	call := &Call{Op: "Del", Keys: []string{key}}
+	return w.run(call, func(c Cache) error {
+		return c.Del(call.Keys[0])
+	})
+}
+
+// GetMulti values by keys
+func (w *wrapped) GetMulti(keys []string) map[string]any {
+	call := &Call{Op: "GetMulti", Keys: append([]string(nil), keys...)}
+	if w.run(call, func(c Cache) error {
+		call.Result = c.GetMulti(call.Keys)
+		return nil
+	}) != nil {
+		return nil
+	}
+
+	values, _ := call.Result.(map[string]any)
+	return values
+}
+
+// SetMulti values, the keys of Call are sorted. the values map is not changed by the interceptors.
+func (w *wrapped) SetMulti(values map[string]any, ttl time.Duration) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	vals := make([]any, len(keys))
+	for i, key := range keys {
+		vals[i] = values[key]
+	}
+
+	call := &Call{Op: "SetMulti", Keys: keys, Values: vals, TTL: ttl}
+	return w.run(call, func(c Cache) error {
+		if len(call.Keys) != len(call.Values) {
+			return errKeysValues
+		}
+
+		mp := make(map[string]any, len(call.Keys))
+		for i, key := range call.Keys {
+			mp[key] = call.Values[i]
+		}
+		return c.SetMulti(mp, call.TTL)
+	})
+}
+
+// DelMulti values by keys
+func (w *wrapped) DelMulti(keys []string) error {
+	call := &Call{Op: "DelMulti", Keys: append([]string(nil), keys...)}
+	return w.run(call, func(c Cache) error {
+		return c.DelMulti(call.Keys)
+	})
+}
+
+// Clear all caches
+func (w *wrapped) Clear() error {
+	return w.run(&Call{Op: "Clear"}, func(c Cache) error {
+		return c.Clear()
+	})
+}
+
+// Close the driver, it is not intercepted.
+func (w *wrapped) Close() error {
+	return w.inner.Close()
+}
+
+/*************************************************************
+ * the optional interfaces
+ *************************************************************/
+
+// the optional interfaces of the driver
+const (
+	hasCounter = 1 << iota
+	hasConditionalSetter
+	hasCompareDeleter
+	hasTTLer
+	hasPrefixClearer
+)
+
+type counterOps struct{ w *wrapped }
+
+// Incr increment the key value by 1
+func (o counterOps) Incr(key string, ttl ...time.Duration) (int64, error) {
+	return o.incrBy("Incr", key, 1, ttl)
+}
+
+// Decr decrement the key value by 1
+func (o counterOps) Decr(key string, ttl ...time.Duration) (int64, error) {
+	return o.incrBy("Decr", key, -1, ttl)
+}
+
+// IncrBy increment the key value by delta
+func (o counterOps) IncrBy(key string, delta int64, ttl ...time.Duration) (int64, error) {
+	return o.incrBy("IncrBy", key, delta, ttl)
+}
+
+func (o counterOps) incrBy(op, key string, delta int64, ttl []time.Duration) (int64, error) {
+	call := &Call{Op: op, Keys: []string{key}}
+	err := o.w.run(call, func(c Cache) (err error) {
+		call.Result, err = c.(Counter).IncrBy(call.Keys[0], delta, ttl...)
+		return err
+	})
+
+	n, _ := call.Result.(int64)
+	return n, err
+}
+
+// IncrByFloat increment the key value by float delta
+func (o counterOps) IncrByFloat(key string, delta float64, ttl ...time.Duration) (float64, error) {
+	call := &Call{Op: "IncrByFloat", Keys: []string{key}}
+	err := o.w.run(call, func(c Cache) (err error) {
+		call.Result, err = c.(Counter).IncrByFloat(call.Keys[0], delta, ttl...)
+		return err
+	})
+
+	n, _ := call.Result.(float64)
+	return n, err
+}
+
+type conditionalSetterOps struct{ w *wrapped }
+
+// Add set the key value only if the key does not exist
+func (o conditionalSetterOps) Add(key string, val any, ttl time.Duration) (bool, error) {
+	return o.w.runBool("Add", key, val, ttl, func(c Cache, key string, val any, ttl time.Duration) (bool, error) {
+		return c.(ConditionalSetter).Add(key, val, ttl)
+	})
+}
+
+// Replace set the key value only if the key already exists
+func (o conditionalSetterOps) Replace(key string, val any, ttl time.Duration) (bool, error) {
+	return o.w.runBool("Replace", key, val, ttl, func(c Cache, key string, val any, ttl time.Duration) (bool, error) {
+		return c.(ConditionalSetter).Replace(key, val, ttl)
+	})
+}
+
+// CompareAndSwap set the key value to newVal only if the current value is equals to oldVal
+func (o conditionalSetterOps) CompareAndSwap(key string, oldVal, newVal any, ttl time.Duration) (bool, error) {
+	return o.w.runBool("CompareAndSwap", key, newVal, ttl, func(c Cache, key string, val any, ttl time.Duration) (bool, error) {
+		return c.(ConditionalSetter).CompareAndSwap(key, oldVal, val, ttl)
+	})
+}
+
+type compareDeleterOps struct{ w *wrapped }
+
+// CompareAndDelete delete the key only if the current value is equals to oldVal.
+func (o compareDeleterOps) CompareAndDelete(key string, oldVal any) (bool, error) {
+	call := &Call{Op: "CompareAndDelete", Keys: []string{key}}
+	err := o.w.run(call, func(c Cache) (err error) {
+		call.Result, err = c.(CompareDeleter).CompareAndDelete(call.Keys[0], oldVal)
+		return err
+	})
+
+	ok, _ := call.Result.(bool)
+	return ok, err
+}
+
+// run the conditional write call, the val is in Call.Values
+func (w *wrapped) runBool(op, key string, val any, ttl time.Duration, fn func(c Cache, key string, val any, ttl time.Duration) (bool, error)) (bool, error) {
+	call := &Call{Op: op, Keys: []string{key}, Values: []any{val}, TTL: ttl}
+	err := w.run(call, func(c Cache) (err error) {
+		call.Result, err = fn(c, call.Keys[0], call.Values[0], call.TTL)
+		return err
+	})
+
+	ok, _ := call.Result.(bool)
+	return ok, err
+}
+
+type ttlerOps struct{ w *wrapped }
+
+// TTL get the remaining time to live of the key.
+func (o ttlerOps) TTL(key string) (time.Duration, error) {
+	call := &Call{Op: "TTL", Keys: []string{key}}
+	err := o.w.run(call, func(c Cache) (err error) {
+		ttl, err := c.(TTLer).TTL(call.Keys[0])
+		if err == nil {
+			call.Result = ttl
+		}
+		return err
+	})
+
+	ttl, _ := call.Result.(time.Duration)
+	return ttl, err
+}
+
+// Expire set a new ttl for the key.
+func (o ttlerOps) Expire(key string, ttl time.Duration) error {
+	call := &Call{Op: "Expire", Keys: []string{key}, TTL: ttl}
+	return o.w.run(call, func(c Cache) error {
+		return c.(TTLer).Expire(call.Keys[0], call.TTL)
+	})
+}
+
+// Persist remove the key expiration.
+func (o ttlerOps) Persist(key string) error {
+	call := &Call{Op: "Persist", Keys: []string{key}}
+	return o.w.run(call, func(c Cache) error {
+		return c.(TTLer).Persist(call.Keys[0])
+	})
+}
+
+// Touch refresh the key expiration to ttl from now.
+func (o ttlerOps) Touch(key string, ttl time.Duration) error {
+	call := &Call{Op: "Touch", Keys: []string{key}, TTL: ttl}
+	return o.w.run(call, func(c Cache) error {
+		return c.(TTLer).Touch(call.Keys[0], call.TTL)
+	})
+}
+
+type prefixClearerOps struct{ w *wrapped }
+
+// ClearPrefix delete the caches which key has the prefix. the prefix is in Call.Keys
+func (o prefixClearerOps) ClearPrefix(prefix string) error {
+	call := &Call{Op: "ClearPrefix", Keys: []string{prefix}}
+	return o.w.run(call, func(c Cache) error {
+		return c.(PrefixClearer).ClearPrefix(call.Keys[0])
+	})
+}
+
+// create the wrapped driver implements the same optional interfaces as the inner driver
+func newWrapped(w *wrapped) Cache {
+	var flags int
+	if _, ok := w.inner.(Counter); ok {
+		flags |= hasCounter
+	}
+	if _, ok := w.inner.(ConditionalSetter); ok {
+		flags |= hasConditionalSetter
+	}
+	if _, ok := w.inner.(CompareDeleter); ok {
+		flags |= hasCompareDeleter
+	}
+	if _, ok := w.inner.(TTLer); ok {
+		flags |= hasTTLer
+	}
+	if _, ok := w.inner.(PrefixClearer); ok {
+		flags |= hasPrefixClearer
+	}
+
+	ct, cs, cd, tl, pc := counterOps{w}, conditionalSetterOps{w}, compareDeleterOps{w}, ttlerOps{w}, prefixClearerOps{w}
+	switch flags {
+	case 0:
+		return w
+	case hasCounter:
+		return &struct {
+			*wrapped
+			counterOps
+		}{w, ct}
+	case hasConditionalSetter:
+		return &struct {
+			*wrapped
+			conditionalSetterOps
+		}{w, cs}
+	case hasCounter | hasConditionalSetter:
+		return &struct {
+			*wrapped
+			counterOps
+			conditionalSetterOps
+		}{w, ct, cs}
+	case hasCompareDeleter:
+		return &struct {
+			*wrapped
+			compareDeleterOps
+		}{w, cd}
+	case hasCounter | hasCompareDeleter:
+		return &struct {
+			*wrapped
+			counterOps
+			compareDeleterOps
+		}{w, ct, cd}
+	case hasConditionalSetter | hasCompareDeleter:
+		return &struct {
+			*wrapped
+			conditionalSetterOps
+			compareDeleterOps
+		}{w, cs, cd}
+	case hasCounter | hasConditionalSetter | hasCompareDeleter:
+		return &struct {
+			*wrapped
+			counterOps
+			conditionalSetterOps
+			compareDeleterOps
+		}{w, ct, cs, cd}
+	case hasTTLer:
+		return &struct {
+			*wrapped
+			ttlerOps
+		}{w, tl}
+	case hasCounter | hasTTLer:
+		return &struct {
+			*wrapped
+			counterOps
+			ttlerOps
+		}{w, ct, tl}
+	case hasConditionalSetter | hasTTLer:
+		return &struct {
+			*wrapped
+			conditionalSetterOps
+			ttlerOps
+		}{w, cs, tl}
+	case hasCounter | hasConditionalSetter | hasTTLer:
+		return &struct {
+			*wrapped
+			counterOps
+			conditionalSetterOps
+			ttlerOps
+		}{w, ct, cs, tl}
+	case hasCompareDeleter | hasTTLer:
+		return &struct {
+			*wrapped
+			compareDeleterOps
+			ttlerOps
+		}{w, cd, tl}
+	case hasCounter | hasCompareDeleter | hasTTLer:
+		return &struct {
+			*wrapped
+			counterOps
+			compareDeleterOps
+			ttlerOps
+		}{w, ct, cd, tl}
+	case hasConditionalSetter | hasCompareDeleter | hasTTLer:
+		return &struct {
+			*wrapped
+			conditionalSetterOps
+			compareDeleterOps
+			ttlerOps
+		}{w, cs, cd, tl}
+	case hasCounter | hasConditionalSetter | hasCompareDeleter | hasTTLer:
+		return &struct {
+			*wrapped
+			counterOps
+			conditionalSetterOps
+			compareDeleterOps
+			ttlerOps
+		}{w, ct, cs, cd, tl}
+	case hasPrefixClearer:
+		return &struct {
+			*wrapped
+			prefixClearerOps
+		}{w, pc}
+	case hasCounter | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			counterOps
+			prefixClearerOps
+		}{w, ct, pc}
+	case hasConditionalSetter | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			conditionalSetterOps
+			prefixClearerOps
+		}{w, cs, pc}
+	case hasCounter | hasConditionalSetter | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			counterOps
+			conditionalSetterOps
+			prefixClearerOps
+		}{w, ct, cs, pc}
+	case hasCompareDeleter | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			compareDeleterOps
+			prefixClearerOps
+		}{w, cd, pc}
+	case hasCounter | hasCompareDeleter | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			counterOps
+			compareDeleterOps
+			prefixClearerOps
+		}{w, ct, cd, pc}
+	case hasConditionalSetter | hasCompareDeleter | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			conditionalSetterOps
+			compareDeleterOps
+			prefixClearerOps
+		}{w, cs, cd, pc}
+	case hasCounter | hasConditionalSetter | hasCompareDeleter | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			counterOps
+			conditionalSetterOps
+			compareDeleterOps
+			prefixClearerOps
+		}{w, ct, cs, cd, pc}
+	case hasTTLer | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			ttlerOps
+			prefixClearerOps
+		}{w, tl, pc}
+	case hasCounter | hasTTLer | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			counterOps
+			ttlerOps
+			prefixClearerOps
+		}{w, ct, tl, pc}
+	case hasConditionalSetter | hasTTLer | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			conditionalSetterOps
+			ttlerOps
+			prefixClearerOps
+		}{w, cs, tl, pc}
+	case hasCounter | hasConditionalSetter | hasTTLer | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			counterOps
+			conditionalSetterOps
+			ttlerOps
+			prefixClearerOps
+		}{w, ct, cs, tl, pc}
+	case hasCompareDeleter | hasTTLer | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			compareDeleterOps
+			ttlerOps
+			prefixClearerOps
+		}{w, cd, tl, pc}
+	case hasCounter | hasCompareDeleter | hasTTLer | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			counterOps
+			compareDeleterOps
+			ttlerOps
+			prefixClearerOps
+		}{w, ct, cd, tl, pc}
+	case hasConditionalSetter | hasCompareDeleter | hasTTLer | hasPrefixClearer:
+		return &struct {
+			*wrapped
+			conditionalSetterOps
+			compareDeleterOps
+			ttlerOps
+			prefixClearerOps
+		}{w, cs, cd, tl, pc}
+	default: // all
+		return &struct {
+			*wrapped
+			counterOps
+			conditionalSetterOps
+			compareDeleterOps
+			ttlerOps
+			prefixClearerOps
+		}{w, ct, cs, cd, tl, pc}
+	}
+}
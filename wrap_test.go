@@ -0,0 +1,279 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gookit/cache"
+	"github.com/gookit/cache/cachetest"
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func ExampleWrap() {
+	c := cache.Wrap(cache.NewMemoryCache(), func(ctx context.Context, call *cache.Call, next func(ctx context.Context) error) error {
+		err := next(ctx)
+		fmt.Println(call.Op, call.Keys, err)
+		return err
+	})
+
+	_ = c.Set("key", "value", 0)
+	_ = c.Get("key")
+
+	// Output:
+	// Set [key] <nil>
+	// Get [key] <nil>
+}
+
+func TestWrap_suite(t *testing.T) {
+	cachetest.RunSuite(t, func(t *testing.T) cache.Cache {
+		return cache.Wrap(cache.NewMemoryCache(), cache.Retry(1, time.Millisecond))
+	}, cachetest.WithTTLPrecision(time.Second))
+}
+
+func TestWrap(t *testing.T) {
+	is := assert.New(t)
+	var calls []string
+	order := func(name string) cache.Interceptor {
+		return func(ctx context.Context, call *cache.Call, next func(ctx context.Context) error) error {
+			calls = append(calls, name+".before:"+call.Op)
+			err := next(ctx)
+			calls = append(calls, name+".after:"+call.Op)
+			return err
+		}
+	}
+
+	mc := cache.NewMemoryCache()
+	c := cache.Wrap(mc, order("i1"), order("i2"))
+	is.Eq(mc, cache.Unwrap(c))
+	is.Eq(mc, cache.Unwrap(mc))
+
+	is.NoErr(c.Set("key", "value", 0))
+	is.Eq([]string{"i1.before:Set", "i2.before:Set", "i2.after:Set", "i1.after:Set"}, calls)
+	is.Eq("value", c.Get("key"))
+	is.True(c.Has("key"))
+	is.NoErr(c.SetMulti(map[string]any{"k2": 2, "k1": 1}, 0))
+	is.Eq(map[string]any{"k1": 1, "k2": 2}, c.GetMulti([]string{"k1", "k2"}))
+	is.NoErr(c.DelMulti([]string{"k1", "k2"}))
+	is.NoErr(c.Del("key"))
+	is.NoErr(c.Clear())
+	is.Len(calls, 32)
+
+	// modify the values and result
+	uc := cache.Wrap(mc, func(ctx context.Context, call *cache.Call, next func(ctx context.Context) error) error {
+		for i, val := range call.Values {
+			call.Values[i] = strings.ToUpper(val.(string))
+		}
+		if err := next(ctx); err != nil {
+			return err
+		}
+		if call.Op == "Get" && call.Result == nil {
+			call.Result = "default"
+		}
+		return nil
+	})
+	is.NoErr(uc.Set("name", "inhere", 0))
+	is.Eq("INHERE", mc.Get("name"))
+	is.Eq("default", uc.Get("not-exist"))
+
+	// the map of SetMulti is not changed
+	values := map[string]any{"k1": "v1", "k2": "v2"}
+	is.NoErr(uc.SetMulti(values, 0))
+	is.Eq(map[string]any{"k1": "v1", "k2": "v2"}, values)
+	is.Eq("V2", mc.Get("k2"))
+
+	// the changed keys are used
+	pc := cache.Wrap(mc, func(ctx context.Context, call *cache.Call, next func(ctx context.Context) error) error {
+		for i, key := range call.Keys {
+			call.Keys[i] = "pfx:" + key
+		}
+		return next(ctx)
+	})
+	is.NoErr(pc.SetMulti(map[string]any{"k1": 1, "k2": 2}, 0))
+	is.Eq(2, mc.Get("pfx:k2"))
+	keys := []string{"k1", "k2"}
+	is.Eq(map[string]any{"pfx:k1": 1, "pfx:k2": 2}, pc.GetMulti(keys))
+	is.Eq([]string{"k1", "k2"}, keys)
+
+	// the mismatched keys and values
+	bc := cache.Wrap(mc, func(ctx context.Context, call *cache.Call, next func(ctx context.Context) error) error {
+		call.Keys = append(call.Keys, "extra")
+		return next(ctx)
+	})
+	is.Err(bc.SetMulti(map[string]any{"k1": 1}, 0))
+
+	// the interceptor error
+	errStop := errors.New("stop")
+	var driverCalled bool
+	sc := cache.Wrap(&closeDriver{Cache: mc}, func(context.Context, *cache.Call, func(ctx context.Context) error) error {
+		return errStop
+	}, func(ctx context.Context, call *cache.Call, next func(ctx context.Context) error) error {
+		driverCalled = true
+		return next(ctx)
+	})
+	is.ErrIs(sc.Set("name", "value", 0), errStop)
+	is.Nil(sc.Get("name"))
+	is.False(sc.Has("name"))
+	is.Nil(sc.GetMulti([]string{"name"}))
+	is.False(driverCalled)
+	is.Eq("INHERE", mc.Get("name"))
+	is.NoErr(sc.Close())
+}
+
+func TestWrap_optional(t *testing.T) {
+	is := assert.New(t)
+	var ops []string
+	record := func(ctx context.Context, call *cache.Call, next func(ctx context.Context) error) error {
+		ops = append(ops, call.Op)
+		return next(ctx)
+	}
+
+	// the memory driver implements all optional interfaces
+	mc := cache.NewMemoryCache()
+	c := cache.Wrap(mc, record)
+	_, ok := c.(cache.Counter)
+	is.True(ok)
+	_, ok = c.(cache.EvictionCounter)
+	is.True(ok)
+
+	ct := c.(cache.Counter)
+	n, err := ct.Incr("num")
+	is.NoErr(err)
+	is.Eq(int64(1), n)
+	n, err = ct.IncrBy("num", 5)
+	is.NoErr(err)
+	is.Eq(int64(6), n)
+	n, err = ct.Decr("num")
+	is.NoErr(err)
+	is.Eq(int64(5), n)
+	f, err := ct.IncrByFloat("float", 1.5)
+	is.NoErr(err)
+	is.Eq(1.5, f)
+
+	cs := c.(cache.ConditionalSetter)
+	ok, err = cs.Add("key", "v1", 0)
+	is.NoErr(err)
+	is.True(ok)
+	ok, err = cs.Replace("key", "v2", 0)
+	is.NoErr(err)
+	is.True(ok)
+	ok, err = cs.CompareAndSwap("key", "v2", "v3", 0)
+	is.NoErr(err)
+	is.True(ok)
+	ok, err = c.(cache.CompareDeleter).CompareAndDelete("key", "v1")
+	is.NoErr(err)
+	is.False(ok)
+
+	tl := c.(cache.TTLer)
+	is.NoErr(tl.Expire("key", time.Minute))
+	ttl, err := tl.TTL("key")
+	is.NoErr(err)
+	is.True(ttl > 0)
+	is.NoErr(tl.Touch("key", time.Minute))
+	is.NoErr(tl.Persist("key"))
+	_, err = tl.TTL("not-exist")
+	is.ErrIs(err, cache.ErrNotFound)
+
+	is.NoErr(c.(cache.PrefixClearer).ClearPrefix("nu"))
+	is.False(mc.Has("num"))
+	is.Eq([]string{
+		"Incr", "IncrBy", "Decr", "IncrByFloat", "Add", "Replace", "CompareAndSwap", "CompareAndDelete",
+		"Expire", "TTL", "Touch", "Persist", "TTL", "ClearPrefix",
+	}, ops)
+
+	// the copy with ctx keeps the interfaces
+	_, ok = cache.WithContext(c, context.Background()).(cache.TTLer)
+	is.True(ok)
+
+	// the driver without optional interfaces
+	nc := cache.Wrap(&ctxDriver{Cache: mc}, record)
+	_, ok = nc.(cache.Counter)
+	is.False(ok)
+	_, ok = nc.(cache.TTLer)
+	is.False(ok)
+	_, ok = nc.(cache.PrefixClearer)
+	is.False(ok)
+	is.Eq(uint64(0), nc.(cache.EvictionCounter).Evictions())
+}
+
+func TestWrap_helpers(t *testing.T) {
+	is := assert.New(t)
+	errEmpty := errors.New("empty key")
+	fd := &flakyDriver{Cache: cache.NewMemoryCache(), fails: 2}
+	c := cache.Wrap(fd, cache.ValidateKeys(func(key string) error {
+		if key == "" {
+			return errEmpty
+		}
+		return nil
+	}), cache.Retry(3, time.Millisecond))
+
+	is.ErrIs(c.Set("", "value", 0), errEmpty)
+	is.ErrIs(c.DelMulti([]string{"key", ""}), errEmpty)
+	is.Eq(0, fd.calls)
+
+	is.NoErr(c.Set("key", "value", 0))
+	is.Eq(3, fd.calls)
+
+	// retry limit
+	fd.calls, fd.fails = 0, 10
+	is.Err(c.Set("key", "value", 0))
+	is.Eq(4, fd.calls)
+
+	// stop retry on ctx done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fd.calls = 0
+	is.Err(cache.WithContext(c, ctx).Set("key", "value", 0))
+	is.Eq(1, fd.calls)
+
+	// not retry the ErrNotSupported
+	fd.calls, fd.err = 0, cache.ErrNotSupported
+	is.ErrIs(c.Set("key", "value", 0), cache.ErrNotSupported)
+	is.Eq(1, fd.calls)
+}
+
+func TestWrap_ctx(t *testing.T) {
+	is := assert.New(t)
+	var got []any
+	d := &ctxDriver{Cache: cache.NewMemoryCache(), got: &got}
+	c := cache.Wrap(d, func(ctx context.Context, call *cache.Call, next func(ctx context.Context) error) error {
+		return next(context.WithValue(ctx, spanNameKey{}, "wrap."+call.Op))
+	})
+
+	// the ctx passed to next is passed to the driver
+	is.Nil(c.Get("key"))
+	is.Eq([]any{"wrap.Get"}, got)
+
+	// the ctx of WithContext is passed to the interceptors
+	type userKey struct{}
+	var user any
+	uc := cache.Wrap(d, func(ctx context.Context, call *cache.Call, next func(ctx context.Context) error) error {
+		user = ctx.Value(userKey{})
+		return next(ctx)
+	})
+	cc := cache.WithContext(uc, context.WithValue(context.Background(), userKey{}, "inhere"))
+	is.Nil(cc.Get("key"))
+	is.Eq("inhere", user)
+}
+
+// a driver fails the first n writes
+type flakyDriver struct {
+	cache.Cache
+	fails int
+	calls int
+	err   error
+}
+
+func (d *flakyDriver) Set(key string, val any, ttl time.Duration) error {
+	d.calls++
+	if d.calls <= d.fails {
+		if d.err != nil {
+			return d.err
+		}
+		return errors.New("temporary failure")
+	}
+	return d.Cache.Set(key, val, ttl)
+}